@@ -0,0 +1,4 @@
+// schedulev1 package holds the generated protobuf messages and gRPC stubs of the schedule API
+package schedulev1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative schedule/v1/schedule.proto
@@ -1,36 +1,60 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/japb1998/action-scheduler/internal/controller/schedule"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/japb1998/action-scheduler/internal/app"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/logging"
 )
 
 func main() {
-	port := flag.Int("p", 8080, "port")
-	r := gin.Default()
+	configFile := flag.String("config", os.Getenv(config.FileEnv), "path to a YAML/TOML config file")
+	port := flag.Int("p", 0, "port. overrides http.port")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+
+	// the flags win over the file and the env, and are validated with them
+	cfg, err := config.Load(*configFile, func(c *config.Config) {
+		if *port != 0 {
+			c.HTTP.Port = *port
+		}
+	})
+
+	if err != nil {
+		slog.Error("invalid configuration", "error", err.Error())
+		os.Exit(1)
+	}
 
-	corsConfig := cors.DefaultConfig()
+	if err := logging.Setup(os.Stdout, cfg.Logging); err != nil {
+		slog.Error("invalid logging configuration", "error", err.Error())
+		os.Exit(1)
+	}
 
-	corsConfig.AllowOrigins = []string{"*"}
-	// To be able to send tokens to the server.
-	corsConfig.AllowCredentials = true
-	corsConfig.AllowHeaders = []string{"*"}
-	corsConfig.AddAllowMethods("OPTIONS", "GET", "PUT", "PATCH")
+	if *printConfig {
+		os.Stdout.WriteString(cfg.String())
+		return
+	}
+	slog.Info("effective config", "config", cfg.String())
 
-	r.Use(cors.New(corsConfig))
+	// SIGTERM is what kubernetes and docker send before killing the container
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	schedules := r.Group("/schedule")
+	a, err := app.New(ctx, cfg)
 
-	schedules.GET("", schedule.GetSchedules)
-	schedules.POST("", schedule.CreateSchedule)
-	schedules.GET("/:id", schedule.GetScheduleByID)
-	schedules.DELETE(":id", schedule.DeleteSchedule)
+	if err != nil {
+		slog.Error("failed to start", "error", err.Error())
+		os.Exit(1)
+	}
 
-	if err := r.Run(fmt.Sprintf(":%d", *port)); err != nil {
-		panic(err)
+	if err := a.Run(ctx); err != nil {
+		slog.Error("server stopped with error", "error", err.Error())
+		os.Exit(1)
 	}
 }
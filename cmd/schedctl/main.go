@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/japb1998/action-scheduler/internal/schedctl"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := schedctl.Run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+
+	os.Exit(code)
+}
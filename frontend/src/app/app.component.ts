@@ -1,4 +1,4 @@
-import { Component, OnInit } from '@angular/core';
+import { Component, OnDestroy, OnInit } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { RouterOutlet } from '@angular/router';
 import { FormsModule } from '@angular/forms';
@@ -7,6 +7,7 @@ enum NotificationType {
   Daily = "daily",
   OneTime = "one_time",
   Monthly = "monthly",
+  Weekly = "weekly",
 }
 
 type CreateSchedule = {
@@ -22,7 +23,7 @@ type CreateSchedule = {
   created_by: string
 }
 
-type  Schedule = CreateSchedule & { action: { name: string, id: string } }
+type  Schedule = CreateSchedule & { action: { name: string, id: string }, version: number }
 @Component({
   selector: 'app-root',
   standalone: true,
@@ -30,7 +31,7 @@ type  Schedule = CreateSchedule & { action: { name: string, id: string } }
   templateUrl: './app.component.html',
   styleUrl: './app.component.css'
 })
-export class AppComponent implements OnInit {
+export class AppComponent implements OnInit, OnDestroy {
   title = 'notification-handler';
   payload: any = ""
   type: string = NotificationType.OneTime
@@ -46,10 +47,39 @@ export class AppComponent implements OnInit {
   schedules: Schedule[] = [];
   payloadError: string = "";
   invalidPayload: boolean = false;
+  // pushes the changes of the schedules, EventSource reconnects on its own and resumes after the last event
+  private stream?: EventSource;
 
 
   ngOnInit() {
     this.loadSchedules();
+    this.watchSchedules();
+  }
+
+  ngOnDestroy() {
+    this.stream?.close();
+  }
+
+  watchSchedules() {
+    this.stream = new EventSource("http://localhost:8080/schedule/stream");
+
+    const upsert = (e: MessageEvent) => {
+      const { schedule }: { schedule: Schedule } = JSON.parse(e.data);
+      const i = this.schedules.findIndex((s) => s.id === schedule.id);
+      if (i === -1) {
+        this.schedules = [...this.schedules, schedule];
+      } else {
+        this.schedules = this.schedules.map((s) => s.id === schedule.id ? schedule : s);
+      }
+    };
+    this.stream.addEventListener("insert", upsert);
+    this.stream.addEventListener("update", upsert);
+    this.stream.addEventListener("delete", (e: MessageEvent) => {
+      const { schedule }: { schedule: Schedule } = JSON.parse(e.data);
+      this.schedules = this.schedules.filter((s) => s.id !== schedule.id);
+    });
+    // events were lost
+    this.stream.addEventListener("reset", () => this.loadSchedules());
   }
   onPayloadChange(event: any) {
    try {
@@ -65,6 +95,7 @@ export class AppComponent implements OnInit {
   protected get displayEndDate() {
     switch (this.type) {
       case NotificationType.Monthly:
+      case NotificationType.Weekly:
       case NotificationType.Daily:
         return true
       default:
@@ -158,11 +189,16 @@ export class AppComponent implements OnInit {
     }).catch(console.error);
   }
 
-  deleteSchedule(id: string) {
-    fetch(`http://localhost:8080/schedule/${id}`, {
-      method: "DELETE"
+  deleteSchedule(sch: Schedule) {
+    // the version listed is the ETag. someone else changed the schedule since when it no longer matches (412)
+    fetch(`http://localhost:8080/schedule/${sch.id}`, {
+      method: "DELETE",
+      headers: { "If-Match": `"${sch.version}"` }
     }).then((res) => {
-      this.schedules = this.schedules.filter((schedule) => schedule.id !== id);
+      if (res.status === 412) {
+        return this.loadSchedules();
+      }
+      this.schedules = this.schedules.filter((schedule) => schedule.id !== sch.id);
     }).catch(console.error);
   }
 }
@@ -0,0 +1,334 @@
+// app package builds the application dependencies explicitly and runs the HTTP server
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/database/mongodb"
+	"github.com/japb1998/action-scheduler/internal/database/sqlite"
+	"github.com/japb1998/action-scheduler/internal/grpcserver"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/metrics"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/service/action"
+	"github.com/japb1998/action-scheduler/internal/service/apikey"
+	"github.com/japb1998/action-scheduler/internal/service/audit"
+	"github.com/japb1998/action-scheduler/internal/service/health"
+	"github.com/japb1998/action-scheduler/internal/service/quota"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/service/schedule"
+	"github.com/japb1998/action-scheduler/internal/service/tenant"
+	"github.com/japb1998/action-scheduler/internal/service/webhook"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/tracing"
+	"github.com/japb1998/action-scheduler/pkg/awssess"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+)
+
+// Services are the dependencies the HTTP handlers are built from
+type Services struct {
+	Schedule *schedule.SchedulerService
+	Audit    *audit.AuditService
+	Tenant   *tenant.TenantService
+	RBAC     *rbac.RBACService
+	APIKey   *apikey.APIKeyService
+	Quota    *quota.QuotaService
+	Webhook  *webhook.WebhookService
+	Health   *health.HealthService
+	Verifier *auth.Verifier
+	// Shutdown is done once the HTTP server shuts down, it ends the event streams
+	Shutdown context.Context
+}
+
+// scheduleStore keeps the schedules in mongo or sqlite, per cfg.Store.Schedules
+type scheduleStore interface {
+	schedule.SchedulerStore
+	DeleteAll(ctx context.Context) (int64, error)
+	CountActive(ctx context.Context) ([]model.ScheduleCount, error)
+}
+
+type App struct {
+	cfg *config.Config
+	// mongo is nil with the sqlite store, sqlite is nil with the mongo store
+	mongo  *mongo.Client
+	sqlite *sql.DB
+	server *http.Server
+	// grpc is nil when the gRPC API is disabled
+	grpc *grpc.Server
+	// dispatcher sends the webhook deliveries, nil when they are stopped
+	dispatcher *webhook.Dispatcher
+	logger     *slog.Logger
+	// shutdownTracing flushes the pending spans
+	shutdownTracing func(context.Context) error
+}
+
+// New connects the client of the store and builds every dependency. the returned app owns the mongo client or the sqlite database.
+func New(ctx context.Context, cfg *config.Config) (*App, error) {
+	logger := slog.Default().With(slog.String("package", "app"))
+
+	// tracing goes first so the clients pick up the global tracer provider
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// clients. the sqlite store keeps every collection and never connects to mongo
+	var (
+		c        *mongo.Client
+		sqliteDB *sql.DB
+	)
+	if cfg.Store.Schedules == "sqlite" {
+		sqliteDB, err = sqlite.Open(ctx, cfg.Store.SQLitePath)
+	} else {
+		c, err = mongodb.Connect(ctx, cfg.Mongo)
+	}
+
+	if err != nil {
+		_ = shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	// closeClients releases the clients when New fails
+	closeClients := func() {
+		if c != nil {
+			_ = c.Disconnect(context.Background())
+		}
+		if sqliteDB != nil {
+			_ = sqliteDB.Close()
+		}
+		_ = shutdownTracing(context.Background())
+	}
+
+	sess, err := awssess.NewSession(cfg.AWS.SessionOptions())
+
+	if err != nil {
+		closeClients()
+		return nil, fmt.Errorf("failed to create aws session. error=%w", err)
+	}
+
+	verifier, err := auth.NewVerifierFromConfig(ctx, cfg.Auth)
+
+	if err != nil {
+		closeClients()
+		return nil, err
+	}
+
+	// dependencies
+	var (
+		st             stores
+		mongoSchedules *store.MongoScheduleStore
+		changes        schedule.Changes
+	)
+	if sqliteDB != nil {
+		st = sqliteStores(sqliteDB)
+	} else {
+		mongoSchedules = store.NewMongoScheduleStore(c, cfg.Mongo.Database)
+		if st, err = mongoStores(ctx, c, cfg.Mongo.Database, mongoSchedules); err != nil {
+			closeClients()
+			return nil, err
+		}
+		changes = mongoSchedules
+	}
+
+	// mongo changes are only valid with the mongo store, see config.Validate
+	if cfg.Store.ChangesSource() == "memory" {
+		broadcast := store.NewBroadcastScheduleStore(st.schedules)
+		st.schedules, changes = broadcast, broadcast
+	} else if err := mongoSchedules.EnableChangePreImages(ctx); err != nil {
+		// the stream still sees the inserts and updates
+		logger.Warn("error enabling schedule pre-images, the schedule stream misses the deletes", "error", err.Error())
+	}
+	sch := scheduler.NewScheduler(sess, &scheduler.SchedulerOps{
+		RetryAttempts: cfg.Scheduler.RetryAttempts,
+		Metrics:       metrics.SchedulerRecorder{},
+		Logger:        logging.FromContext,
+		RateLimit:     cfg.Scheduler.RateLimit,
+	})
+
+	activeSchedules := metrics.NewActiveSchedulesCollector(st.schedules.CountActive, cfg.Health.Timeout)
+	if err := metrics.Registry.Register(activeSchedules); err != nil {
+		logger.Warn("active schedules collector already registered", "error", err.Error())
+	}
+	actionSvc := action.New(cfg.Actions)
+	auditSvc := audit.New(st.audit)
+	quotaSvc := quota.New(st.quota, st.schedules, actionSvc, cfg.Quota)
+	tenantSvc := tenant.New(st.tenants, st.schedules, sch, quotaSvc, st.apiKeys, st.roles, st.webhooks, st.deliveries, auditSvc)
+	rbacSvc := rbac.New(st.roles, cfg.Auth.BootstrapAdmins)
+	webhookSvc := webhook.New(st.webhooks, st.deliveries, cfg.Webhooks)
+	var checks []health.Check
+	if c != nil {
+		checks = append(checks, health.Check{Name: "mongo", Check: func(ctx context.Context) error {
+			return mongodb.Ping(ctx, c)
+		}})
+	}
+	checks = append(checks,
+		health.Check{Name: "aws_credentials", Check: func(ctx context.Context) error {
+			_, err := sess.Config.Credentials.GetWithContext(ctx)
+			return err
+		}},
+		health.Check{Name: "scheduler", Check: sch.Ping},
+	)
+	if sqliteDB != nil {
+		checks = append(checks, health.Check{Name: "sqlite", Check: sqliteDB.PingContext})
+	}
+	healthSvc := health.New(cfg.Health.Timeout, checks...)
+
+	svc := Services{
+		Schedule: schedule.New(st.schedules, actionSvc, tenantSvc, rbacSvc, quotaSvc, sch, auditSvc, webhookSvc, changes, cfg.Batch),
+		Audit:    auditSvc,
+		Tenant:   tenantSvc,
+		RBAC:     rbacSvc,
+		APIKey:   apikey.New(st.apiKeys),
+		Quota:    quotaSvc,
+		Webhook:  webhookSvc,
+		Health:   healthSvc,
+		Verifier: verifier,
+	}
+	shutdown, stopStreams := context.WithCancel(context.Background())
+	svc.Shutdown = shutdown
+
+	a := &App{
+		cfg:    cfg,
+		mongo:  c,
+		sqlite: sqliteDB,
+		server: &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.HTTP.Port),
+			Handler: NewRouter(cfg, svc),
+		},
+		logger:          logger,
+		shutdownTracing: shutdownTracing,
+	}
+	a.server.RegisterOnShutdown(stopStreams)
+
+	if cfg.GRPC.Port != 0 {
+		a.grpc = grpcserver.New(svc.Schedule, svc.Verifier, svc.APIKey)
+	}
+
+	if cfg.Webhooks.Workers > 0 {
+		a.dispatcher = webhook.NewDispatcher(st.webhooks, st.deliveries, cfg.Webhooks)
+	}
+
+	return a, nil
+}
+
+// Run serves HTTP and gRPC and dispatches the webhook deliveries until ctx is done,
+// then drains in-flight requests and deliveries, disconnects the mongo client or closes the sqlite database and flushes the spans.
+func (a *App) Run(ctx context.Context) error {
+	errCh := make(chan error, 2)
+
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+	dispatched := make(chan struct{})
+
+	if a.dispatcher != nil {
+		go func() {
+			a.dispatcher.Run(dispatchCtx)
+			close(dispatched)
+		}()
+	} else {
+		close(dispatched)
+	}
+
+	go func() {
+		a.logger.Info("http server listening", "addr", a.server.Addr)
+		if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	if a.grpc != nil {
+		addr := fmt.Sprintf(":%d", a.cfg.GRPC.Port)
+		lis, err := net.Listen("tcp", addr)
+
+		if err != nil {
+			errCh <- fmt.Errorf("failed to listen for grpc. error=%w", err)
+		} else {
+			go func() {
+				a.logger.Info("grpc server listening", "addr", addr)
+				if err := a.grpc.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+					errCh <- err
+				}
+			}()
+		}
+	}
+
+	var runErr error
+	select {
+	case runErr = <-errCh:
+	case <-ctx.Done():
+		a.logger.Info("shutting down", "timeout", a.cfg.HTTP.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := a.server.Shutdown(shutdownCtx); err != nil {
+		a.logger.Error("error draining http server", "error", err.Error())
+		runErr = errors.Join(runErr, err)
+	}
+
+	if a.grpc != nil {
+		a.stopGRPC(shutdownCtx)
+	}
+
+	stopDispatch()
+	select {
+	case <-dispatched:
+	case <-shutdownCtx.Done():
+		a.logger.Error("error draining webhook deliveries", "error", shutdownCtx.Err().Error())
+	}
+
+	if a.mongo != nil {
+		if err := a.mongo.Disconnect(shutdownCtx); err != nil {
+			a.logger.Error("error disconnecting mongo", "error", err.Error())
+			runErr = errors.Join(runErr, err)
+		}
+	}
+
+	if a.sqlite != nil {
+		if err := a.sqlite.Close(); err != nil {
+			a.logger.Error("error closing sqlite", "error", err.Error())
+			runErr = errors.Join(runErr, err)
+		}
+	}
+
+	if err := a.shutdownTracing(shutdownCtx); err != nil {
+		a.logger.Error("error flushing spans", "error", err.Error())
+		runErr = errors.Join(runErr, err)
+	}
+
+	a.logger.Info("shutdown complete")
+	return runErr
+}
+
+// stopGRPC drains the in-flight calls and forces the stop once ctx is done
+func (a *App) stopGRPC(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		a.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		a.logger.Error("error draining grpc server", "error", ctx.Err().Error())
+		a.grpc.Stop()
+	}
+}
+
+// Handler exposes the router, mainly for tests
+func (a *App) Handler() http.Handler {
+	return a.server.Handler
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/controller/apikey"
+	"github.com/japb1998/action-scheduler/internal/controller/audit"
+	"github.com/japb1998/action-scheduler/internal/controller/health"
+	"github.com/japb1998/action-scheduler/internal/controller/quota"
+	"github.com/japb1998/action-scheduler/internal/controller/role"
+	"github.com/japb1998/action-scheduler/internal/controller/schedule"
+	"github.com/japb1998/action-scheduler/internal/controller/tenant"
+	"github.com/japb1998/action-scheduler/internal/controller/webhook"
+	"github.com/japb1998/action-scheduler/internal/metrics"
+	"github.com/japb1998/action-scheduler/internal/middleware"
+	"github.com/japb1998/action-scheduler/internal/openapi"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// NewRouter registers every route and its middleware
+func NewRouter(cfg *config.Config, svc Services) *gin.Engine {
+	// gin's own logger is replaced by the access log, which goes through the request logger.
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.CalendarKey())
+
+	corsConfig := cors.DefaultConfig()
+
+	// tokens are sent in the Authorization header so credentials (cookies) are not allowed.
+	corsConfig.AllowOrigins = cfg.HTTP.CORSAllowOrigins
+	corsConfig.AllowHeaders = []string{"Authorization", "Content-Type", middleware.RequestIDHeader, middleware.APIKeyHeader, "If-Match", "Last-Event-ID", "traceparent", "tracestate"}
+	corsConfig.ExposeHeaders = []string{middleware.RequestIDHeader, "ETag"}
+	corsConfig.AddAllowMethods("OPTIONS", "GET", "PUT", "PATCH")
+
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	r.Use(cors.New(corsConfig))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.AccessLog())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Errors())
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	r.GET("/openapi.json", gin.WrapH(openapi.Handler()))
+	r.GET("/docs", gin.WrapH(openapi.Docs()))
+
+	// probes, metrics and docs are not authenticated
+	healthHandler := health.NewHandler(svc.Health)
+	r.GET("/healthz", healthHandler.Healthz)
+	r.GET("/readyz", healthHandler.Readyz)
+
+	authenticated := r.Group("", middleware.Authenticate(svc.Verifier, svc.APIKey))
+	authz := svc.RBAC
+
+	scheduleHandler := schedule.NewHandler(svc.Schedule)
+	schedules := authenticated.Group("/schedule")
+
+	schedules.GET("", scheduleHandler.GetSchedules)
+	schedules.POST("", scheduleHandler.CreateSchedule)
+	schedules.GET("/export", scheduleHandler.ExportSchedules)
+	schedules.GET("/stream", middleware.Shutdown(svc.Shutdown), scheduleHandler.StreamSchedules)
+	schedules.POST("/import", scheduleHandler.ImportSchedules)
+	schedules.POST("/import/ics", scheduleHandler.ImportCalendar)
+	// also serves /schedule/:id.ics
+	schedules.GET("/:id", scheduleHandler.GetScheduleByID)
+	schedules.PUT("/:id", scheduleHandler.UpdateSchedule)
+	schedules.DELETE(":id", scheduleHandler.DeleteSchedule)
+	schedules.POST("/:id/runs", scheduleHandler.ReportRun)
+	// :batch and :batchDelete
+	authenticated.POST("/schedule:method", scheduleHandler.CustomMethod)
+	authenticated.GET("/calendar.ics", scheduleHandler.GetCalendarFeed)
+
+	authenticated.GET("/audit", middleware.RequirePermission(authz, rbac.OpReadAudit), audit.NewHandler(svc.Audit).GetAuditEntries)
+
+	quotaHandler := quota.NewHandler(svc.Quota)
+
+	authenticated.GET("/quota", quotaHandler.GetQuota)
+	authenticated.POST("/quota/reconcile", middleware.RequirePermission(authz, rbac.OpManageTenant), quotaHandler.ReconcileQuota)
+
+	tenantHandler := tenant.NewHandler(svc.Tenant)
+
+	authenticated.GET("/tenant", tenantHandler.GetTenant)
+	authenticated.PUT("/tenant", middleware.RequirePermission(authz, rbac.OpManageTenant), tenantHandler.UpsertTenant)
+	authenticated.DELETE("/tenant", middleware.RequirePermission(authz, rbac.OpManageTenant), tenantHandler.DeleteTenant)
+
+	roleHandler := role.NewHandler(svc.RBAC)
+	roles := authenticated.Group("/role", middleware.RequirePermission(authz, rbac.OpManageRoles))
+
+	roles.GET("", roleHandler.GetRoles)
+	roles.PUT("/:subject", roleHandler.UpsertRole)
+	roles.DELETE("/:subject", roleHandler.DeleteRole)
+
+	apiKeyHandler := apikey.NewHandler(svc.APIKey)
+	apiKeyRoutes := authenticated.Group("/apikey", middleware.RequirePermission(authz, rbac.OpManageAPIKeys))
+
+	apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+	apiKeyRoutes.GET("", apiKeyHandler.GetAPIKeys)
+	apiKeyRoutes.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+
+	webhookHandler := webhook.NewHandler(svc.Webhook)
+	webhooks := authenticated.Group("/webhook", middleware.RequirePermission(authz, rbac.OpManageWebhooks))
+
+	webhooks.POST("", webhookHandler.CreateWebhook)
+	webhooks.GET("", webhookHandler.GetWebhooks)
+	webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery/replay", webhookHandler.ReplayDelivery)
+
+	return r
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/openapi"
+	"github.com/japb1998/action-scheduler/internal/service/apikey"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/store"
+)
+
+// routes serving the document itself are not part of it
+var undocumented = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+// routes serving several documented paths, e.g. /schedule:batch
+var customMethods = map[string][]string{
+	"POST /schedule:method": {"/schedule:batch", "/schedule:batchDelete"},
+	"GET /schedule/:id":     {"/schedule/:id", "/schedule/:id.ics"},
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter(config.Default(), Services{})
+	doc := openapi.Build()
+
+	for _, route := range r.Routes() {
+		if undocumented[route.Method+" "+route.Path] {
+			continue
+		}
+		if paths, ok := customMethods[route.Method+" "+route.Path]; ok {
+			for _, path := range paths {
+				if !doc.Has(route.Method, path) {
+					t.Errorf("%s %s has no entry in the OpenAPI document. add it to openapi.Build", route.Method, path)
+				}
+			}
+			continue
+		}
+		if !doc.Has(route.Method, route.Path) {
+			t.Errorf("%s %s has no entry in the OpenAPI document. add it to openapi.Build", route.Method, route.Path)
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter(config.Default(), Services{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d. got=%d", http.StatusOK, w.Code)
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document. error=%s", err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("expected openapi %s. got=%s", openapi.Version, doc.OpenAPI)
+	}
+}
+
+// noRoles binds no subject, everyone is a viewer
+type noRoles struct{}
+
+func (noRoles) GetBySubject(ctx context.Context, subject string) (*model.RoleBinding, error) {
+	return nil, store.ErrRoleBindingNotFound
+}
+func (noRoles) Get(ctx context.Context) ([]model.RoleBinding, error)         { return nil, nil }
+func (noRoles) Upsert(ctx context.Context, binding *model.RoleBinding) error { return nil }
+func (noRoles) Delete(ctx context.Context, subject string) error             { return nil }
+
+// the privileged routes are rejected before reaching their handlers, which have no services here
+func TestPrivilegedRoutesRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("secret")
+	v, err := auth.NewVerifier(auth.VerifierOps{HMACSecret: secret})
+	if err != nil {
+		t.Fatalf("error creating verifier: %v", err)
+	}
+	r := NewRouter(config.Default(), Services{Verifier: v, RBAC: rbac.New(noRoles{}, nil)})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":       "viewer",
+		"tenant_id": "acme",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatalf("error signing: %v", err)
+	}
+
+	for _, route := range []string{
+		"PUT /tenant", "DELETE /tenant",
+		"GET /audit",
+		"POST /quota/reconcile",
+		"GET /role", "PUT /role/u1", "DELETE /role/u1",
+		"POST /apikey", "GET /apikey", "DELETE /apikey/1",
+		"POST /webhook", "GET /webhook", "DELETE /webhook/1",
+	} {
+		method, path, _ := strings.Cut(route, " ")
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected %s to be forbidden to viewers. got=%d %s", route, w.Code, w.Body.String())
+		}
+	}
+}
+
+// oneKey knows a single API key, with every action
+type oneKey struct{ key model.APIKey }
+
+func (k oneKey) Create(ctx context.Context, key *model.APIKey) (string, error) { return "", nil }
+func (k oneKey) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	return &k.key, nil
+}
+func (k oneKey) Get(ctx context.Context) ([]model.APIKey, error)           { return nil, nil }
+func (k oneKey) Revoke(ctx context.Context, id string, at time.Time) error { return nil }
+func (k oneKey) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+// keys passed as ?key= end up in the URLs of calendar servers, only read-only ones are accepted
+func TestCalendarKeyRequiresReadOnlyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := apikey.New(oneKey{model.APIKey{ID: "key-1", TenantID: "acme", Actions: []string{"a1"}}})
+	r := NewRouter(config.Default(), Services{RBAC: rbac.New(noRoles{}, nil), APIKey: keys})
+
+	for _, path := range []string{"/calendar.ics?key=ask_1", "/schedule/1.ics?key=ask_1"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "calendar_key_not_read_only") {
+			t.Errorf("expected %s to be forbidden to keys that are not read-only. got=%d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
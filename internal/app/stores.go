@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/japb1998/action-scheduler/internal/service/apikey"
+	"github.com/japb1998/action-scheduler/internal/service/audit"
+	"github.com/japb1998/action-scheduler/internal/service/quota"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/service/tenant"
+	"github.com/japb1998/action-scheduler/internal/service/webhook"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// stores keep every collection, all in mongo or all in sqlite per cfg.Store.Schedules
+type stores struct {
+	schedules  scheduleStore
+	tenants    tenant.TenantStore
+	audit      audit.AuditStore
+	quota      quota.QuotaStore
+	roles      roleStore
+	apiKeys    apiKeyStore
+	webhooks   webhookStore
+	deliveries deliveryStore
+}
+
+// the tenant scoped stores are also torn down with the tenant
+type (
+	roleStore interface {
+		rbac.RoleStore
+		tenant.TenantData
+	}
+	apiKeyStore interface {
+		apikey.APIKeyStore
+		tenant.APIKeyStore
+	}
+	webhookStore interface {
+		webhook.WebhookStore
+		tenant.TenantData
+	}
+	deliveryStore interface {
+		webhook.DeliveryStore
+		tenant.TenantData
+	}
+)
+
+// mongoStores also creates the indexes the stores rely on, the sqlite tables get theirs from the migrations
+func mongoStores(ctx context.Context, c *mongo.Client, db string, schedules *store.MongoScheduleStore) (stores, error) {
+	quotaStore := store.NewMongoQuotaStore(c, db)
+	deliveries := store.NewMongoDeliveryStore(c, db)
+
+	if err := quotaStore.EnsureIndexes(ctx); err != nil {
+		return stores{}, err
+	}
+	if err := deliveries.EnsureIndexes(ctx); err != nil {
+		return stores{}, err
+	}
+
+	return stores{
+		schedules:  schedules,
+		tenants:    store.NewMongoTenantStore(c, db),
+		audit:      store.NewMongoAuditStore(c, db),
+		quota:      quotaStore,
+		roles:      store.NewMongoRoleStore(c, db),
+		apiKeys:    store.NewMongoAPIKeyStore(c, db),
+		webhooks:   store.NewMongoWebhookStore(c, db),
+		deliveries: deliveries,
+	}, nil
+}
+
+func sqliteStores(db *sql.DB) stores {
+	return stores{
+		schedules:  store.NewSQLiteScheduleStore(db),
+		tenants:    store.NewSQLiteTenantStore(db),
+		audit:      store.NewSQLiteAuditStore(db),
+		quota:      store.NewSQLiteQuotaStore(db),
+		roles:      store.NewSQLiteRoleStore(db),
+		apiKeys:    store.NewSQLiteAPIKeyStore(db),
+		webhooks:   store.NewSQLiteWebhookStore(db),
+		deliveries: store.NewSQLiteDeliveryStore(db),
+	}
+}
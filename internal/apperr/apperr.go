@@ -0,0 +1,123 @@
+// apperr package defines the typed domain errors services return and the HTTP status each kind maps to
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUpstream
+	KindUnauthorized
+	KindForbidden
+	KindQuotaExceeded
+	// KindPreconditionFailed is a conditional request whose condition no longer holds, e.g. a stale If-Match
+	KindPreconditionFailed
+	// KindPreconditionRequired is a request that must be conditional and is not
+	KindPreconditionRequired
+)
+
+// Error is a domain error. services wrap it with fmt.Errorf("%w. ...") to add context.
+type Error struct {
+	Kind Kind
+	// Code is a stable machine readable code, e.g. schedule_not_found
+	Code    string
+	Message string
+	Details any
+	// Err is the cause. it is logged but never sent to the client.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ". error=" + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string, details any) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Details: details}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Upstream reports a failure of a dependency, e.g. EventBridge
+func Upstream(code, message string, cause error) *Error {
+	return &Error{Kind: KindUpstream, Code: code, Message: message, Err: cause}
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func PreconditionRequired(code, message string) *Error {
+	return New(KindPreconditionRequired, code, message)
+}
+
+// From returns the domain error in the chain of err. untyped errors are internal errors.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal server error", Err: err}
+}
+
+// HTTPStatus maps the kind to its status code
+func HTTPStatus(kind Kind) int {
+	switch kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindQuotaExceeded:
+		return http.StatusTooManyRequests
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case KindUpstream:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// Public reports whether the full error chain can be sent to the client.
+// internal and upstream errors only expose their message, their cause may leak infrastructure details.
+func (e *Error) Public() bool {
+	return e.Kind != KindInternal && e.Kind != KindUpstream
+}
@@ -0,0 +1,26 @@
+package apperr
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// Binding converts a gin binding error to a validation error. failed validator rules are listed per field in the details.
+func Binding(err error) *Error {
+	var e validator.ValidationErrors
+
+	if !errors.As(err, &e) {
+		return &Error{Kind: KindValidation, Code: "invalid_request", Message: "invalid request body or query", Details: []types.FieldError{{Error: err.Error()}}}
+	}
+
+	details := make([]types.FieldError, 0, len(e))
+	for _, fe := range e {
+		details = append(details, types.FieldError{
+			Field: fe.Field(),
+			Error: fe.Error(),
+		})
+	}
+	return Validation("invalid_request", "invalid request body or query", details)
+}
@@ -0,0 +1,19 @@
+package apperr
+
+import "github.com/japb1998/action-scheduler/internal/types"
+
+// Response builds the error envelope of err. the request ID is left to the caller.
+func Response(err error) types.ErrorResponse {
+	e := From(err)
+
+	message := e.Message
+	if e.Public() {
+		message = err.Error()
+	}
+
+	return types.ErrorResponse{
+		Code:    e.Code,
+		Message: message,
+		Details: e.Details,
+	}
+}
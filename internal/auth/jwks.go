@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// jwksRefreshInterval is the minimum time between two fetches of a remote key set.
+const jwksRefreshInterval = 5 * time.Minute
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet holds the RSA public keys of a JWKS document loaded from a file or URL.
+type KeySet struct {
+	source    string
+	client    *http.Client
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet loads the JWKS document at source. source is either a file path or an http(s) URL.
+func NewKeySet(ctx context.Context, source string) (*KeySet, error) {
+	ks := &KeySet{
+		source: source,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if err := ks.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key returns the key with the given kid. remote key sets are refreshed once when the kid is unknown.
+func (ks *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	ks.mu.RLock()
+	stale := ks.isRemote() && time.Since(ks.fetchedAt) > jwksRefreshInterval
+	ks.mu.RUnlock()
+
+	if stale {
+		if err := ks.load(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := ks.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w. kid=%s", ErrUnknownKey, kid)
+}
+
+func (ks *KeySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	// tokens without kid are accepted when the set only holds one key.
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) isRemote() bool {
+	return strings.HasPrefix(ks.source, "http://") || strings.HasPrefix(ks.source, "https://")
+}
+
+func (ks *KeySet) load(ctx context.Context) error {
+	by, err := ks.read(ctx)
+
+	if err != nil {
+		return fmt.Errorf("failed to read jwks from %s. error=%w", ks.source, err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(by, &set); err != nil {
+		return fmt.Errorf("invalid jwks document. error=%w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAKey(k)
+		if err != nil {
+			return fmt.Errorf("invalid jwk kid=%s. error=%w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("jwks at %s contains no RSA signing keys", ks.source)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.fetchedAt = time.Now()
+	ks.mu.Unlock()
+
+	return nil
+}
+
+func (ks *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !ks.isRemote() {
+		return os.ReadFile(ks.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus. error=%w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent. error=%w", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
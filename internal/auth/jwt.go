@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/config"
+)
+
+var (
+	ErrInvalidToken  = apperr.Unauthorized("invalid_token", "invalid token")
+	ErrNoVerifierKey = errors.New("no jwt verification key configured")
+)
+
+type VerifierOps struct {
+	// HMACSecret enables HS256 tokens.
+	HMACSecret []byte
+	// KeySet enables RS256 tokens.
+	KeySet *KeySet
+	// Issuer and Audience are validated when not empty.
+	Issuer   string
+	Audience string
+}
+
+type claims struct {
+	Email    string `json:"email"`
+	TenantID string `json:"tenant_id"`
+	jwt.RegisteredClaims
+}
+
+// Verifier validates HS256/RS256 JWTs and extracts the principal from their claims.
+type Verifier struct {
+	ops    VerifierOps
+	parser *jwt.Parser
+}
+
+func NewVerifier(ops VerifierOps) (*Verifier, error) {
+	if len(ops.HMACSecret) == 0 && ops.KeySet == nil {
+		return nil, ErrNoVerifierKey
+	}
+
+	methods := make([]string, 0, 2)
+	if len(ops.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if ops.KeySet != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	parserOps := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if ops.Issuer != "" {
+		parserOps = append(parserOps, jwt.WithIssuer(ops.Issuer))
+	}
+	if ops.Audience != "" {
+		parserOps = append(parserOps, jwt.WithAudience(ops.Audience))
+	}
+
+	return &Verifier{
+		ops:    ops,
+		parser: jwt.NewParser(parserOps...),
+	}, nil
+}
+
+// NewVerifierFromConfig builds a verifier from the auth configuration. JWKS is loaded from a file path or URL.
+func NewVerifierFromConfig(ctx context.Context, cfg config.Auth) (*Verifier, error) {
+	ops := VerifierOps{
+		HMACSecret: []byte(cfg.HS256Secret),
+		Issuer:     cfg.Issuer,
+		Audience:   cfg.Audience,
+	}
+
+	if source := cfg.JWKS; source != "" {
+		ks, err := NewKeySet(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		ops.KeySet = ks
+	}
+
+	v, err := NewVerifier(ops)
+	if err != nil {
+		return nil, fmt.Errorf("%w. set auth.hs256_secret and/or auth.jwks", err)
+	}
+
+	return v, nil
+}
+
+// Verify validates the token signature and claims and returns the principal it identifies.
+func (v *Verifier) Verify(ctx context.Context, token string) (Principal, error) {
+	var c claims
+
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		switch t.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return v.ops.HMACSecret, nil
+		case jwt.SigningMethodRS256.Alg():
+			kid, _ := t.Header["kid"].(string)
+			return v.ops.KeySet.Key(ctx, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+	})
+
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w. error=%w", ErrInvalidToken, err)
+	}
+
+	if c.Subject == "" {
+		return Principal{}, fmt.Errorf("%w. description: sub claim is required", ErrInvalidToken)
+	}
+
+	return Principal{
+		Subject:  c.Subject,
+		Email:    c.Email,
+		Issuer:   c.Issuer,
+		TenantID: c.TenantID,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("local-secret")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	by, _ := json.Marshal(jwks{Keys: []jwk{{
+		Kid: "local",
+		Kty: "RSA",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	if err := os.WriteFile(jwksPath, by, 0o600); err != nil {
+		t.Fatalf("error writing jwks: %v", err)
+	}
+
+	ks, err := NewKeySet(context.Background(), jwksPath)
+	if err != nil {
+		t.Fatalf("error loading jwks: %v", err)
+	}
+
+	v, err := NewVerifier(VerifierOps{HMACSecret: secret, KeySet: ks, Issuer: "test"})
+	if err != nil {
+		t.Fatalf("error creating verifier: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, k any, kid string, c jwt.RegisteredClaims) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(k)
+		if err != nil {
+			t.Fatalf("error signing token: %v", err)
+		}
+		return s
+	}
+
+	valid := jwt.RegisteredClaims{
+		Subject:   "user-1",
+		Issuer:    "test",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	wrongIssuer := valid
+	wrongIssuer.Issuer = "other"
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{name: "hs256", token: sign(jwt.SigningMethodHS256, secret, "", valid), valid: true},
+		{name: "rs256", token: sign(jwt.SigningMethodRS256, key, "local", valid), valid: true},
+		{name: "hs256 wrong secret", token: sign(jwt.SigningMethodHS256, []byte("other"), "", valid), valid: false},
+		{name: "rs256 unknown kid", token: sign(jwt.SigningMethodRS256, key, "unknown", valid), valid: false},
+		{name: "expired", token: sign(jwt.SigningMethodHS256, secret, "", expired), valid: false},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodHS256, secret, "", wrongIssuer), valid: false},
+		{name: "unsigned", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid), valid: false},
+	}
+
+	for _, tt := range tests {
+		p, err := v.Verify(context.Background(), tt.token)
+
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid token. got error=%v", tt.name, err)
+		}
+		if tt.valid && p.Subject != "user-1" {
+			t.Errorf("%s: expected subject user-1. got=%s", tt.name, p.Subject)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected error verifying token", tt.name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// auth package verifies caller credentials and exposes the authenticated principal through context.Context
+package auth
+
+import "context"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Email   string
+	Issuer  string
+	// TenantID is the organisation the principal belongs to.
+	TenantID string
+	// APIKeyID, Actions and ReadOnly are only set for service callers authenticated with an API key.
+	APIKeyID string
+	Actions  []string
+	ReadOnly bool
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored in the context, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
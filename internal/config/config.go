@@ -0,0 +1,340 @@
+// config package loads the typed application configuration from a YAML/TOML file with env var overrides
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/pkg/awssess"
+)
+
+type Config struct {
+	HTTP      HTTP      `yaml:"http" toml:"http"`
+	GRPC      GRPC      `yaml:"grpc" toml:"grpc"`
+	Mongo     Mongo     `yaml:"mongo" toml:"mongo"`
+	Store     Store     `yaml:"store" toml:"store"`
+	AWS       AWS       `yaml:"aws" toml:"aws"`
+	Scheduler Scheduler `yaml:"scheduler" toml:"scheduler"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	Actions   Actions   `yaml:"actions" toml:"actions"`
+	Quota     Quota     `yaml:"quota" toml:"quota"`
+	Batch     Batch     `yaml:"batch" toml:"batch"`
+	Webhooks  Webhooks  `yaml:"webhooks" toml:"webhooks"`
+	Health    Health    `yaml:"health" toml:"health"`
+	Tracing   Tracing   `yaml:"tracing" toml:"tracing"`
+	Logging   Logging   `yaml:"logging" toml:"logging"`
+}
+
+type HTTP struct {
+	Port             int      `yaml:"port" toml:"port" env:"PORT"`
+	CORSAllowOrigins []string `yaml:"cors_allow_origins" toml:"cors_allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	// ShutdownTimeout is how long in-flight requests are drained on SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+}
+
+type GRPC struct {
+	// Port of the gRPC API. 0 disables it.
+	Port int `yaml:"port" toml:"port" env:"GRPC_PORT"`
+}
+
+type Mongo struct {
+	URI      string        `yaml:"uri" toml:"uri" env:"MONGO_URI" secret:"true"`
+	Database string        `yaml:"database" toml:"database" env:"MONGO_DATABASE"`
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout" env:"MONGO_TIMEOUT"`
+}
+
+// Store selects where the schedules and the other collections are kept.
+type Store struct {
+	// Schedules is "mongo" or "sqlite". sqlite keeps every collection in the sqlite database and never connects to mongo.
+	Schedules string `yaml:"schedules" toml:"schedules" env:"SCHEDULE_STORE"`
+	// SQLitePath is the database file of the sqlite store. ":memory:" keeps the data in memory until the process exits.
+	SQLitePath string `yaml:"sqlite_path" toml:"sqlite_path" env:"SQLITE_PATH"`
+	// Changes is where GET /schedule/stream reads the schedule changes from: "mongo" change streams, which need a replica set,
+	// or "memory", the writes of this process only. it defaults to mongo with the mongo store and to memory with the sqlite store.
+	Changes string `yaml:"changes" toml:"changes" env:"SCHEDULE_CHANGES"`
+}
+
+// ChangesSource returns Changes or its default for the store
+func (s Store) ChangesSource() string {
+	if s.Changes != "" {
+		return s.Changes
+	}
+	if s.Schedules == "sqlite" {
+		return "memory"
+	}
+	return "mongo"
+}
+
+type AWS struct {
+	// Stage "local" uses the shared config Profile and Region
+	Stage   string `yaml:"stage" toml:"stage" env:"STAGE"`
+	Profile string `yaml:"profile" toml:"profile" env:"AWS_PROFILE"`
+	Region  string `yaml:"region" toml:"region" env:"AWS_REGION"`
+}
+
+type Scheduler struct {
+	RetryAttempts int64 `yaml:"retry_attempts" toml:"retry_attempts" env:"SCHEDULER_RETRY_ATTEMPTS"`
+	// RateLimit caps the EventBridge API calls per second, to stay below the account quota. 0 is unlimited.
+	RateLimit float64 `yaml:"rate_limit" toml:"rate_limit" env:"SCHEDULER_RATE_LIMIT"`
+}
+
+type Auth struct {
+	HS256Secret string `yaml:"hs256_secret" toml:"hs256_secret" env:"JWT_HS256_SECRET" secret:"true"`
+	// JWKS is a file path or an http(s) URL
+	JWKS     string `yaml:"jwks" toml:"jwks" env:"JWT_JWKS"`
+	Issuer   string `yaml:"issuer" toml:"issuer" env:"JWT_ISSUER"`
+	Audience string `yaml:"audience" toml:"audience" env:"JWT_AUDIENCE"`
+	// BootstrapAdmins are subjects that are admins of every tenant
+	BootstrapAdmins []string `yaml:"bootstrap_admins" toml:"bootstrap_admins" env:"RBAC_BOOTSTRAP_ADMINS"`
+}
+
+type Actions struct {
+	SingleEmailArn  string `yaml:"single_email_arn" toml:"single_email_arn" env:"SINGLE_EMAIL_FUNCTION"`
+	SingleEmailRole string `yaml:"single_email_role" toml:"single_email_role" env:"SINGLE_EMAIL_ROLE"`
+	MassEmailArn    string `yaml:"mass_email_arn" toml:"mass_email_arn" env:"MASS_EMAIL_ARN"`
+	MassEmailRole   string `yaml:"mass_email_role" toml:"mass_email_role" env:"MASS_EMAIL_ROLE"`
+}
+
+// Quota limits. 0 is unlimited.
+type Quota struct {
+	MaxActivePerUser   int64 `yaml:"max_active_per_user" toml:"max_active_per_user" env:"QUOTA_MAX_PER_USER"`
+	MaxActivePerTenant int64 `yaml:"max_active_per_tenant" toml:"max_active_per_tenant" env:"QUOTA_MAX_PER_TENANT"`
+	MaxActivePerAction int64 `yaml:"max_active_per_action" toml:"max_active_per_action" env:"QUOTA_MAX_PER_ACTION"`
+	MaxCreatesPerMin   int64 `yaml:"max_creates_per_minute" toml:"max_creates_per_minute" env:"QUOTA_MAX_CREATES_PER_MINUTE"`
+}
+
+// Batch bounds the batch endpoints
+type Batch struct {
+	MaxItems int `yaml:"max_items" toml:"max_items" env:"BATCH_MAX_ITEMS"`
+	// Concurrency is how many items of a batch are processed at once
+	Concurrency int `yaml:"concurrency" toml:"concurrency" env:"BATCH_CONCURRENCY"`
+	// MaxImportRows bounds the rows of an import file
+	MaxImportRows int `yaml:"max_import_rows" toml:"max_import_rows" env:"IMPORT_MAX_ROWS"`
+}
+
+// Webhooks configures the delivery of the webhook events
+type Webhooks struct {
+	// Workers is how many deliveries are sent at once. 0 stops the deliveries, which stay queued.
+	Workers int `yaml:"workers" toml:"workers" env:"WEBHOOK_WORKERS"`
+	// PollInterval is how often an idle worker looks for due deliveries
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL"`
+	// Timeout bounds each attempt
+	Timeout     time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOK_TIMEOUT"`
+	MaxAttempts int           `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	// BackoffBase is the delay after the first failed attempt. it doubles after every attempt up to BackoffMax.
+	BackoffBase time.Duration `yaml:"backoff_base" toml:"backoff_base" env:"WEBHOOK_BACKOFF_BASE"`
+	BackoffMax  time.Duration `yaml:"backoff_max" toml:"backoff_max" env:"WEBHOOK_BACKOFF_MAX"`
+	// AllowPrivateURLs accepts http webhooks and webhooks on loopback, private and link-local addresses. for local development only.
+	AllowPrivateURLs bool `yaml:"allow_private_urls" toml:"allow_private_urls" env:"WEBHOOK_ALLOW_PRIVATE_URLS"`
+}
+
+type Health struct {
+	// Timeout bounds each readiness check
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"HEALTH_TIMEOUT"`
+}
+
+type Tracing struct {
+	// Exporter is "none", "stdout", "file" or "otlp"
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	// File is where the "file" exporter appends spans
+	File string `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	// Endpoint is the host:port of the OTLP/HTTP collector used by the "otlp" exporter
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	// Insecure sends spans to the collector over plain http instead of https
+	Insecure    bool    `yaml:"insecure" toml:"insecure" env:"TRACING_OTLP_INSECURE"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type Logging struct {
+	// Format is "text" or "json"
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Level is "debug", "info", "warn" or "error"
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+// Default returns the configuration used for every value missing from the file and the environment
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Port:             8080,
+			CORSAllowOrigins: []string{"http://localhost:4200"},
+			ShutdownTimeout:  15 * time.Second,
+		},
+		GRPC: GRPC{
+			Port: 50051,
+		},
+		Mongo: Mongo{
+			Database: "notification-scheduler",
+			Timeout:  29 * time.Second,
+		},
+		Store: Store{
+			Schedules:  "mongo",
+			SQLitePath: "action-scheduler.db",
+		},
+		AWS: AWS{
+			Profile: "personal",
+			Region:  "us-east-1",
+		},
+		Scheduler: Scheduler{
+			RateLimit: 20,
+		},
+		Batch: Batch{
+			MaxItems:      100,
+			Concurrency:   10,
+			MaxImportRows: 1000,
+		},
+		Webhooks: Webhooks{
+			Workers:      2,
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  8,
+			BackoffBase:  10 * time.Second,
+			BackoffMax:   time.Hour,
+		},
+		Health: Health{
+			Timeout: 2 * time.Second,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "action-scheduler",
+			SampleRatio: 1,
+		},
+		Logging: Logging{
+			Format: "text",
+			Level:  "info",
+		},
+	}
+}
+
+// Validate reports every invalid value at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.HTTP.Port <= 0 || c.HTTP.Port > 65535 {
+		errs = append(errs, fmt.Errorf("http.port (PORT) must be between 1 and 65535. got=%d", c.HTTP.Port))
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("http.shutdown_timeout (HTTP_SHUTDOWN_TIMEOUT) must be positive. got=%s", c.HTTP.ShutdownTimeout))
+	}
+	if c.GRPC.Port < 0 || c.GRPC.Port > 65535 {
+		errs = append(errs, fmt.Errorf("grpc.port (GRPC_PORT) must be between 0 and 65535. got=%d", c.GRPC.Port))
+	}
+	if c.GRPC.Port != 0 && c.GRPC.Port == c.HTTP.Port {
+		errs = append(errs, fmt.Errorf("grpc.port (GRPC_PORT) must differ from http.port (PORT). got=%d", c.GRPC.Port))
+	}
+	if len(c.HTTP.CORSAllowOrigins) == 0 {
+		errs = append(errs, errors.New("http.cors_allow_origins (CORS_ALLOW_ORIGINS) requires at least one origin"))
+	}
+	// the sqlite store never connects to mongo
+	if c.Store.Schedules != "sqlite" {
+		if c.Mongo.URI == "" {
+			errs = append(errs, errors.New("mongo.uri (MONGO_URI) is required"))
+		}
+		if c.Mongo.Database == "" {
+			errs = append(errs, errors.New("mongo.database (MONGO_DATABASE) is required"))
+		}
+		if c.Mongo.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("mongo.timeout (MONGO_TIMEOUT) must be positive. got=%s", c.Mongo.Timeout))
+		}
+	}
+	switch c.Store.Schedules {
+	case "mongo":
+	case "sqlite":
+		if c.Store.SQLitePath == "" {
+			errs = append(errs, errors.New("store.sqlite_path (SQLITE_PATH) is required by the sqlite store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("store.schedules (SCHEDULE_STORE) must be mongo or sqlite. got=%q", c.Store.Schedules))
+	}
+	switch c.Store.ChangesSource() {
+	case "mongo":
+		if c.Store.Schedules == "sqlite" {
+			errs = append(errs, errors.New("store.changes (SCHEDULE_CHANGES) must be memory with the sqlite store"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("store.changes (SCHEDULE_CHANGES) must be mongo or memory. got=%q", c.Store.Changes))
+	}
+	if c.Scheduler.RetryAttempts < 0 || c.Scheduler.RetryAttempts > 185 {
+		errs = append(errs, fmt.Errorf("scheduler.retry_attempts (SCHEDULER_RETRY_ATTEMPTS) must be between 0 and 185. got=%d", c.Scheduler.RetryAttempts))
+	}
+	if c.Scheduler.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.rate_limit (SCHEDULER_RATE_LIMIT) must not be negative. got=%g", c.Scheduler.RateLimit))
+	}
+	if c.Batch.MaxItems <= 0 {
+		errs = append(errs, fmt.Errorf("batch.max_items (BATCH_MAX_ITEMS) must be positive. got=%d", c.Batch.MaxItems))
+	}
+	if c.Batch.MaxImportRows <= 0 {
+		errs = append(errs, fmt.Errorf("batch.max_import_rows (IMPORT_MAX_ROWS) must be positive. got=%d", c.Batch.MaxImportRows))
+	}
+	if c.Batch.Concurrency <= 0 {
+		errs = append(errs, fmt.Errorf("batch.concurrency (BATCH_CONCURRENCY) must be positive. got=%d", c.Batch.Concurrency))
+	}
+	if c.Webhooks.Workers < 0 {
+		errs = append(errs, fmt.Errorf("webhooks.workers (WEBHOOK_WORKERS) must not be negative. got=%d", c.Webhooks.Workers))
+	}
+	if c.Webhooks.PollInterval <= 0 || c.Webhooks.Timeout <= 0 || c.Webhooks.BackoffBase <= 0 {
+		errs = append(errs, errors.New("webhooks.poll_interval (WEBHOOK_POLL_INTERVAL), webhooks.timeout (WEBHOOK_TIMEOUT) and webhooks.backoff_base (WEBHOOK_BACKOFF_BASE) must be positive"))
+	}
+	if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		errs = append(errs, fmt.Errorf("webhooks.backoff_max (WEBHOOK_BACKOFF_MAX) must be at least webhooks.backoff_base. got=%s", c.Webhooks.BackoffMax))
+	}
+	if c.Webhooks.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.max_attempts (WEBHOOK_MAX_ATTEMPTS) must be positive. got=%d", c.Webhooks.MaxAttempts))
+	}
+	if c.Auth.HS256Secret == "" && c.Auth.JWKS == "" {
+		errs = append(errs, errors.New("auth.hs256_secret (JWT_HS256_SECRET) and/or auth.jwks (JWT_JWKS) is required"))
+	}
+	if (c.Actions.SingleEmailArn == "") != (c.Actions.SingleEmailRole == "") {
+		errs = append(errs, errors.New("actions.single_email_arn (SINGLE_EMAIL_FUNCTION) and actions.single_email_role (SINGLE_EMAIL_ROLE) must be set together"))
+	}
+	if (c.Actions.MassEmailArn == "") != (c.Actions.MassEmailRole == "") {
+		errs = append(errs, errors.New("actions.mass_email_arn (MASS_EMAIL_ARN) and actions.mass_email_role (MASS_EMAIL_ROLE) must be set together"))
+	}
+	if c.Health.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.timeout (HEALTH_TIMEOUT) must be positive. got=%s", c.Health.Timeout))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			errs = append(errs, errors.New("tracing.file (TRACING_FILE) is required by the file exporter"))
+		}
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint (TRACING_OTLP_ENDPOINT) is required by the otlp exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter (TRACING_EXPORTER) must be none, stdout, file or otlp. got=%q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1. got=%g", c.Tracing.SampleRatio))
+	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		errs = append(errs, fmt.Errorf("logging.format (LOG_FORMAT) must be text or json. got=%q", c.Logging.Format))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level (LOG_LEVEL) must be debug, info, warn or error. got=%q", c.Logging.Level))
+	}
+	if c.Quota.MaxActivePerUser < 0 || c.Quota.MaxActivePerTenant < 0 || c.Quota.MaxActivePerAction < 0 || c.Quota.MaxCreatesPerMin < 0 {
+		errs = append(errs, errors.New("quota limits can't be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// SessionOptions maps the AWS configuration to the awssess options
+func (a AWS) SessionOptions() awssess.Options {
+	return awssess.Options{
+		Stage:   a.Stage,
+		Profile: a.Profile,
+		Region:  a.Region,
+	}
+}
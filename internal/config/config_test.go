@@ -0,0 +1,163 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(yamlPath, []byte(`
+http:
+  port: 9090
+mongo:
+  uri: mongodb://file
+  timeout: 10s
+auth:
+  hs256_secret: file-secret
+`), 0o600)
+
+	tomlPath := filepath.Join(dir, "config.toml")
+	os.WriteFile(tomlPath, []byte(`
+[http]
+port = 9091
+[mongo]
+uri = "mongodb://toml"
+[auth]
+jwks = "jwks.json"
+`), 0o600)
+
+	t.Run("yaml with env overrides", func(t *testing.T) {
+		t.Setenv("MONGO_URI", "mongodb://env")
+		t.Setenv("RBAC_BOOTSTRAP_ADMINS", "a, b")
+
+		cfg, err := Load(yamlPath)
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+
+		if cfg.HTTP.Port != 9090 {
+			t.Errorf("expected port from file. got=%d", cfg.HTTP.Port)
+		}
+		if cfg.Mongo.URI != "mongodb://env" {
+			t.Errorf("expected uri from env. got=%s", cfg.Mongo.URI)
+		}
+		if cfg.Mongo.Timeout != 10*time.Second {
+			t.Errorf("expected timeout from file. got=%s", cfg.Mongo.Timeout)
+		}
+		if cfg.Mongo.Database != "notification-scheduler" {
+			t.Errorf("expected default database. got=%s", cfg.Mongo.Database)
+		}
+		if len(cfg.Auth.BootstrapAdmins) != 2 || cfg.Auth.BootstrapAdmins[1] != "b" {
+			t.Errorf("expected admins from env. got=%v", cfg.Auth.BootstrapAdmins)
+		}
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		cfg, err := Load(tomlPath)
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+		if cfg.HTTP.Port != 9091 || cfg.Auth.JWKS != "jwks.json" {
+			t.Errorf("expected values from toml file. got=%+v", cfg)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("PORT", "0")
+		t.Setenv("SINGLE_EMAIL_FUNCTION", "arn")
+
+		_, err := Load("")
+		if err == nil {
+			t.Fatal("expected validation error")
+		}
+		for _, want := range []string{"PORT", "MONGO_URI", "JWT_HS256_SECRET", "SINGLE_EMAIL_ROLE"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected error to mention %s. got=%v", want, err)
+			}
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		cfg, err := Load(yamlPath, func(c *Config) { c.HTTP.Port = 9092 })
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+		if cfg.HTTP.Port != 9092 {
+			t.Errorf("expected port from the override. got=%d", cfg.HTTP.Port)
+		}
+
+		if _, err := Load(yamlPath, func(c *Config) { c.HTTP.Port = -1 }); err == nil || !strings.Contains(err.Error(), "PORT") {
+			t.Errorf("expected the override to be validated. got=%v", err)
+		}
+	})
+
+	t.Run("sqlite without mongo", func(t *testing.T) {
+		t.Setenv("SCHEDULE_STORE", "sqlite")
+		t.Setenv("JWT_HS256_SECRET", "secret")
+
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("expected the sqlite store not to require mongo. got=%v", err)
+		}
+		if got := cfg.Store.ChangesSource(); got != "memory" {
+			t.Errorf("expected the changes to default to memory. got=%s", got)
+		}
+	})
+
+	t.Run("otlp", func(t *testing.T) {
+		t.Setenv("TRACING_EXPORTER", "otlp")
+
+		if _, err := Load(yamlPath); err == nil || !strings.Contains(err.Error(), "TRACING_OTLP_ENDPOINT") {
+			t.Errorf("expected the otlp exporter to require an endpoint. got=%v", err)
+		}
+
+		t.Setenv("TRACING_OTLP_ENDPOINT", "collector:4318")
+		t.Setenv("TRACING_OTLP_INSECURE", "true")
+
+		cfg, err := Load(yamlPath)
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+		if cfg.Tracing.Endpoint != "collector:4318" || !cfg.Tracing.Insecure {
+			t.Errorf("expected the otlp settings from env. got=%+v", cfg.Tracing)
+		}
+	})
+
+	t.Run("bool env", func(t *testing.T) {
+		t.Setenv("WEBHOOK_ALLOW_PRIVATE_URLS", "true")
+
+		cfg, err := Load(yamlPath)
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+		if !cfg.Webhooks.AllowPrivateURLs {
+			t.Errorf("expected private webhook urls allowed from env")
+		}
+
+		t.Setenv("WEBHOOK_ALLOW_PRIVATE_URLS", "maybe")
+		if _, err := Load(yamlPath); err == nil || !strings.Contains(err.Error(), "WEBHOOK_ALLOW_PRIVATE_URLS") {
+			t.Errorf("expected an invalid bool to be rejected. got=%v", err)
+		}
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		cfg, err := Load(yamlPath)
+		if err != nil {
+			t.Fatalf("error loading config: %v", err)
+		}
+
+		out := cfg.String()
+		if strings.Contains(out, "file-secret") || strings.Contains(out, "mongodb://file") {
+			t.Errorf("expected secrets to be redacted. got=%s", out)
+		}
+		if cfg.Auth.HS256Secret != "file-secret" {
+			t.Errorf("expected original config to be untouched")
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is the env var holding the path of the config file. the file is optional.
+const FileEnv = "CONFIG_FILE"
+
+const redacted = "****"
+
+// Load reads the defaults, then the file at path (if any), then the env var overrides, then the overrides, e.g. of the command line flags,
+// and validates the result.
+func Load(path string, overrides ...func(*Config)) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := decodeFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	for _, override := range overrides {
+		override(cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func decodeFile(path string, cfg *Config) error {
+	by, err := os.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s. error=%w", path, err)
+	}
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(by))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(by)).DisallowUnknownFields().Decode(cfg)
+	default:
+		return fmt.Errorf("unsupported config file extension %q. wanted=.yaml, .yml or .toml", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid config file %s. error=%w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides every field tagged with `env` whose env var is set
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(key)
+		if key == "" || !ok {
+			continue
+		}
+
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("invalid value for env %s. error=%w", key, err)
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case int, int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case []string:
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration with every field tagged `secret` masked
+func (c *Config) Redacted() *Config {
+	cp := *c
+	redact(reflect.ValueOf(&cp).Elem())
+	return &cp
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			redact(value)
+			continue
+		}
+
+		if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String && value.String() != "" {
+			value.SetString(redacted)
+		}
+	}
+}
+
+// String renders the redacted configuration as YAML
+func (c *Config) String() string {
+	by, err := yaml.Marshal(c.Redacted())
+
+	if err != nil {
+		return fmt.Sprintf("failed to render config. error=%v", err)
+	}
+	return string(by)
+}
@@ -0,0 +1,55 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type APIKeyService interface {
+	Mint(c context.Context, input types.CreateAPIKeyInput) (*types.MintedAPIKey, error)
+	GetAll(c context.Context) ([]types.APIKey, error)
+	Revoke(c context.Context, id string) error
+}
+
+// CreateAPIKey mints a new key. the plain key is only part of this response.
+func (h *Handler) CreateAPIKey(ctx *gin.Context) {
+	var input types.CreateAPIKeyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	key, err := h.svc.Mint(ctx.Request.Context(), input)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+func (h *Handler) GetAPIKeys(ctx *gin.Context) {
+	keys, err := h.svc.GetAll(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+func (h *Handler) RevokeAPIKey(ctx *gin.Context) {
+	if err := h.svc.Revoke(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,12 @@
+package apikey
+
+// Handler serves the API key routes
+type Handler struct {
+	svc APIKeyService
+}
+
+func NewHandler(svc APIKeyService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
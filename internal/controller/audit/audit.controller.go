@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type AuditService interface {
+	GetPaginated(c context.Context, filter *types.AuditFilter) (*types.PaginatedResult[types.AuditEntry], error)
+}
+
+// GetAuditEntries supports filtering by schedule_id, actor and a from/to RFC3339 range.
+func (h *Handler) GetAuditEntries(ctx *gin.Context) {
+	var filter types.AuditFilter
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+
+	entries, err := h.svc.GetPaginated(ctx.Request.Context(), &filter)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,12 @@
+package audit
+
+// Handler serves the audit routes
+type Handler struct {
+	svc AuditService
+}
+
+func NewHandler(svc AuditService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
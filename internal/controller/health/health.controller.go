@@ -0,0 +1,32 @@
+package health
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type HealthService interface {
+	Ready(c context.Context) *types.Readiness
+}
+
+// Healthz only reports that the process is up. it never checks dependencies.
+func (h *Handler) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": types.HealthStatusOK,
+	})
+}
+
+// Readyz reports the status of every dependency. any failing dependency returns 503.
+func (h *Handler) Readyz(ctx *gin.Context) {
+	readiness := h.svc.Ready(ctx.Request.Context())
+
+	status := http.StatusOK
+	if readiness.Status != types.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(status, readiness)
+}
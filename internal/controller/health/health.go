@@ -0,0 +1,12 @@
+package health
+
+// Handler serves the liveness and readiness routes
+type Handler struct {
+	svc HealthService
+}
+
+func NewHandler(svc HealthService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
@@ -0,0 +1,43 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type QuotaService interface {
+	Usage(c context.Context, createdBy string) ([]types.QuotaUsage, error)
+	Reconcile(c context.Context) ([]types.QuotaUsage, error)
+}
+
+// GetQuota returns the caller's usage of every quota that applies to them
+func (h *Handler) GetQuota(ctx *gin.Context) {
+	usage, err := h.svc.Usage(ctx.Request.Context(), requestctx.Actor(ctx.Request.Context()))
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"usage": usage,
+	})
+}
+
+// ReconcileQuota recounts the active schedule counters of the tenant from its schedules
+func (h *Handler) ReconcileQuota(ctx *gin.Context) {
+	usage, err := h.svc.Reconcile(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"usage": usage,
+	})
+}
@@ -0,0 +1,12 @@
+package quota
+
+// Handler serves the quota routes
+type Handler struct {
+	svc QuotaService
+}
+
+func NewHandler(svc QuotaService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
@@ -0,0 +1,55 @@
+package role
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type RoleService interface {
+	GetRoles(c context.Context) ([]types.RoleBinding, error)
+	UpsertRole(c context.Context, subject string, input types.UpsertRoleInput) (*types.RoleBinding, error)
+	DeleteRole(c context.Context, subject string) error
+}
+
+func (h *Handler) GetRoles(ctx *gin.Context) {
+	roles, err := h.svc.GetRoles(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+// UpsertRole assigns a role and its allowed actions to the subject
+func (h *Handler) UpsertRole(ctx *gin.Context) {
+	var input types.UpsertRoleInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	role, err := h.svc.UpsertRole(ctx.Request.Context(), ctx.Param("subject"), input)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (h *Handler) DeleteRole(ctx *gin.Context) {
+	if err := h.svc.DeleteRole(ctx.Request.Context(), ctx.Param("subject")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,12 @@
+package role
+
+// Handler serves the role routes
+type Handler struct {
+	svc RoleService
+}
+
+func NewHandler(svc RoleService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/ical"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// icsSuffix selects the iCalendar representation of a schedule.
+// gin can't route /schedule/:id.ics next to /schedule/:id, so GetScheduleByID looks for it.
+const icsSuffix = ".ics"
+
+// icsFormat is the export format of the calendar with the payloads
+const icsFormat = "ics"
+
+// GetScheduleCalendar returns a calendar with the event of the schedule
+func (h *Handler) GetScheduleCalendar(ctx *gin.Context) {
+	id := strings.TrimSuffix(ctx.Param("id"), icsSuffix)
+
+	sch, err := h.svc.GetByID(ctx.Request.Context(), id)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	loc, err := h.svc.TimeZone(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf, loc, time.Now())
+
+	if err := w.Write(sch); err != nil {
+		ctx.Error(err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, sch.ID))
+	ctx.Data(http.StatusOK, ical.ContentType, buf.Bytes())
+}
+
+// GetCalendarFeed streams a calendar with the events of every schedule matching the filter, for calendar apps to subscribe to.
+func (h *Handler) GetCalendarFeed(ctx *gin.Context) {
+	var filter types.ScheduleFilterInput
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	loc, err := h.svc.TimeZone(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	w := ical.NewWriter(ctx.Writer, loc, time.Now())
+	ctx.Header("Content-Type", ical.ContentType)
+
+	err = h.svc.Export(ctx.Request.Context(), filter, w.Write)
+
+	if err == nil {
+		err = w.Close()
+	}
+
+	endStream(ctx, err)
+}
+
+// exportCalendar streams the calendar of GetCalendarFeed with the payloads of the schedules, so it can be imported back.
+// the feeds leave them out, they are read by calendar servers and their ?key= ends up in their URLs.
+func (h *Handler) exportCalendar(ctx *gin.Context, filter types.ScheduleFilterInput) {
+	loc, err := h.svc.TimeZone(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	w := ical.NewWriter(ctx.Writer, loc, time.Now()).WithPayloads()
+	ctx.Header("Content-Type", ical.ContentType)
+	ctx.Header("Content-Disposition", `attachment; filename="schedules.ics"`)
+
+	err = h.svc.Export(ctx.Request.Context(), filter, w.Write)
+
+	if err == nil {
+		err = w.Close()
+	}
+
+	endStream(ctx, err)
+}
+
+// ImportCalendar imports the events of an iCalendar file. events without an action use the action of the query.
+// recurrence rules the scheduler can't express fail their row.
+func (h *Handler) ImportCalendar(ctx *gin.Context) {
+	var query types.CalendarImportQuery
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	loc, err := h.svc.TimeZone(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rows, err := ical.Read(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes), loc)
+
+	if err != nil {
+		ctx.Error(apperr.Validation("invalid_file", err.Error(), nil))
+		return
+	}
+
+	for i := range rows {
+		if rows[i].Input.ActionID == "" {
+			rows[i].Input.ActionID = query.ActionID
+		}
+		if rows[i].Err != nil {
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&rows[i].Input); err != nil {
+			rows[i].Err = apperr.Binding(err)
+		}
+	}
+
+	result, err := h.svc.Import(ctx.Request.Context(), rows, query.DryRun)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -2,14 +2,14 @@ package schedule
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"github.com/japb1998/action-scheduler/internal/service/schedule"
+	"github.com/japb1998/action-scheduler/internal/apperr"
 	"github.com/japb1998/action-scheduler/internal/types"
 )
 
@@ -17,138 +17,216 @@ type ScheduleService interface {
 	GetByID(c context.Context, id string) (*types.Schedule, error)
 	GetPaginated(c context.Context, pagination *types.PaginationOps) (*types.PaginatedResult[types.Schedule], error)
 	Create(c context.Context, schedule types.CreateScheduleInput) (*types.Schedule, error)
-	Delete(c context.Context, id string) error
+	Update(c context.Context, id string, version int64, input types.UpdateScheduleInput) (*types.Schedule, error)
+	Delete(c context.Context, id string, version int64) error
+	BatchCreate(c context.Context, items []types.CreateScheduleInput) (*types.BatchResult, error)
+	BatchDelete(c context.Context, input types.BatchDeleteInput) (*types.BatchResult, error)
+	Export(c context.Context, f types.ScheduleFilterInput, fn func(*types.Schedule) error) error
+	Import(c context.Context, rows []types.ImportRow, dryRun bool) (*types.ImportResult, error)
+	TimeZone(c context.Context) (*time.Location, error)
+	ReportRun(c context.Context, id string, run types.ScheduleRunInput) error
+	Watch(c context.Context, after string) (types.ScheduleEvents, error)
 }
 
-func GetSchedules(ctx *gin.Context) {
+var (
+	errUnknownMethod  = apperr.NotFound("unknown_method", "unknown schedule method")
+	errMissingIfMatch = apperr.PreconditionRequired("if_match_required", "If-Match with the ETag of the schedule is required")
+	errInvalidIfMatch = apperr.PreconditionFailed("invalid_if_match", "If-Match is not an ETag of the schedule")
+)
 
+func (h *Handler) GetSchedules(ctx *gin.Context) {
 	var paginationOps types.PaginationOps
 
 	if err := ctx.ShouldBindQuery(&paginationOps); err != nil {
-		var e validator.ValidationErrors
-
-		if errors.As(err, &e) {
-			errSlice := make([]struct {
-				Field string
-				Error string
-			}, 0)
-			for _, err := range e {
-				errSlice = append(errSlice, struct {
-					Field string
-					Error string
-				}{
-					Error: err.Error(),
-					Field: err.Field(),
-				})
-			}
-
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"errors": e,
-			})
-			return
-		}
-
+		ctx.Error(apperr.Binding(err))
+		return
 	}
 
 	if paginationOps.Limit == 0 {
 		paginationOps.Limit = 10
 	}
 
-	schedules, err := scheduleSvc.GetPaginated(ctx.Request.Context(), &paginationOps)
+	schedules, err := h.svc.GetPaginated(ctx.Request.Context(), &paginationOps)
 
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, schedules)
-
 }
 
-func CreateSchedule(ctx *gin.Context) {
+func (h *Handler) CreateSchedule(ctx *gin.Context) {
 	var sch types.CreateScheduleInput
-	if err := ctx.BindJSON(&sch); err != nil {
-		var e validator.ValidationErrors
-		log.Println(err)
-		if errors.As(err, &e) {
-			errSlice := make([]struct {
-				Field string `json:"field"`
-				Error string `json:"error"`
-			}, 0)
-			for _, err := range e {
-				errSlice = append(errSlice, struct {
-					Field string `json:"field"`
-					Error string `json:"error"`
-				}{
-					Error: err.Error(),
-					Field: err.Field(),
-				})
-			}
-
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"errors": errSlice,
-			})
-			return
-		} else {
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-	}
-
-	newSch, err := scheduleSvc.Create(ctx.Request.Context(), sch)
+
+	if err := ctx.ShouldBindJSON(&sch); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	newSch, err := h.svc.Create(ctx.Request.Context(), sch)
 
 	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", etag(newSch.Version))
 	ctx.JSON(http.StatusCreated, newSch)
 }
 
-func GetScheduleByID(ctx *gin.Context) {
-	id := ctx.Param("id")
+// GetScheduleByID returns the schedule, or its iCalendar event for GET /schedule/:id.ics
+func (h *Handler) GetScheduleByID(ctx *gin.Context) {
+	if strings.HasSuffix(ctx.Param("id"), icsSuffix) {
+		h.GetScheduleCalendar(ctx)
+		return
+	}
 
-	sch, err := scheduleSvc.GetByID(ctx.Request.Context(), id)
+	sch, err := h.svc.GetByID(ctx.Request.Context(), ctx.Param("id"))
 
 	if err != nil {
-		if errors.Is(schedule.ErrScheduleNotFound, err) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("schedule with ID: %s not found", id),
-			})
-			return
-		}
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", etag(sch.Version))
 	ctx.JSON(http.StatusOK, sch)
 }
 
-func DeleteSchedule(ctx *gin.Context) {
-	id := ctx.Param("id")
+// UpdateSchedule replaces the expression and payload of the schedule at the version in If-Match
+func (h *Handler) UpdateSchedule(ctx *gin.Context) {
+	version, err := ifMatch(ctx)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var input types.UpdateScheduleInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	sch, err := h.svc.Update(ctx.Request.Context(), ctx.Param("id"), version, input)
 
-	err := scheduleSvc.Delete(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", etag(sch.Version))
+	ctx.JSON(http.StatusOK, sch)
+}
+
+// DeleteSchedule deletes the schedule at the version in If-Match
+func (h *Handler) DeleteSchedule(ctx *gin.Context) {
+	version, err := ifMatch(ctx)
 
 	if err != nil {
-		if errors.Is(schedule.ErrScheduleNotFound, err) {
-			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
-				"error": fmt.Sprintf("schedule with ID: %s not found", id),
-			})
-			return
-		}
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		ctx.Error(err)
+		return
+	}
+
+	if err := h.svc.Delete(ctx.Request.Context(), ctx.Param("id"), version); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ReportRun lets the action of a schedule report that it fired or failed
+func (h *Handler) ReportRun(ctx *gin.Context) {
+	var run types.ScheduleRunInput
+
+	if err := ctx.ShouldBindJSON(&run); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if err := h.svc.ReportRun(ctx.Request.Context(), ctx.Param("id"), run); err != nil {
+		ctx.Error(err)
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// CustomMethod serves the custom methods of the collection, e.g. POST /schedule:batch.
+// gin can't route /schedule:batch and /schedule:batchDelete as two paths, so the route is /schedule:method.
+func (h *Handler) CustomMethod(ctx *gin.Context) {
+	switch ctx.Param("method") {
+	case ":batch":
+		h.BatchCreateSchedules(ctx)
+	case ":batchDelete":
+		h.BatchDeleteSchedules(ctx)
+	default:
+		ctx.Error(fmt.Errorf("%w. method=%s", errUnknownMethod, ctx.Param("method")))
+	}
+}
+
+// BatchCreateSchedules creates every item. items fail independently, so the response is 200 with a result per item.
+func (h *Handler) BatchCreateSchedules(ctx *gin.Context) {
+	var input types.BatchCreateInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	result, err := h.svc.BatchCreate(ctx.Request.Context(), input.Items)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) BatchDeleteSchedules(ctx *gin.Context) {
+	var input types.BatchDeleteInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	result, err := h.svc.BatchDelete(ctx.Request.Context(), input)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// etag is the strong ETag of a schedule version, e.g. "3"
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatch returns the version in the If-Match header, types.AnyVersion for *.
+// weak ETags never match, If-Match uses the strong comparison.
+func ifMatch(ctx *gin.Context) (int64, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+
+	switch {
+	case header == "":
+		return 0, errMissingIfMatch
+	case header == "*":
+		return types.AnyVersion, nil
+	case len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"':
+		return 0, fmt.Errorf("%w. if-match=%s", errInvalidIfMatch, header)
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("%w. if-match=%s", errInvalidIfMatch, header)
+	}
+	return version, nil
+}
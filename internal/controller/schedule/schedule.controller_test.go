@@ -0,0 +1,376 @@
+package schedule
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/middleware"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/service/schedule"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type fakeService struct {
+	schedules map[string]*types.Schedule
+}
+
+func (f *fakeService) GetByID(c context.Context, id string) (*types.Schedule, error) {
+	if sch, ok := f.schedules[id]; ok {
+		return sch, nil
+	}
+	return nil, schedule.ErrScheduleNotFound
+}
+
+func (f *fakeService) GetPaginated(c context.Context, pagination *types.PaginationOps) (*types.PaginatedResult[types.Schedule], error) {
+	return &types.PaginatedResult[types.Schedule]{Limit: pagination.Limit, Page: pagination.Page}, nil
+}
+
+func (f *fakeService) Create(c context.Context, input types.CreateScheduleInput) (*types.Schedule, error) {
+	if input.Name == "upstream" {
+		return nil, apperr.Upstream("scheduler_failed", "failed to create schedule in the scheduler", errors.New("ThrottlingException"))
+	}
+	sch := &types.Schedule{ID: "new", Name: input.Name, Expression: input.Expression}
+	f.schedules[sch.ID] = sch
+	return sch, nil
+}
+
+func (f *fakeService) Update(c context.Context, id string, version int64, input types.UpdateScheduleInput) (*types.Schedule, error) {
+	sch, ok := f.schedules[id]
+	if !ok {
+		return nil, schedule.ErrScheduleNotFound
+	}
+	if version != types.AnyVersion && version != sch.Version {
+		return nil, schedule.ErrVersionMismatch
+	}
+	sch.Expression, sch.Payload = input.Expression, input.Payload
+	sch.Version++
+	return sch, nil
+}
+
+func (f *fakeService) Delete(c context.Context, id string, version int64) error {
+	if id == "other" {
+		return fmt.Errorf("%w. reason: test", rbac.ErrForbidden)
+	}
+	sch, ok := f.schedules[id]
+	if !ok {
+		return schedule.ErrScheduleNotFound
+	}
+	if version != types.AnyVersion && version != sch.Version {
+		return schedule.ErrVersionMismatch
+	}
+	delete(f.schedules, id)
+	return nil
+}
+
+func (f *fakeService) BatchCreate(c context.Context, items []types.CreateScheduleInput) (*types.BatchResult, error) {
+	result := &types.BatchResult{}
+	for i, item := range items {
+		sch, err := f.Create(c, item)
+		if err != nil {
+			result.Items = append(result.Items, types.BatchItemResult{Index: i, Status: types.BatchFailed, Error: &types.ErrorResponse{Code: apperr.From(err).Code}})
+			result.Failed++
+			continue
+		}
+		result.Items = append(result.Items, types.BatchItemResult{Index: i, ID: sch.ID, Status: types.BatchCreated, Schedule: sch})
+		result.Succeeded++
+	}
+	return result, nil
+}
+
+func (f *fakeService) BatchDelete(c context.Context, input types.BatchDeleteInput) (*types.BatchResult, error) {
+	if (len(input.IDs) == 0) == (input.Filter == nil) {
+		return nil, schedule.ErrInvalidBatch
+	}
+	return &types.BatchResult{}, nil
+}
+
+func (f *fakeService) Export(c context.Context, filter types.ScheduleFilterInput, fn func(*types.Schedule) error) error {
+	if filter.CreatedBy == "other" {
+		return fmt.Errorf("%w. reason: test", rbac.ErrForbidden)
+	}
+	for _, sch := range f.schedules {
+		if err := fn(sch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeService) Import(c context.Context, rows []types.ImportRow, dryRun bool) (*types.ImportResult, error) {
+	result := &types.ImportResult{DryRun: dryRun}
+	for _, row := range rows {
+		if row.Err != nil {
+			result.Rows = append(result.Rows, types.ImportRowResult{Row: row.Row, Status: types.ImportInvalid, Error: &types.ErrorResponse{Code: apperr.From(row.Err).Code}})
+			result.Failed++
+			continue
+		}
+		result.Rows = append(result.Rows, types.ImportRowResult{Row: row.Row, Name: row.Input.Name, Status: types.ImportWouldCreate})
+		result.Created++
+	}
+	return result, nil
+}
+
+func (f *fakeService) ReportRun(c context.Context, id string, run types.ScheduleRunInput) error {
+	if _, ok := f.schedules[id]; !ok {
+		return schedule.ErrScheduleNotFound
+	}
+	return nil
+}
+
+func (f *fakeService) TimeZone(c context.Context) (*time.Location, error) {
+	return time.UTC, nil
+}
+
+// Watch replays the schedules as inserts after the resume token, which is the ID of a schedule
+func (f *fakeService) Watch(c context.Context, after string) (types.ScheduleEvents, error) {
+	if after == "forbidden" {
+		return nil, apperr.Forbidden("forbidden", "forbidden")
+	}
+	events := &fakeEvents{}
+	for _, id := range []string{"1", "2nd"} {
+		if sch, ok := f.schedules[id]; ok && id > after {
+			events.events = append(events.events, &types.ScheduleEvent{ID: id, Type: types.ScheduleEventInsert, Schedule: sch})
+		}
+	}
+	return events, nil
+}
+
+type fakeEvents struct {
+	events []*types.ScheduleEvent
+}
+
+func (e *fakeEvents) Next(ctx context.Context) (*types.ScheduleEvent, error) {
+	if len(e.events) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	event := e.events[0]
+	e.events = e.events[1:]
+	return event, nil
+}
+
+func (e *fakeEvents) Close() error { return nil }
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(&fakeService{schedules: map[string]*types.Schedule{
+		"1":     {ID: "1", Version: 1},
+		"2nd":   {ID: "2nd", Version: 2},
+		"daily": {ID: "daily", Name: "digest", Expression: types.Expression{Type: types.DAILY}, Version: 1},
+	}})
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.GET("/schedule", h.GetSchedules)
+	r.POST("/schedule", h.CreateSchedule)
+	r.GET("/schedule/:id", h.GetScheduleByID)
+	r.PUT("/schedule/:id", h.UpdateSchedule)
+	r.DELETE("/schedule/:id", h.DeleteSchedule)
+	r.POST("/schedule:method", h.CustomMethod)
+	r.GET("/schedule/export", h.ExportSchedules)
+	r.POST("/schedule/import", h.ImportSchedules)
+	r.POST("/schedule/import/ics", h.ImportCalendar)
+	r.GET("/calendar.ics", h.GetCalendarFeed)
+	r.POST("/schedule/:id/runs", h.ReportRun)
+
+	tests := []struct {
+		method  string
+		path    string
+		body    string
+		ifMatch string
+		status  int
+		code    string
+	}{
+		{method: http.MethodGet, path: "/schedule", status: http.StatusOK},
+		{method: http.MethodGet, path: "/schedule?limit=-1", status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodGet, path: "/schedule/1", status: http.StatusOK},
+		{method: http.MethodGet, path: "/schedule/2", status: http.StatusNotFound, code: "schedule_not_found"},
+		{method: http.MethodPost, path: "/schedule", body: `{"action":"1","name":"test","expression":{"type":"daily"}}`, status: http.StatusCreated},
+		{method: http.MethodPost, path: "/schedule", body: `{"action":"1","name":"test","expression":{"type":"yearly"}}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPost, path: "/schedule", body: `{"action":"1","name":"test"`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPost, path: "/schedule", body: `{"action":"1","name":"upstream","expression":{"type":"daily"}}`, status: http.StatusBadGateway, code: "scheduler_failed"},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"expression":{"type":"weekly"}}`, status: http.StatusPreconditionRequired, code: "if_match_required"},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"expression":{"type":"weekly"}}`, ifMatch: `W/"1"`, status: http.StatusPreconditionFailed, code: "invalid_if_match"},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"expression":{"type":"weekly"}}`, ifMatch: `"0"`, status: http.StatusPreconditionFailed, code: "version_mismatch"},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"payload":{}}`, ifMatch: `"1"`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"expression":{"type":"daily"}}`, ifMatch: `"1"`, status: http.StatusOK},
+		{method: http.MethodPut, path: "/schedule/daily", body: `{"expression":{"type":"daily"}}`, ifMatch: "*", status: http.StatusOK},
+		{method: http.MethodPut, path: "/schedule/2", body: `{"expression":{"type":"daily"}}`, ifMatch: `"1"`, status: http.StatusNotFound, code: "schedule_not_found"},
+		{method: http.MethodDelete, path: "/schedule/other", ifMatch: "*", status: http.StatusForbidden, code: "forbidden"},
+		{method: http.MethodDelete, path: "/schedule/1", status: http.StatusPreconditionRequired, code: "if_match_required"},
+		{method: http.MethodDelete, path: "/schedule/1", ifMatch: `"one"`, status: http.StatusPreconditionFailed, code: "invalid_if_match"},
+		{method: http.MethodDelete, path: "/schedule/2nd", ifMatch: `"1"`, status: http.StatusPreconditionFailed, code: "version_mismatch"},
+		{method: http.MethodDelete, path: "/schedule/1", ifMatch: `"1"`, status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/schedule:batch", body: `{"items":[{"action":"1","name":"test","expression":{"type":"daily"}},{"action":"1","name":"upstream","expression":{"type":"daily"}}]}`, status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule:batch", body: `{"items":[]}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPost, path: "/schedule:batch", body: `{"items":[{"action":"1","name":"test","expression":{"type":"yearly"}}]}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPost, path: "/schedule:batchDelete", body: `{"ids":["1"]}`, status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule:batchDelete", body: `{}`, status: http.StatusBadRequest, code: "invalid_batch"},
+		{method: http.MethodPost, path: "/schedule:purge", body: `{}`, status: http.StatusNotFound, code: "unknown_method"},
+		{method: http.MethodGet, path: "/schedule/export?format=csv", status: http.StatusOK},
+		{method: http.MethodGet, path: "/schedule/export?format=xml", status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodGet, path: "/schedule/export?created_by=other", status: http.StatusForbidden, code: "forbidden"},
+		{method: http.MethodPost, path: "/schedule/import?dry_run=true", body: `[{"action":"1","name":"test","expression":{"type":"daily"}}]`, status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule/import?format=csv", body: "name,action,type\n", status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule/import", body: `{"action":"1"}`, status: http.StatusBadRequest, code: "invalid_file"},
+		{method: http.MethodGet, path: "/schedule/daily.ics", status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule/daily/runs", body: `{"status":"failed","error":"timeout"}`, status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/schedule/daily/runs", body: `{"status":"paused"}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{method: http.MethodPost, path: "/schedule/2/runs", body: `{"status":"fired"}`, status: http.StatusNotFound, code: "schedule_not_found"},
+		{method: http.MethodGet, path: "/schedule/2.ics", status: http.StatusNotFound, code: "schedule_not_found"},
+		{method: http.MethodGet, path: "/calendar.ics?created_by=other", status: http.StatusForbidden, code: "forbidden"},
+		{method: http.MethodGet, path: "/calendar.ics", status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule/import/ics?action=1", body: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:standup\r\nDTSTART:20300107T090000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=MO\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", status: http.StatusOK},
+		{method: http.MethodPost, path: "/schedule/import/ics", body: "BEGIN:VEVENT\r\nEND:VEVENT\r\n", status: http.StatusBadRequest, code: "invalid_file"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d. got=%d body=%s", tt.method, tt.path, tt.status, w.Code, w.Body.String())
+		}
+
+		if tt.code == "" {
+			continue
+		}
+
+		var res types.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("%s %s: expected the error envelope. got=%s", tt.method, tt.path, w.Body.String())
+			continue
+		}
+		if res.Code != tt.code {
+			t.Errorf("%s %s: expected code %s. got=%s", tt.method, tt.path, tt.code, res.Code)
+		}
+		// upstream causes are logged, never returned
+		if strings.Contains(res.Message, "ThrottlingException") {
+			t.Errorf("%s %s: the upstream cause leaked to the client. got=%s", tt.method, tt.path, res.Message)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(&fakeService{schedules: map[string]*types.Schedule{"1": {ID: "1", Version: 3}}})
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.GET("/schedule/:id", h.GetScheduleByID)
+	r.PUT("/schedule/:id", h.UpdateSchedule)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schedule/1", nil))
+	if got := w.Header().Get("ETag"); got != `"3"` {
+		t.Fatalf("expected the ETag of version 3. got=%s", got)
+	}
+
+	// the ETag read is the If-Match of the update, which answers with the ETag of the next version
+	req := httptest.NewRequest(http.MethodPut, "/schedule/1", strings.NewReader(`{"expression":{"type":"daily"}}`))
+	req.Header.Set("If-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Errorf("expected 200 with the ETag of version 4. got=%d %s", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestImportValidatesRows(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(&fakeService{schedules: map[string]*types.Schedule{}})
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.POST("/schedule/import", h.ImportSchedules)
+
+	body := "name: ok\naction: \"1\"\nexpression:\n  type: daily\n"
+	body = "- " + strings.ReplaceAll(body, "\n", "\n  ") + "\n- name: x\n  action: \"1\"\n  expression:\n    type: yearly\n"
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/schedule/import?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/yaml")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d. got=%d body=%s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var res types.ImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response: %s", w.Body.String())
+	}
+	if !res.DryRun || res.Created != 1 || res.Failed != 1 {
+		t.Fatalf("expected 1 row to create and 1 invalid. got=%+v", res)
+	}
+	if res.Rows[1].Row != 2 || res.Rows[1].Error == nil || res.Rows[1].Error.Code != "invalid_request" {
+		t.Errorf("expected row 2 to fail binding. got=%+v", res.Rows[1])
+	}
+}
+
+func TestStreamSchedules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(&fakeService{schedules: map[string]*types.Schedule{"1": {ID: "1", Name: "first"}, "2nd": {ID: "2nd", Name: "second"}}})
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.GET("/schedule/stream", h.StreamSchedules)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the stream resumes after the Last-Event-ID
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/schedule/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error streaming: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected 200 text/event-stream. got=%d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	var lines []string
+	for scanner := bufio.NewScanner(res.Body); scanner.Scan() && scanner.Text() != ""; {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{"id: 2nd", "event: insert", `data: {"type":"insert","schedule":`}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines. got=%q", len(want), lines)
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], want[i]) {
+			t.Errorf("expected line %d to start with %q. got=%q", i, want[i], lines[i])
+		}
+	}
+
+	// errors before the stream starts are the usual envelope
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/schedule/stream?after=forbidden", nil)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error streaming: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403. got=%d", res.StatusCode)
+	}
+}
@@ -0,0 +1,103 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// heartbeat keeps the idle streams open through the proxies
+const heartbeat = 15 * time.Second
+
+// StreamSchedules streams the changes of the schedules as server-sent events, until the client disconnects.
+// the stream resumes after the Last-Event-ID header, sent by EventSource on reconnects, or the after query.
+func (h *Handler) StreamSchedules(ctx *gin.Context) {
+	after := ctx.GetHeader("Last-Event-ID")
+	if after == "" {
+		after = ctx.Query("after")
+	}
+
+	events, err := h.svc.Watch(ctx.Request.Context(), after)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	defer events.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	c, cancel := context.WithCancel(ctx.Request.Context())
+	next, failed := make(chan *types.ScheduleEvent), make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			event, err := events.Next(c)
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case next <- event:
+			case <-c.Done():
+				return
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-next:
+			if err := writeEvent(ctx.Writer, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case err := <-failed:
+			if c.Err() == nil {
+				logging.FromContext(c).Error("schedule stream interrupted", "error", err.Error())
+			}
+			return
+		case <-c.Done():
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, event *types.ScheduleEvent) error {
+	data, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
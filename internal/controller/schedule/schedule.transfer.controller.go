@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/transfer"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// maxImportBytes bounds the body of an import
+const maxImportBytes = 10 << 20
+
+// ExportSchedules streams every schedule matching the filter. an error after the first schedule was written can only end the stream early.
+func (h *Handler) ExportSchedules(ctx *gin.Context) {
+	var query types.ExportQuery
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if query.Format == "" {
+		query.Format = transfer.JSON
+	}
+
+	if query.Format == icsFormat {
+		h.exportCalendar(ctx, query.ScheduleFilterInput)
+		return
+	}
+
+	w, err := transfer.NewWriter(ctx.Writer, query.Format)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Type", transfer.ContentType(query.Format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="schedules.%s"`, query.Format))
+
+	err = h.svc.Export(ctx.Request.Context(), query.ScheduleFilterInput, func(sch *types.Schedule) error {
+		return w.Write(transfer.Record(sch))
+	})
+
+	if err == nil {
+		err = w.Close()
+	}
+
+	endStream(ctx, err)
+}
+
+// endStream ends a streamed file. an error replaces the file with the error envelope while nothing was sent, and ends the stream early otherwise.
+func endStream(ctx *gin.Context, err error) {
+	if err == nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	if ctx.Writer.Written() {
+		logging.FromContext(ctx.Request.Context()).Error("stream interrupted", "path", ctx.FullPath(), "error", err.Error())
+		ctx.Abort()
+		return
+	}
+	ctx.Writer.Header().Del("Content-Disposition")
+	ctx.Writer.Header().Del("Content-Type")
+	ctx.Error(err)
+}
+
+// ImportSchedules reads the schedules of the body, in the format of the query or of the Content-Type.
+// rows are validated like the create body and failing rows are reported without failing the import.
+func (h *Handler) ImportSchedules(ctx *gin.Context) {
+	var query types.ImportQuery
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if query.Format == "" {
+		format, err := transfer.FormatOf(ctx.ContentType())
+		if err != nil {
+			ctx.Error(apperr.Validation("unsupported_format", "set format or a JSON, YAML or CSV Content-Type", nil))
+			return
+		}
+		query.Format = format
+	}
+
+	rows, err := transfer.Read(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes), query.Format)
+
+	if err != nil {
+		ctx.Error(apperr.Validation("invalid_file", err.Error(), nil))
+		return
+	}
+
+	for i := range rows {
+		if rows[i].Err != nil {
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&rows[i].Input); err != nil {
+			rows[i].Err = apperr.Binding(err)
+		}
+	}
+
+	result, err := h.svc.Import(ctx.Request.Context(), rows, query.DryRun)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
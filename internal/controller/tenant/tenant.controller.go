@@ -0,0 +1,57 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type TenantService interface {
+	GetCurrent(c context.Context) (*types.Tenant, error)
+	Upsert(c context.Context, input types.UpsertTenantInput) (*types.Tenant, error)
+	Delete(c context.Context) error
+}
+
+// GetTenant returns the configuration of the caller's tenant
+func (h *Handler) GetTenant(ctx *gin.Context) {
+	t, err := h.svc.GetCurrent(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, t)
+}
+
+// UpsertTenant configures the caller's tenant
+func (h *Handler) UpsertTenant(ctx *gin.Context) {
+	var input types.UpsertTenantInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	t, err := h.svc.Upsert(ctx.Request.Context(), input)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, t)
+}
+
+// DeleteTenant tears down the caller's tenant and every schedule it owns
+func (h *Handler) DeleteTenant(ctx *gin.Context) {
+	if err := h.svc.Delete(ctx.Request.Context()); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
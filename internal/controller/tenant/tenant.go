@@ -0,0 +1,12 @@
+package tenant
+
+// Handler serves the tenant routes
+type Handler struct {
+	svc TenantService
+}
+
+func NewHandler(svc TenantService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type WebhookService interface {
+	Create(c context.Context, input types.CreateWebhookInput) (*types.CreatedWebhook, error)
+	GetAll(c context.Context) ([]types.Webhook, error)
+	Delete(c context.Context, id string) error
+	Deliveries(c context.Context, webhookID string, filter *types.DeliveryFilter) (*types.PaginatedResult[types.WebhookDelivery], error)
+	Replay(c context.Context, webhookID, deliveryID string) (*types.WebhookDelivery, error)
+}
+
+// CreateWebhook registers a webhook. the signing secret is only part of this response.
+func (h *Handler) CreateWebhook(ctx *gin.Context) {
+	var input types.CreateWebhookInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	webhook, err := h.svc.Create(ctx.Request.Context(), input)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+func (h *Handler) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := h.svc.GetAll(ctx.Request.Context())
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+func (h *Handler) DeleteWebhook(ctx *gin.Context) {
+	if err := h.svc.Delete(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetDeliveries returns the delivery log of the webhook, newest first
+func (h *Handler) GetDeliveries(ctx *gin.Context) {
+	var filter types.DeliveryFilter
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.Error(apperr.Binding(err))
+		return
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+
+	deliveries, err := h.svc.Deliveries(ctx.Request.Context(), ctx.Param("id"), &filter)
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery queues the event of a delivery again
+func (h *Handler) ReplayDelivery(ctx *gin.Context) {
+	delivery, err := h.svc.Replay(ctx.Request.Context(), ctx.Param("id"), ctx.Param("delivery"))
+
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
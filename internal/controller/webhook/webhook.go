@@ -0,0 +1,12 @@
+package webhook
+
+// Handler serves the webhook routes
+type Handler struct {
+	svc WebhookService
+}
+
+func NewHandler(svc WebhookService) *Handler {
+	return &Handler{
+		svc: svc,
+	}
+}
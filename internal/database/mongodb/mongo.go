@@ -2,39 +2,38 @@ package mongodb
 
 import (
 	"context"
-	"os"
-	"time"
+	"fmt"
+	"log/slog"
 
+	"github.com/japb1998/action-scheduler/internal/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
-var (
-	mongoUri    string = os.Getenv("MONGO_URI")
-	mongoClient *mongo.Client
-)
-
-func MustInit() *mongo.Client {
-	if mongoClient != nil {
-		return mongoClient
-	}
+// Connect creates a client. the caller owns the client and must Disconnect it.
+// an unreachable server is not an error: the driver reconnects and readiness reports it in the meantime.
+func Connect(ctx context.Context, cfg config.Mongo) (*mongo.Client, error) {
 	// Use the SetServerAPIOptions() method to set the Stable API version to 1
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(mongoUri).SetServerAPIOptions(serverAPI).SetTimeout(time.Second * 29)
+	// the monitor creates a span for every command, as a child of the span in the command context.
+	opts := options.Client().ApplyURI(cfg.URI).SetServerAPIOptions(serverAPI).SetTimeout(cfg.Timeout).SetMonitor(otelmongo.NewMonitor())
 	// Create a new client and connect to the server
-	client, err := mongo.Connect(context.TODO(), opts)
+	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to connect to mongo. error=%w", err)
 	}
 
-	// Send a ping to confirm a successful connection
-	var result bson.M
-	if err := client.Database("admin").RunCommand(context.TODO(), bson.D{{"ping", 1}}).Decode(&result); err != nil {
-		panic(err)
+	if err := Ping(ctx, client); err != nil {
+		slog.Warn("mongo is not reachable yet", "package", "mongodb", "error", err.Error())
 	}
 
-	mongoClient = client
+	return client, nil
+}
 
-	return mongoClient
+// Ping sends a ping to confirm the server is reachable
+func Ping(ctx context.Context, client *mongo.Client) error {
+	var result bson.M
+	return client.Database("admin").RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Decode(&result)
 }
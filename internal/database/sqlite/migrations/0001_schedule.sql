@@ -0,0 +1,16 @@
+CREATE TABLE schedule (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    client_token TEXT NOT NULL,
+    expression_type TEXT NOT NULL,
+    -- RFC 3339 in UTC, the zero time when unset
+    expression_start TEXT NOT NULL,
+    expression_end TEXT NOT NULL,
+    -- JSON object
+    payload TEXT NOT NULL
+);
+
+CREATE INDEX schedule_tenant ON schedule (tenant_id, created_by, action);
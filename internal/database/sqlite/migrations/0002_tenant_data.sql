@@ -0,0 +1,99 @@
+-- the collections kept in mongo by the mongo store. times are RFC 3339 in UTC with nanoseconds, fixed width so they compare as text.
+-- the zero time is the unset one, lists are JSON arrays and ids are 24 hex characters.
+CREATE TABLE tenant (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    allowed_actions TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE TABLE audit (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    schedule_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    -- JSON schedules, NULL when unset
+    before TEXT,
+    after TEXT
+);
+
+CREATE INDEX audit_tenant ON audit (tenant_id, timestamp);
+
+CREATE TABLE role_binding (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    role TEXT NOT NULL,
+    actions TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    UNIQUE (tenant_id, subject)
+);
+
+CREATE TABLE api_key (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    actions TEXT NOT NULL,
+    read_only INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    last_used_at TEXT NOT NULL,
+    revoked_at TEXT NOT NULL
+);
+
+CREATE INDEX api_key_hash ON api_key (hash);
+CREATE INDEX api_key_tenant ON api_key (tenant_id);
+
+CREATE TABLE webhook (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    events TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX webhook_tenant ON webhook (tenant_id);
+
+CREATE TABLE webhook_delivery (
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    webhook_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    next_attempt_at TEXT NOT NULL,
+    locked_until TEXT NOT NULL,
+    last_status_code INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    delivered_at TEXT NOT NULL,
+    replay_of TEXT NOT NULL
+);
+
+-- Claim looks for the due pending deliveries
+CREATE INDEX webhook_delivery_due ON webhook_delivery (status, next_attempt_at);
+CREATE INDEX webhook_delivery_tenant ON webhook_delivery (tenant_id, webhook_id, created_at);
+
+CREATE TABLE quota_counter (
+    -- <tenant>:<scope>:<key>
+    id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    -- NULL for the counters that don't expire
+    expires_at TEXT
+);
+
+CREATE INDEX quota_counter_tenant ON quota_counter (tenant_id, scope);
@@ -0,0 +1,2 @@
+-- schedules stored before versioning are at version 0
+ALTER TABLE schedule ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,116 @@
+// sqlite package opens the embedded SQLite database of single node deployments and keeps its schema migrated
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// registers the pure Go "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens the database at path and applies the pending migrations. ":memory:" is a database that lives as long as the returned DB.
+// the caller owns the DB and must Close it.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", path)
+	if path != ":memory:" {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s. error=%w", path, err)
+	}
+	// sqlite serializes the writes anyway, and an in memory database only exists on its connection
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate applies, in order and each in its own transaction, the migrations newer than the schema version of the database
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create the migrations table. error=%w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to get the schema version. error=%w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version, err := migrationVersion(file)
+
+		if err != nil {
+			return err
+		}
+		if version <= current {
+			continue
+		}
+
+		if err := apply(ctx, db, file, version); err != nil {
+			return err
+		}
+		slog.Info("applied sqlite migration", "package", "sqlite", "migration", file)
+	}
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, file string, version int) error {
+	script, err := migrations.ReadFile(file)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("failed to apply migration %s. error=%w", file, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to record migration %s. error=%w", file, err)
+	}
+	return tx.Commit()
+}
+
+// migrationVersion parses the number prefixing the name of a migration, as in 0001_schedule.sql
+func migrationVersion(file string) (int, error) {
+	name := strings.TrimPrefix(file, "migrations/")
+	prefix, _, _ := strings.Cut(name, "_")
+
+	version, err := strconv.Atoi(prefix)
+
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid migration name %s, expected <version>_<name>.sql", name)
+	}
+	return version, nil
+}
@@ -0,0 +1,178 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/middleware"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// metadata keys, the gRPC counterparts of the HTTP headers
+const (
+	RequestIDKey     = "x-request-id"
+	APIKeyKey        = "x-api-key"
+	AuthorizationKey = "authorization"
+)
+
+const errorDomain = "action-scheduler"
+
+var (
+	errMissingCredentials = apperr.Unauthorized("missing_credentials", "missing bearer token or api key")
+	errMissingTenant      = apperr.Forbidden("missing_tenant", "token is not bound to a tenant")
+)
+
+// loggingInterceptor propagates the x-request-id metadata or generates a new one, like the HTTP middleware.RequestID,
+// stores a logger carrying it in the context and logs the call.
+func loggingInterceptor(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	id := firstMetadata(c, RequestIDKey)
+	if !middleware.ValidRequestID(id) {
+		id = uuid.NewString()
+	}
+	_ = grpc.SetHeader(c, metadata.Pairs(RequestIDKey, id))
+
+	c = requestctx.WithRequestID(c, id)
+	c = logging.With(c, slog.String("request_id", id))
+
+	res, err := handler(c, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unavailable || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	logging.FromContext(c).LogAttrs(c, level, "rpc served",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	)
+	return res, err
+}
+
+// errorInterceptor maps domain errors to status errors. the domain code is sent as an ErrorInfo detail.
+func errorInterceptor(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	res, err := handler(c, req)
+
+	if err == nil {
+		return res, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return res, err
+	}
+
+	e := apperr.From(err)
+	message := e.Message
+	if e.Public() {
+		message = err.Error()
+	}
+
+	logger := logging.FromContext(c).With("code", e.Code, "error", err.Error())
+	if e.Public() {
+		logger.Info("rpc rejected")
+	} else {
+		logger.Error("rpc failed")
+	}
+
+	st := status.New(Code(e.Kind), message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   e.Code,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"request_id": requestctx.RequestID(c)},
+	}}
+
+	if fields, ok := e.Details.([]types.FieldError); ok {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(fields))
+		for _, f := range fields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Error})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return nil, st.Err()
+}
+
+// authInterceptor verifies the x-api-key or the bearer token metadata and stores the principal and its tenant in the context
+func authInterceptor(v *auth.Verifier, keys middleware.APIKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(c context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var (
+			p   auth.Principal
+			err error
+		)
+
+		if key := firstMetadata(c, APIKeyKey); key != "" {
+			p, err = keys.Authenticate(c, key)
+		} else {
+			token, ok := strings.CutPrefix(firstMetadata(c, AuthorizationKey), "Bearer ")
+
+			if !ok || token == "" {
+				return nil, errMissingCredentials
+			}
+
+			p, err = v.Verify(c, token)
+			if err != nil {
+				err = auth.ErrInvalidToken
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.TenantID == "" {
+			return nil, errMissingTenant
+		}
+
+		c = auth.WithPrincipal(c, p)
+		c = requestctx.WithActor(c, p.Subject)
+		c = requestctx.WithTenant(c, p.TenantID)
+		c = logging.With(c, slog.String("actor", p.Subject), slog.String("tenant_id", p.TenantID))
+		return handler(c, req)
+	}
+}
+
+// Code maps the kind of a domain error to its status code
+func Code(kind apperr.Kind) codes.Code {
+	switch kind {
+	case apperr.KindValidation:
+		return codes.InvalidArgument
+	case apperr.KindUnauthorized:
+		return codes.Unauthenticated
+	case apperr.KindForbidden:
+		return codes.PermissionDenied
+	case apperr.KindNotFound:
+		return codes.NotFound
+	case apperr.KindConflict:
+		return codes.AlreadyExists
+	case apperr.KindQuotaExceeded:
+		return codes.ResourceExhausted
+	case apperr.KindPreconditionFailed, apperr.KindPreconditionRequired:
+		return codes.FailedPrecondition
+	case apperr.KindUpstream:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+func firstMetadata(c context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(c, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
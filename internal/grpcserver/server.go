@@ -0,0 +1,141 @@
+// grpcserver package serves the schedule gRPC API backed by the same services as the REST API
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin/binding"
+	schedulev1 "github.com/japb1998/action-scheduler/api/schedule/v1"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/middleware"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"google.golang.org/grpc"
+)
+
+type ScheduleService interface {
+	GetByID(c context.Context, id string) (*types.Schedule, error)
+	GetPaginated(c context.Context, pagination *types.PaginationOps) (*types.PaginatedResult[types.Schedule], error)
+	Create(c context.Context, schedule types.CreateScheduleInput) (*types.Schedule, error)
+	Delete(c context.Context, id string, version int64) error
+	Update(c context.Context, id string, version int64, input types.UpdateScheduleInput) (*types.Schedule, error)
+}
+
+var (
+	errMissingVersion = apperr.PreconditionRequired("version_required", "the version of the schedule is required")
+	errInvalidVersion = apperr.Validation("invalid_version", "the version of the schedule can't be negative", nil)
+)
+
+type scheduleServer struct {
+	schedulev1.UnimplementedScheduleServiceServer
+	svc ScheduleService
+}
+
+// New builds the gRPC server. interceptors run in order: logging, error mapping, authentication.
+func New(svc ScheduleService, v *auth.Verifier, keys middleware.APIKeyAuthenticator, ops ...grpc.ServerOption) *grpc.Server {
+	ops = append(ops, grpc.ChainUnaryInterceptor(
+		loggingInterceptor,
+		errorInterceptor,
+		authInterceptor(v, keys),
+	))
+
+	s := grpc.NewServer(ops...)
+	schedulev1.RegisterScheduleServiceServer(s, &scheduleServer{svc: svc})
+	return s
+}
+
+func (s *scheduleServer) GetSchedule(c context.Context, req *schedulev1.GetScheduleRequest) (*schedulev1.Schedule, error) {
+	sch, err := s.svc.GetByID(c, req.GetId())
+
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapScheduleTypeToProto(sch)
+}
+
+func (s *scheduleServer) ListSchedules(c context.Context, req *schedulev1.ListSchedulesRequest) (*schedulev1.ListSchedulesResponse, error) {
+	pagination := types.PaginationOps{Page: int(req.GetPage()), Limit: int(req.GetLimit())}
+
+	if err := binding.Validator.ValidateStruct(&pagination); err != nil {
+		return nil, apperr.Binding(err)
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	result, err := s.svc.GetPaginated(c, &pagination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*schedulev1.Schedule, 0, len(result.Items))
+	for i := range result.Items {
+		item, err := mapper.MapScheduleTypeToProto(&result.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return &schedulev1.ListSchedulesResponse{
+		Items: items,
+		Total: int32(result.Total),
+		Page:  int32(result.Page),
+		Limit: int32(result.Limit),
+	}, nil
+}
+
+func (s *scheduleServer) CreateSchedule(c context.Context, req *schedulev1.CreateScheduleRequest) (*schedulev1.Schedule, error) {
+	input := mapper.MapCreateScheduleProtoToType(req)
+
+	// same binding rules as the REST API
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, apperr.Binding(err)
+	}
+
+	sch, err := s.svc.Create(c, input)
+
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapScheduleTypeToProto(sch)
+}
+
+func (s *scheduleServer) DeleteSchedule(c context.Context, req *schedulev1.DeleteScheduleRequest) (*schedulev1.DeleteScheduleResponse, error) {
+	if req.Version == nil {
+		return nil, errMissingVersion
+	}
+	if req.GetVersion() < 0 {
+		return nil, errInvalidVersion
+	}
+
+	if err := s.svc.Delete(c, req.GetId(), req.GetVersion()); err != nil {
+		return nil, err
+	}
+	return &schedulev1.DeleteScheduleResponse{}, nil
+}
+
+func (s *scheduleServer) UpdateSchedule(c context.Context, req *schedulev1.UpdateScheduleRequest) (*schedulev1.Schedule, error) {
+	if req.Version == nil {
+		return nil, errMissingVersion
+	}
+	if req.GetVersion() < 0 {
+		return nil, errInvalidVersion
+	}
+	input := mapper.MapUpdateScheduleProtoToType(req)
+
+	// same binding rules as the REST API
+	if err := binding.Validator.ValidateStruct(&input); err != nil {
+		return nil, apperr.Binding(err)
+	}
+
+	sch, err := s.svc.Update(c, req.GetId(), req.GetVersion(), input)
+
+	if err != nil {
+		return nil, err
+	}
+	return mapper.MapScheduleTypeToProto(sch)
+}
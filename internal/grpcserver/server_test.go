@@ -0,0 +1,313 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	schedulev1 "github.com/japb1998/action-scheduler/api/schedule/v1"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/service/schedule"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+var secret = []byte("local-secret")
+
+type fakeService struct {
+	schedules map[string]*types.Schedule
+}
+
+func (f *fakeService) GetByID(c context.Context, id string) (*types.Schedule, error) {
+	if sch, ok := f.schedules[id]; ok {
+		return sch, nil
+	}
+	return nil, schedule.ErrScheduleNotFound
+}
+
+func (f *fakeService) GetPaginated(c context.Context, pagination *types.PaginationOps) (*types.PaginatedResult[types.Schedule], error) {
+	return &types.PaginatedResult[types.Schedule]{Limit: pagination.Limit, Page: pagination.Page}, nil
+}
+
+func (f *fakeService) Create(c context.Context, input types.CreateScheduleInput) (*types.Schedule, error) {
+	if input.Name == "upstream" {
+		return nil, apperr.Upstream("scheduler_failed", "failed to create schedule in the scheduler", errors.New("ThrottlingException"))
+	}
+	sch := &types.Schedule{ID: "new", Name: input.Name, Expression: input.Expression, Payload: input.Payload}
+	f.schedules[sch.ID] = sch
+	return sch, nil
+}
+
+func (f *fakeService) Delete(c context.Context, id string, version int64) error {
+	sch, ok := f.schedules[id]
+	if !ok {
+		return schedule.ErrScheduleNotFound
+	}
+	if version != types.AnyVersion && version != sch.Version {
+		return schedule.ErrVersionMismatch
+	}
+	delete(f.schedules, id)
+	return nil
+}
+
+func (f *fakeService) Update(c context.Context, id string, version int64, input types.UpdateScheduleInput) (*types.Schedule, error) {
+	sch, ok := f.schedules[id]
+	if !ok {
+		return nil, schedule.ErrScheduleNotFound
+	}
+	if version != types.AnyVersion && version != sch.Version {
+		return nil, schedule.ErrVersionMismatch
+	}
+	sch.Expression, sch.Payload = input.Expression, input.Payload
+	sch.Version++
+	return sch, nil
+}
+
+type noKeys struct{}
+
+func (noKeys) Authenticate(c context.Context, key string) (auth.Principal, error) {
+	return auth.Principal{}, auth.ErrInvalidToken
+}
+
+func newClient(t *testing.T) schedulev1.ScheduleServiceClient {
+	t.Helper()
+
+	v, err := auth.NewVerifier(auth.VerifierOps{HMACSecret: secret})
+	if err != nil {
+		t.Fatalf("error creating verifier: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	s := New(&fakeService{schedules: map[string]*types.Schedule{"1": {ID: "1", Name: "existing", Version: 1}}}, v, noKeys{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return schedulev1.NewScheduleServiceClient(conn)
+}
+
+func withToken(t *testing.T, tenantID string) context.Context {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":       "user-1",
+		"tenant_id": tenantID,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatalf("error signing token: %v", err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), AuthorizationKey, "Bearer "+token)
+}
+
+func TestScheduleService(t *testing.T) {
+	client := newClient(t)
+	daily := &schedulev1.Expression{Type: schedulev1.ExpressionType_EXPRESSION_TYPE_DAILY}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		call   func(ctx context.Context) error
+		code   codes.Code
+		reason string
+	}{
+		{
+			name: "missing credentials",
+			ctx:  context.Background(),
+			call: func(ctx context.Context) error {
+				_, err := client.GetSchedule(ctx, &schedulev1.GetScheduleRequest{Id: "1"})
+				return err
+			},
+			code:   codes.Unauthenticated,
+			reason: "missing_credentials",
+		},
+		{
+			name: "missing tenant",
+			ctx:  withToken(t, ""),
+			call: func(ctx context.Context) error {
+				_, err := client.GetSchedule(ctx, &schedulev1.GetScheduleRequest{Id: "1"})
+				return err
+			},
+			code:   codes.PermissionDenied,
+			reason: "missing_tenant",
+		},
+		{
+			name: "get",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.GetSchedule(ctx, &schedulev1.GetScheduleRequest{Id: "1"})
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "not found",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.GetSchedule(ctx, &schedulev1.GetScheduleRequest{Id: "2"})
+				return err
+			},
+			code:   codes.NotFound,
+			reason: "schedule_not_found",
+		},
+		{
+			name: "list",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				res, err := client.ListSchedules(ctx, &schedulev1.ListSchedulesRequest{})
+				if err == nil && res.GetLimit() != 10 {
+					t.Errorf("expected default limit 10. got=%d", res.GetLimit())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "create",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateSchedule(ctx, &schedulev1.CreateScheduleRequest{Action: "1", Name: "test", Expression: daily})
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "create invalid",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateSchedule(ctx, &schedulev1.CreateScheduleRequest{Action: "1", Name: "t", Expression: daily})
+				return err
+			},
+			code:   codes.InvalidArgument,
+			reason: "invalid_request",
+		},
+		{
+			name: "create upstream",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateSchedule(ctx, &schedulev1.CreateScheduleRequest{Action: "1", Name: "upstream", Expression: daily})
+				return err
+			},
+			code:   codes.Unavailable,
+			reason: "scheduler_failed",
+		},
+		{
+			name: "update without version",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.UpdateSchedule(ctx, &schedulev1.UpdateScheduleRequest{Id: "1", Expression: daily})
+				return err
+			},
+			code:   codes.FailedPrecondition,
+			reason: "version_required",
+		},
+		{
+			name: "update",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				sch, err := client.UpdateSchedule(ctx, &schedulev1.UpdateScheduleRequest{Id: "1", Version: proto.Int64(1), Expression: daily})
+				if err == nil && sch.GetVersion() != 2 {
+					return fmt.Errorf("expected version 2. got=%d", sch.GetVersion())
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "stale update",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.UpdateSchedule(ctx, &schedulev1.UpdateScheduleRequest{Id: "1", Version: proto.Int64(1), Expression: daily})
+				return err
+			},
+			code:   codes.FailedPrecondition,
+			reason: "version_mismatch",
+		},
+		{
+			name: "delete without version",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.DeleteSchedule(ctx, &schedulev1.DeleteScheduleRequest{Id: "1"})
+				return err
+			},
+			code:   codes.FailedPrecondition,
+			reason: "version_required",
+		},
+		{
+			name: "negative version",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.DeleteSchedule(ctx, &schedulev1.DeleteScheduleRequest{Id: "1", Version: proto.Int64(types.AnyVersion)})
+				return err
+			},
+			code:   codes.InvalidArgument,
+			reason: "invalid_version",
+		},
+		{
+			name: "stale delete",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.DeleteSchedule(ctx, &schedulev1.DeleteScheduleRequest{Id: "1", Version: proto.Int64(1)})
+				return err
+			},
+			code:   codes.FailedPrecondition,
+			reason: "version_mismatch",
+		},
+		{
+			name: "delete",
+			ctx:  withToken(t, "tenant-1"),
+			call: func(ctx context.Context) error {
+				_, err := client.DeleteSchedule(ctx, &schedulev1.DeleteScheduleRequest{Id: "1", Version: proto.Int64(2)})
+				return err
+			},
+			code: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx)
+			st := status.Convert(err)
+
+			if st.Code() != tt.code {
+				t.Fatalf("expected code %s. got=%s (%s)", tt.code, st.Code(), st.Message())
+			}
+			if tt.code == codes.Unavailable && st.Message() != "failed to create schedule in the scheduler" {
+				t.Errorf("upstream cause leaked in message %q", st.Message())
+			}
+			if tt.reason == "" {
+				return
+			}
+
+			for _, d := range st.Details() {
+				if info, ok := d.(*errdetails.ErrorInfo); ok {
+					if info.GetReason() != tt.reason {
+						t.Errorf("expected reason %q. got=%q", tt.reason, info.GetReason())
+					}
+					return
+				}
+			}
+			t.Errorf("expected an ErrorInfo detail")
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// ical package renders schedules as iCalendar (RFC 5545) events with recurrence rules, and reads them back for the calendar import
+package ical
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+const ContentType = "text/calendar; charset=utf-8"
+
+// properties carrying what an event needs to be imported as a schedule again
+const (
+	actionProperty  = "X-ACTION-SCHEDULER-ACTION"
+	payloadProperty = "X-ACTION-SCHEDULER-PAYLOAD"
+)
+
+// lineLength is the octet limit of a content line, longer lines are folded
+const lineLength = 75
+
+const (
+	dateTimeUTC = "20060102T150405Z"
+	dateTime    = "20060102T150405"
+	date        = "20060102"
+)
+
+// weekdays are the BYDAY codes, indexed by time.Weekday
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Writer streams the events of schedules in a VCALENDAR. nothing is written before the first Write or Close, so the caller can still send an error instead.
+//
+// times are written in loc, the time zone of the tenant the scheduler fires in, with a TZID and without VTIMEZONE,
+// which calendar apps resolve from the IANA name.
+// schedules without start date fire relative to their creation, which isn't stored, so they are rendered from now.
+type Writer struct {
+	w        io.Writer
+	loc      *time.Location
+	now      time.Time
+	payloads bool
+	started  bool
+}
+
+func NewWriter(w io.Writer, loc *time.Location, now time.Time) *Writer {
+	return &Writer{w: w, loc: loc, now: now}
+}
+
+// WithPayloads writes the payloads of the schedules in the events, so the file can be imported back.
+// only the export sets it, the feeds are read by calendar servers which have no business with the payloads.
+func (w *Writer) WithPayloads() *Writer {
+	w.payloads = true
+	return w
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//action-scheduler//schedules//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Schedules",
+	}
+	if w.loc != time.UTC {
+		lines = append(lines, "X-WR-TIMEZONE:"+w.loc.String())
+	}
+	return w.lines(lines...)
+}
+
+// Write writes the event of the schedule. a schedule that will never fire again and has no start date has no event.
+func (w *Writer) Write(sch *types.Schedule) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	start, end := time.Time(sch.Expression.Start), time.Time(sch.Expression.End)
+	first := start.In(w.loc)
+
+	if start.IsZero() {
+		exp, _ := scheduler.NewExpression(start, end, sch.Expression.Type)
+		runs := exp.Next(w.now, 1, w.loc)
+		if len(runs) == 0 {
+			return nil
+		}
+		first = runs[0]
+	}
+
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + sch.ID + "@action-scheduler",
+		"DTSTAMP:" + w.now.UTC().Format(dateTimeUTC),
+		w.dateTime("DTSTART", first),
+		"SUMMARY:" + escape(sch.Name),
+		"DESCRIPTION:" + escape(fmt.Sprintf("runs action %s", actionName(sch.Action))),
+	}
+
+	if rule := w.rule(sch.Expression, first); rule != "" {
+		lines = append(lines, "RRULE:"+rule)
+	}
+
+	lines = append(lines, actionProperty+":"+escape(sch.Action.Id))
+	if w.payloads && len(sch.Payload) > 0 {
+		by, err := json.Marshal(sch.Payload)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, payloadProperty+":"+escape(string(by)))
+	}
+
+	return w.lines(append(lines, "END:VEVENT")...)
+}
+
+// Close ends the calendar
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.lines("END:VCALENDAR")
+}
+
+// rule returns the RRULE of the expression starting at first. one time schedules have none.
+func (w *Writer) rule(exp types.Expression, first time.Time) string {
+	var rule string
+
+	switch exp.Type {
+	case types.DAILY:
+		rule = "FREQ=DAILY"
+	case types.WEEKLY:
+		rule = "FREQ=WEEKLY;BYDAY=" + weekdays[first.Weekday()]
+	case types.MONTHLY:
+		day := first.Day()
+		// same rule as the scheduler, a schedule without start created on the last day of the month fires on the last day
+		now := w.now.In(w.loc)
+		if time.Time(exp.Start).IsZero() && now.AddDate(0, 0, 1).Month() != now.Month() {
+			day = -1
+		}
+		rule = fmt.Sprintf("FREQ=MONTHLY;BYMONTHDAY=%d", day)
+	default:
+		return ""
+	}
+
+	if end := time.Time(exp.End); !end.IsZero() {
+		rule += ";UNTIL=" + end.UTC().Format(dateTimeUTC)
+	}
+	return rule
+}
+
+func (w *Writer) dateTime(name string, t time.Time) string {
+	if w.loc == time.UTC {
+		return name + ":" + t.UTC().Format(dateTimeUTC)
+	}
+	return fmt.Sprintf("%s;TZID=%s:%s", name, w.loc.String(), t.In(w.loc).Format(dateTime))
+}
+
+// lines writes content lines, folded and ended with CRLF
+func (w *Writer) lines(lines ...string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		fold(&b, line)
+	}
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+// fold splits a line in lines of at most lineLength octets without splitting a character. continuation lines start with a space.
+func fold(b *strings.Builder, line string) {
+	limit := lineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space counts towards the limit
+		limit = lineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func actionName(a types.Action) string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Id
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
@@ -0,0 +1,141 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestRoundTrip(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+	now := time.Date(2030, 1, 15, 9, 0, 0, 0, loc)
+	at := func(month time.Month, day, hour int) types.ScheduleDate {
+		return types.ScheduleDate(time.Date(2030, month, day, hour, 0, 0, 0, loc))
+	}
+
+	schedules := []types.Schedule{
+		{ID: "1", Name: "digest; daily", Action: types.Action{Id: "a1", Name: "email"}, Expression: types.Expression{Type: types.DAILY, Start: at(2, 1, 8), End: at(3, 1, 8)}, Payload: map[string]any{"to": "a@b.c"}},
+		{ID: "2", Name: "standup", Action: types.Action{Id: "a1"}, Expression: types.Expression{Type: types.WEEKLY, Start: at(2, 6, 10)}},
+		{ID: "3", Name: "report", Action: types.Action{Id: "a2"}, Expression: types.Expression{Type: types.MONTHLY, Start: at(2, 20, 12)}},
+		{ID: "4", Name: strings.Repeat("long name ", 12), Action: types.Action{Id: "a2"}, Expression: types.Expression{Type: types.ONE, Start: at(4, 1, 7)}},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, loc, now).WithPayloads()
+	for i := range schedules {
+		if err := w.Write(&schedules[i]); err != nil {
+			t.Fatalf("error writing: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing: %v", err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > lineLength {
+			t.Errorf("expected lines of at most %d octets. got=%q", lineLength, line)
+		}
+	}
+	for _, want := range []string{"RRULE:FREQ=DAILY;UNTIL=20300301T130000Z", "RRULE:FREQ=WEEKLY;BYDAY=WE", "RRULE:FREQ=MONTHLY;BYMONTHDAY=20", "DTSTART;TZID=America/New_York:20300401T070000"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+
+	rows, err := Read(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("error reading:\n%s\n%v", buf.String(), err)
+	}
+	if len(rows) != len(schedules) {
+		t.Fatalf("expected %d rows. got=%d", len(schedules), len(rows))
+	}
+
+	for i, row := range rows {
+		want := schedules[i]
+		if row.Err != nil {
+			t.Fatalf("row %d: unexpected error %v", row.Row, row.Err)
+		}
+		if row.Input.Name != want.Name || row.Input.ActionID != want.Action.Id || row.Input.Expression.Type != want.Expression.Type {
+			t.Errorf("row %d: expected %+v. got=%+v", i+1, want, row.Input)
+		}
+		if !time.Time(row.Input.Expression.Start).Equal(time.Time(want.Expression.Start)) || !time.Time(row.Input.Expression.End).Equal(time.Time(want.Expression.End)) {
+			t.Errorf("row %d: expected %v to %v. got=%v to %v", i+1, want.Expression.Start, want.Expression.End, row.Input.Expression.Start, row.Input.Expression.End)
+		}
+		if len(row.Input.Payload) != len(want.Payload) {
+			t.Errorf("row %d: expected payload %v. got=%v", i+1, want.Payload, row.Input.Payload)
+		}
+	}
+}
+
+func TestExpression(t *testing.T) {
+	// a Wednesday
+	start := time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC)
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2030, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		rule      string
+		wantType  string
+		wantStart time.Time
+		wantErr   bool
+	}{
+		{rule: "FREQ=DAILY;INTERVAL=1", wantType: types.DAILY, wantStart: start},
+		{rule: "FREQ=WEEKLY", wantType: types.WEEKLY, wantStart: start},
+		{rule: "FREQ=WEEKLY;BYDAY=MO", wantType: types.WEEKLY, wantStart: day(1, 7)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1", wantType: types.MONTHLY, wantStart: day(2, 1)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,15;BYSETPOS=-1", wantType: types.MONTHLY, wantStart: day(1, 15)},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=31", wantType: types.MONTHLY, wantStart: day(1, 31)},
+		{rule: "FREQ=DAILY;INTERVAL=2", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=MO,WE", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYDAY=2TU", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=-1", wantErr: true},
+		{rule: "FREQ=YEARLY", wantErr: true},
+		{rule: "FREQ=DAILY;BYHOUR=9,17", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			exp, err := expression(start, tt.rule)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedRule) {
+					t.Fatalf("expected %v. got=%v", ErrUnsupportedRule, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exp.Type != tt.wantType || !time.Time(exp.Start).Equal(tt.wantStart) {
+				t.Errorf("expected %s from %v. got=%s from %v", tt.wantType, tt.wantStart, exp.Type, time.Time(exp.Start))
+			}
+		})
+	}
+}
+
+func TestWriterLeavesPayloadsOut(t *testing.T) {
+	sch := types.Schedule{ID: "1", Name: "digest", Action: types.Action{Id: "a1"}, Expression: types.Expression{Type: types.DAILY, Start: types.ScheduleDate(time.Date(2030, 2, 1, 8, 0, 0, 0, time.UTC))}, Payload: map[string]any{"token": "secret"}}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, time.UTC, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err := w.Write(&sch); err != nil {
+		t.Fatalf("error writing: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing: %v", err)
+	}
+
+	if strings.Contains(buf.String(), payloadProperty) || strings.Contains(buf.String(), "secret") {
+		t.Errorf("expected no payload in:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,347 @@
+package ical
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+var (
+	ErrUnsupportedRule = apperr.Validation("unsupported_rrule", "unsupported recurrence rule. supported: FREQ=DAILY, FREQ=WEEKLY with one BYDAY, FREQ=MONTHLY with one BYMONTHDAY or BYSETPOS over BYMONTHDAY", nil)
+	ErrInvalidEvent    = apperr.Validation("invalid_event", "invalid event", nil)
+)
+
+// maxLineLength bounds an unfolded content line
+const maxLineLength = 1 << 20
+
+// property is a content line, e.g. DTSTART;TZID=Europe/Paris:20300101T090000
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Read reads the VEVENTs of a calendar as import rows, in the order of the file.
+// floating times, without UTC suffix nor TZID, are read in loc.
+// an event that can't be mapped to a schedule gets its error, the file only fails when it isn't a calendar.
+func Read(r io.Reader, loc *time.Location) ([]types.ImportRow, error) {
+	lines, err := unfold(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rows []types.ImportRow
+		// components is the stack of the open components, e.g. VCALENDAR, VEVENT, VALARM
+		components []string
+		event      []property
+		calendar   bool
+	)
+
+	for i, line := range lines {
+		p, err := parseProperty(line)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch p.name {
+		case "BEGIN":
+			name := strings.ToUpper(p.value)
+			if len(components) == 0 && name != "VCALENDAR" {
+				return nil, fmt.Errorf("expected a VCALENDAR. got=%s", p.value)
+			}
+			if name == "VCALENDAR" {
+				calendar = true
+			}
+			if name == "VEVENT" {
+				event = nil
+			}
+			components = append(components, name)
+			continue
+		case "END":
+			name := strings.ToUpper(p.value)
+			if len(components) == 0 || components[len(components)-1] != name {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", i+1, p.value)
+			}
+			components = components[:len(components)-1]
+			if name == "VEVENT" {
+				rows = append(rows, eventRow(len(rows)+1, event, loc))
+			}
+			continue
+		}
+
+		// only the properties of the event itself, not of its alarms
+		if len(components) > 0 && components[len(components)-1] == "VEVENT" {
+			event = append(event, p)
+		}
+	}
+
+	if !calendar {
+		return nil, errors.New("expected a VCALENDAR")
+	}
+	if len(components) > 0 {
+		return nil, fmt.Errorf("missing END:%s", components[len(components)-1])
+	}
+	return rows, nil
+}
+
+// unfold returns the content lines of r, with the folded lines joined
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) (property, error) {
+	// the value starts at the first colon outside of a quoted parameter value
+	quoted, colon := false, -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("expected NAME:VALUE. got=%s", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+// eventRow maps an event to the create input of its schedule
+func eventRow(n int, event []property, loc *time.Location) types.ImportRow {
+	row := types.ImportRow{Row: n}
+	var start, rule *property
+
+	for i := range event {
+		p := &event[i]
+		switch p.name {
+		case "SUMMARY":
+			row.Input.Name = unescape(p.value)
+		case actionProperty:
+			row.Input.ActionID = unescape(p.value)
+		case payloadProperty:
+			if err := json.Unmarshal([]byte(unescape(p.value)), &row.Input.Payload); err != nil {
+				row.Err = fmt.Errorf("%w. %s must be a JSON object. error=%w", ErrInvalidEvent, payloadProperty, err)
+				return row
+			}
+		case "DTSTART":
+			start = p
+		case "RRULE":
+			if rule != nil {
+				row.Err = fmt.Errorf("%w. reason: more than one RRULE. create one event per rule", ErrUnsupportedRule)
+				return row
+			}
+			rule = p
+		case "RDATE", "EXDATE", "EXRULE":
+			// dropping them would fire the schedule on other dates than the calendar shows
+			row.Err = fmt.Errorf("%w. reason: %s is not supported", ErrUnsupportedRule, p.name)
+			return row
+		}
+	}
+
+	if start == nil {
+		row.Err = fmt.Errorf("%w. reason: DTSTART is required", ErrInvalidEvent)
+		return row
+	}
+
+	t, err := parseTime(*start, loc)
+	if err != nil {
+		row.Err = fmt.Errorf("%w. reason: invalid DTSTART. error=%w", ErrInvalidEvent, err)
+		return row
+	}
+
+	if rule == nil {
+		row.Input.Expression = types.Expression{Type: types.ONE, Start: types.ScheduleDate(t)}
+		return row
+	}
+
+	row.Input.Expression, row.Err = expression(t, rule.value)
+	return row
+}
+
+// parseTime reads a DATE-TIME or DATE value. a DATE is midnight of that day.
+func parseTime(p property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := p.params["TZID"]; ok {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+
+	switch {
+	case p.params["VALUE"] == "DATE" || len(p.value) == len(date):
+		return time.ParseInLocation(date, p.value, loc)
+	case strings.HasSuffix(p.value, "Z"):
+		return time.Parse(dateTimeUTC, p.value)
+	}
+	return time.ParseInLocation(dateTime, p.value, loc)
+}
+
+// expression maps a recurrence rule starting at start to a schedule expression.
+// the start moves to the first occurrence of the rule, as the scheduler fires on the weekday or day of the month of its start.
+func expression(start time.Time, value string) (types.Expression, error) {
+	rule := map[string]string{}
+
+	for _, part := range strings.Split(value, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return types.Expression{}, fmt.Errorf("%w. reason: invalid rule part %q", ErrUnsupportedRule, part)
+		}
+		rule[strings.ToUpper(k)] = strings.ToUpper(v)
+	}
+
+	for k, v := range rule {
+		switch k {
+		case "FREQ", "UNTIL", "BYDAY", "BYMONTHDAY", "BYSETPOS", "WKST":
+		case "INTERVAL":
+			if v != "1" {
+				return types.Expression{}, fmt.Errorf("%w. reason: INTERVAL=%s, only every day, week or month is supported", ErrUnsupportedRule, v)
+			}
+		case "COUNT":
+			return types.Expression{}, fmt.Errorf("%w. reason: COUNT is not supported, use UNTIL", ErrUnsupportedRule)
+		default:
+			return types.Expression{}, fmt.Errorf("%w. reason: %s is not supported", ErrUnsupportedRule, k)
+		}
+	}
+
+	exp := types.Expression{}
+
+	if until, ok := rule["UNTIL"]; ok {
+		end, err := parseTime(property{value: until, params: map[string]string{}}, start.Location())
+		if err != nil {
+			return types.Expression{}, fmt.Errorf("%w. reason: invalid UNTIL %q", ErrUnsupportedRule, until)
+		}
+		exp.End = types.ScheduleDate(end)
+	}
+
+	byDay, byMonthDay, bySetPos := rule["BYDAY"], rule["BYMONTHDAY"], rule["BYSETPOS"]
+
+	switch freq := rule["FREQ"]; freq {
+	case "DAILY":
+		if byDay != "" || byMonthDay != "" || bySetPos != "" {
+			return types.Expression{}, fmt.Errorf("%w. reason: FREQ=DAILY can't have BYDAY, BYMONTHDAY or BYSETPOS", ErrUnsupportedRule)
+		}
+		exp.Type = types.DAILY
+	case "WEEKLY":
+		if byMonthDay != "" || bySetPos != "" {
+			return types.Expression{}, fmt.Errorf("%w. reason: FREQ=WEEKLY can't have BYMONTHDAY or BYSETPOS", ErrUnsupportedRule)
+		}
+		if byDay != "" {
+			if strings.Contains(byDay, ",") {
+				return types.Expression{}, fmt.Errorf("%w. reason: BYDAY=%s has more than one day, create one schedule per day", ErrUnsupportedRule, byDay)
+			}
+			day := slices.Index(weekdays, byDay)
+			if day < 0 {
+				return types.Expression{}, fmt.Errorf("%w. reason: invalid BYDAY=%s", ErrUnsupportedRule, byDay)
+			}
+			start = start.AddDate(0, 0, (day-int(start.Weekday())+7)%7)
+		}
+		exp.Type = types.WEEKLY
+	case "MONTHLY":
+		if byDay != "" {
+			return types.Expression{}, fmt.Errorf("%w. reason: FREQ=MONTHLY by weekday (BYDAY=%s) is not supported", ErrUnsupportedRule, byDay)
+		}
+		if byMonthDay != "" {
+			day, err := monthDay(byMonthDay, bySetPos)
+			if err != nil {
+				return types.Expression{}, err
+			}
+			start = nextMonthDay(start, day)
+		} else if bySetPos != "" {
+			return types.Expression{}, fmt.Errorf("%w. reason: BYSETPOS needs BYMONTHDAY", ErrUnsupportedRule)
+		}
+		exp.Type = types.MONTHLY
+	case "":
+		return types.Expression{}, fmt.Errorf("%w. reason: FREQ is required", ErrUnsupportedRule)
+	default:
+		return types.Expression{}, fmt.Errorf("%w. reason: FREQ=%s is not supported", ErrUnsupportedRule, freq)
+	}
+
+	exp.Start = types.ScheduleDate(start)
+	return exp, nil
+}
+
+// monthDay returns the single day of the month a BYMONTHDAY list and its BYSETPOS select.
+// BYSETPOS is only supported over days every month has, so it selects the same day each month.
+func monthDay(byMonthDay, bySetPos string) (int, error) {
+	var days []int
+
+	for _, v := range strings.Split(byMonthDay, ",") {
+		day, err := strconv.Atoi(v)
+		if err != nil || day < 1 || day > 31 {
+			return 0, fmt.Errorf("%w. reason: BYMONTHDAY=%s, only days 1 to 31 are supported", ErrUnsupportedRule, byMonthDay)
+		}
+		days = append(days, day)
+	}
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	if bySetPos == "" {
+		if len(days) > 1 {
+			return 0, fmt.Errorf("%w. reason: BYMONTHDAY=%s has more than one day, create one schedule per day or select one with BYSETPOS", ErrUnsupportedRule, byMonthDay)
+		}
+		return days[0], nil
+	}
+
+	if days[len(days)-1] > 28 {
+		return 0, fmt.Errorf("%w. reason: BYSETPOS over days after the 28th selects a different day in shorter months", ErrUnsupportedRule)
+	}
+
+	pos, err := strconv.Atoi(bySetPos)
+	if err != nil || pos == 0 || pos > len(days) || pos < -len(days) {
+		return 0, fmt.Errorf("%w. reason: BYSETPOS=%s must select one of the %d days of BYMONTHDAY", ErrUnsupportedRule, bySetPos, len(days))
+	}
+	if pos < 0 {
+		pos += len(days) + 1
+	}
+	return days[pos-1], nil
+}
+
+// nextMonthDay returns the first time from t on that day of the month, at the time of day of t
+func nextMonthDay(t time.Time, day int) time.Time {
+	for i := 0; ; i++ {
+		month := time.Date(t.Year(), t.Month()+time.Month(i), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+		next := month.AddDate(0, 0, day-1)
+
+		if next.Month() == month.Month() && !next.Before(t) {
+			return next
+		}
+	}
+}
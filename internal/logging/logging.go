@@ -0,0 +1,77 @@
+// logging package configures the process logger and carries a request scoped logger through context.Context
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type ctxKey struct{}
+
+// NewHandler builds the handler for the configured format and level
+func NewHandler(w io.Writer, cfg config.Logging) (slog.Handler, error) {
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q. error=%w", cfg.Level, err)
+	}
+	ops := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(cfg.Format) {
+	case FormatText:
+		return slog.NewTextHandler(w, ops), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, ops), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", cfg.Format)
+}
+
+// Setup makes the configured logger the default one. every context without a logger falls back to it.
+func Setup(w io.Writer, cfg config.Logging) error {
+	h, err := NewHandler(w, cfg)
+
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// WithLogger stores the logger in the context
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// With stores a logger with the extra attributes in the context
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, logger(ctx).With(args...))
+}
+
+// FromContext returns the request logger, or the default logger, with the IDs of the current span if any
+func FromContext(ctx context.Context) *slog.Logger {
+	l := logger(ctx)
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return l
+}
+
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, config.Logging{Format: FormatJSON, Level: "info"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithLogger(context.Background(), slog.New(h))
+	ctx = With(ctx, "request_id", "req-1")
+
+	input := types.CreateScheduleInput{Name: "reminder", Payload: map[string]any{"email": "jane@example.com"}}
+	FromContext(ctx).Info("creating schedule", "input", input)
+	FromContext(ctx).Debug("below the level")
+
+	out := buf.String()
+
+	if !strings.Contains(out, `"request_id":"req-1"`) {
+		t.Errorf("expected the request ID in the record. got=%s", out)
+	}
+	if strings.Contains(out, "jane@example.com") {
+		t.Errorf("the payload was logged verbatim. got=%s", out)
+	}
+	if !strings.Contains(out, `"payload_keys":1`) {
+		t.Errorf("expected the payload size in the record. got=%s", out)
+	}
+	if strings.Contains(out, "below the level") {
+		t.Errorf("expected debug records to be dropped. got=%s", out)
+	}
+}
+
+func TestNewHandlerInvalid(t *testing.T) {
+	if _, err := NewHandler(&bytes.Buffer{}, config.Logging{Format: "xml", Level: "info"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := NewHandler(&bytes.Buffer{}, config.Logging{Format: FormatText, Level: "loud"}); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,30 @@
+package mapper
+
+import (
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MapAPIKeyModelToType maps api key model -> types. the hash is never mapped.
+func MapAPIKeyModelToType(m *model.APIKey) *types.APIKey {
+	return &types.APIKey{
+		ID:         m.ID,
+		Name:       m.Name,
+		Prefix:     m.Prefix,
+		Actions:    m.Actions,
+		ReadOnly:   m.ReadOnly,
+		CreatedBy:  m.CreatedBy,
+		CreatedAt:  m.CreatedAt,
+		LastUsedAt: optionalTime(m.LastUsedAt),
+		RevokedAt:  optionalTime(m.RevokedAt),
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
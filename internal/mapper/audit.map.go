@@ -0,0 +1,38 @@
+package mapper
+
+import (
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MapAuditModelToType maps audit model -> types. snapshots are mapped without resolving their action.
+func MapAuditModelToType(m *model.AuditEntry) *types.AuditEntry {
+	entry := &types.AuditEntry{
+		ID:         m.ID,
+		ScheduleID: m.ScheduleID,
+		Action:     string(m.Action),
+		Actor:      m.Actor,
+		RequestID:  m.RequestID,
+		Timestamp:  m.Timestamp,
+	}
+
+	if m.Before != nil {
+		entry.Before = MapScheduleModelToType(m.Before, types.Action{Id: m.Before.ActionID})
+	}
+
+	if m.After != nil {
+		entry.After = MapScheduleModelToType(m.After, types.Action{Id: m.After.ActionID})
+	}
+
+	return entry
+}
+
+// MapAuditFilterTypeToModel maps audit filter types -> model
+func MapAuditFilterTypeToModel(f *types.AuditFilter) model.AuditFilter {
+	return model.AuditFilter{
+		ScheduleID: f.ScheduleID,
+		Actor:      f.Actor,
+		From:       f.From,
+		To:         f.To,
+	}
+}
@@ -0,0 +1,15 @@
+package mapper
+
+import (
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MapRoleBindingModelToType maps role binding model -> types
+func MapRoleBindingModelToType(m *model.RoleBinding) *types.RoleBinding {
+	return &types.RoleBinding{
+		Subject: m.Subject,
+		Role:    string(m.Role),
+		Actions: m.Actions,
+	}
+}
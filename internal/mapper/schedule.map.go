@@ -1,24 +1,24 @@
 package mapper
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/japb1998/action-scheduler/internal/model"
 	"github.com/japb1998/action-scheduler/internal/types"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MapScheduleModelToType maps schedule model -> types
 func MapScheduleModelToType(model *model.Schedule, action types.Action) *types.Schedule {
 	return &types.Schedule{
-		ID:          model.ID.Hex(),
+		ID:          model.ID,
+		TenantID:    model.TenantID,
 		Payload:     model.Payload,
 		CreatedBy:   model.CreatedBy,
 		Action:      action,
 		ClientToken: model.ClientToken,
 		Name:        model.Name,
 		Expression:  MapModelExpressionToType(model.Expression),
+		Version:     model.Version,
 	}
 }
 
@@ -41,37 +41,27 @@ func MapTypeExpressionToModel(te types.Expression) model.Expression {
 }
 
 // MapScheduleTypesToModelList maps schedule types list -> model list
-func MapScheduleTypesToModelList(typesList []types.Schedule) ([]model.Schedule, error) {
+func MapScheduleTypesToModelList(typesList []types.Schedule) []model.Schedule {
 	modelList := make([]model.Schedule, 0, len(typesList))
 
 	for _, types := range typesList {
-		model, err := MapScheduleTypesToModel(&types)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to map schedule types to model. error=%w", err)
-		}
-		modelList = append(modelList, *model)
+		modelList = append(modelList, *MapScheduleTypesToModel(&types))
 	}
 
-	return modelList, nil
+	return modelList
 }
 
 // MapScheduleTypesToModel maps schedule types -> model
-func MapScheduleTypesToModel(types *types.Schedule) (*model.Schedule, error) {
-
-	id, err := primitive.ObjectIDFromHex(types.ID)
-
-	if err != nil {
-		return nil, fmt.Errorf("invalid id on schedule ID field. error=%w", err)
-	}
-
+func MapScheduleTypesToModel(types *types.Schedule) *model.Schedule {
 	return &model.Schedule{
-		ID:          id,
+		ID:          types.ID,
+		TenantID:    types.TenantID,
 		Payload:     types.Payload,
 		CreatedBy:   types.CreatedBy,
 		ActionID:    types.Action.Id,
 		ClientToken: types.ClientToken,
 		Name:        types.Name,
 		Expression:  MapTypeExpressionToModel(types.Expression),
-	}, nil
+		Version:     types.Version,
+	}
 }
@@ -0,0 +1,92 @@
+package mapper
+
+import (
+	"fmt"
+	"time"
+
+	schedulev1 "github.com/japb1998/action-scheduler/api/schedule/v1"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var expressionTypesToProto = map[string]schedulev1.ExpressionType{
+	types.MONTHLY: schedulev1.ExpressionType_EXPRESSION_TYPE_MONTHLY,
+	types.WEEKLY:  schedulev1.ExpressionType_EXPRESSION_TYPE_WEEKLY,
+	types.DAILY:   schedulev1.ExpressionType_EXPRESSION_TYPE_DAILY,
+	types.ONE:     schedulev1.ExpressionType_EXPRESSION_TYPE_ONE_TIME,
+}
+
+// MapScheduleTypeToProto maps schedule types -> proto
+func MapScheduleTypeToProto(s *types.Schedule) (*schedulev1.Schedule, error) {
+	payload, err := structpb.NewStruct(s.Payload)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload for schedule with ID='%s'. error=%w", s.ID, err)
+	}
+
+	return &schedulev1.Schedule{
+		Id:        s.ID,
+		TenantId:  s.TenantID,
+		CreatedBy: s.CreatedBy,
+		Name:      s.Name,
+		Payload:   payload,
+		Action: &schedulev1.Action{
+			Id:   s.Action.Id,
+			Name: s.Action.Name,
+			Arn:  s.Action.Arn,
+			Role: s.Action.Role,
+		},
+		Expression: &schedulev1.Expression{
+			Type:      expressionTypesToProto[s.Expression.Type],
+			StartDate: optionalTimestamp(time.Time(s.Expression.Start)),
+			EndDate:   optionalTimestamp(time.Time(s.Expression.End)),
+		},
+		Version: s.Version,
+	}, nil
+}
+
+// MapCreateScheduleProtoToType maps create schedule request proto -> types. an unspecified expression type maps to an empty type.
+func MapCreateScheduleProtoToType(req *schedulev1.CreateScheduleRequest) types.CreateScheduleInput {
+	return types.CreateScheduleInput{
+		ActionID:   req.GetAction(),
+		Name:       req.GetName(),
+		Expression: mapExpressionProtoToType(req.GetExpression()),
+		Payload:    req.GetPayload().AsMap(),
+	}
+}
+
+// MapUpdateScheduleProtoToType maps update schedule request proto -> types. the id and version are not part of the input.
+func MapUpdateScheduleProtoToType(req *schedulev1.UpdateScheduleRequest) types.UpdateScheduleInput {
+	return types.UpdateScheduleInput{
+		Expression: mapExpressionProtoToType(req.GetExpression()),
+		Payload:    req.GetPayload().AsMap(),
+	}
+}
+
+func mapExpressionProtoToType(e *schedulev1.Expression) types.Expression {
+	var expression types.Expression
+
+	if e == nil {
+		return expression
+	}
+	for t, pt := range expressionTypesToProto {
+		if pt == e.GetType() {
+			expression.Type = t
+		}
+	}
+	if e.GetStartDate() != nil {
+		expression.Start = types.ScheduleDate(e.GetStartDate().AsTime())
+	}
+	if e.GetEndDate() != nil {
+		expression.End = types.ScheduleDate(e.GetEndDate().AsTime())
+	}
+	return expression
+}
+
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
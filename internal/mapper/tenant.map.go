@@ -0,0 +1,17 @@
+package mapper
+
+import (
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MapTenantModelToType maps tenant model -> types
+func MapTenantModelToType(m *model.Tenant, group string) *types.Tenant {
+	return &types.Tenant{
+		ID:             m.ID,
+		Name:           m.Name,
+		TimeZone:       m.TimeZone,
+		AllowedActions: m.AllowedActions,
+		ScheduleGroup:  group,
+	}
+}
@@ -0,0 +1,39 @@
+package mapper
+
+import (
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MapWebhookModelToType maps webhook model -> types. the secret is never mapped.
+func MapWebhookModelToType(m *model.Webhook) *types.Webhook {
+	return &types.Webhook{
+		ID:        m.ID,
+		URL:       m.URL,
+		Events:    m.Events,
+		CreatedBy: m.CreatedBy,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// MapDeliveryModelToType maps delivery model -> types
+func MapDeliveryModelToType(m *model.WebhookDelivery) *types.WebhookDelivery {
+	d := &types.WebhookDelivery{
+		ID:             m.ID,
+		WebhookID:      m.WebhookID,
+		EventID:        m.EventID,
+		Event:          m.Event,
+		Status:         m.Status,
+		Attempts:       m.Attempts,
+		LastStatusCode: m.LastStatusCode,
+		LastError:      m.LastError,
+		CreatedAt:      m.CreatedAt,
+		DeliveredAt:    optionalTime(m.DeliveredAt),
+		ReplayOf:       m.ReplayOf,
+	}
+
+	if m.Status == types.DeliveryPending {
+		d.NextAttemptAt = optionalTime(m.NextAttemptAt)
+	}
+	return d
+}
@@ -0,0 +1,141 @@
+// metrics package holds the prometheus collectors exposed on /metrics
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "action_scheduler"
+
+// Registry holds every collector of the app. the default registry is not used so tests and multiple apps don't collide.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "operation_duration_seconds",
+		Help:      "Mongo operation latency by collection and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"collection", "operation"})
+
+	MongoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "operation_errors_total",
+		Help:      "Failed mongo operations by collection and operation.",
+	}, []string{"collection", "operation"})
+
+	SchedulerCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "eventbridge",
+		Name:      "calls_total",
+		Help:      "EventBridge scheduler API calls by operation and outcome (ok, error, throttled).",
+	}, []string{"operation", "outcome"})
+
+	SchedulerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "eventbridge",
+		Name:      "call_duration_seconds",
+		Help:      "EventBridge scheduler API call latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		MongoDuration,
+		MongoErrors,
+		SchedulerCalls,
+		SchedulerDuration,
+	)
+}
+
+// Handler serves the registry in the prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveMongo records the latency and outcome of a mongo operation
+func ObserveMongo(collection, operation string, start time.Time, err error) {
+	MongoDuration.WithLabelValues(collection, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		MongoErrors.WithLabelValues(collection, operation).Inc()
+	}
+}
+
+// SchedulerRecorder records EventBridge calls made by pkg/scheduler
+type SchedulerRecorder struct{}
+
+func (SchedulerRecorder) ObserveCall(operation string, outcome scheduler.Outcome, duration time.Duration) {
+	SchedulerCalls.WithLabelValues(operation, string(outcome)).Inc()
+	SchedulerDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+var activeSchedulesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "active_schedules"),
+	"Schedules stored by expression type and action.",
+	[]string{"type", "action"}, nil,
+)
+
+// ActiveSchedulesCollector counts the stored schedules on every scrape
+type ActiveSchedulesCollector struct {
+	count   func(ctx context.Context) ([]model.ScheduleCount, error)
+	timeout time.Duration
+}
+
+func NewActiveSchedulesCollector(count func(ctx context.Context) ([]model.ScheduleCount, error), timeout time.Duration) *ActiveSchedulesCollector {
+	return &ActiveSchedulesCollector{
+		count:   count,
+		timeout: timeout,
+	}
+}
+
+func (c *ActiveSchedulesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSchedulesDesc
+}
+
+func (c *ActiveSchedulesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+
+	if err != nil {
+		slog.Error("error counting active schedules", "package", "metrics", "error", err.Error())
+		ch <- prometheus.NewInvalidMetric(activeSchedulesDesc, err)
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(activeSchedulesDesc, prometheus.GaugeValue, float64(count.Count), string(count.Type), count.ActionID)
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// CalendarKeyParam is the query parameter of the API key of calendar subscriptions, calendar apps can't set headers
+const CalendarKeyParam = "key"
+
+var (
+	errMissingCredentials = apperr.Unauthorized("missing_credentials", "missing bearer token or api key")
+	errMissingTenant      = apperr.Forbidden("missing_tenant", "token is not bound to a tenant")
+	errCalendarKey        = apperr.Forbidden("calendar_key_not_read_only", "only read-only api keys can be passed as ?key=")
+)
+
+// calendarKeyCtx marks the requests whose API key came from the ?key= of CalendarKey
+type calendarKeyCtx struct{}
+
+// APIKeyAuthenticator resolves the principal of a service caller's API key
+type APIKeyAuthenticator interface {
+	Authenticate(c context.Context, key string) (auth.Principal, error)
+}
+
+// Authenticate verifies the X-API-Key header or the bearer token and stores the principal and its tenant in the request context.
+func Authenticate(v *auth.Verifier, keys APIKeyAuthenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			p   auth.Principal
+			err error
+		)
+
+		if key := ctx.GetHeader(APIKeyHeader); key != "" {
+			p, err = keys.Authenticate(ctx.Request.Context(), key)
+		} else {
+			token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+
+			if !ok || token == "" {
+				ctx.Error(errMissingCredentials)
+				ctx.Abort()
+				return
+			}
+
+			p, err = v.Verify(ctx.Request.Context(), token)
+			if err != nil {
+				err = auth.ErrInvalidToken
+			}
+		}
+
+		if err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+
+		// keys in URLs end up in logs and on calendar servers, they must not be able to change anything
+		if ctx.Request.Context().Value(calendarKeyCtx{}) != nil && !p.ReadOnly {
+			ctx.Error(errCalendarKey)
+			ctx.Abort()
+			return
+		}
+
+		if p.TenantID == "" {
+			ctx.Error(errMissingTenant)
+			ctx.Abort()
+			return
+		}
+
+		c := auth.WithPrincipal(ctx.Request.Context(), p)
+		c = requestctx.WithActor(c, p.Subject)
+		c = requestctx.WithTenant(c, p.TenantID)
+		c = logging.With(c, slog.String("actor", p.Subject), slog.String("tenant_id", p.TenantID))
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+	}
+}
+
+// CalendarKey moves the key query parameter of .ics requests to the X-API-Key header, so a subscription URL carries its credentials.
+// it runs before the access log and tracing, which never see the key. Authenticate only accepts read-only keys from it.
+func CalendarKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !strings.HasSuffix(ctx.Request.URL.Path, ".ics") {
+			ctx.Next()
+			return
+		}
+
+		query := ctx.Request.URL.Query()
+		if key := query.Get(CalendarKeyParam); key != "" {
+			if ctx.GetHeader(APIKeyHeader) == "" {
+				ctx.Request.Header.Set(APIKeyHeader, key)
+				ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), calendarKeyCtx{}, true))
+			}
+			query.Del(CalendarKeyParam)
+			ctx.Request.URL.RawQuery = query.Encode()
+			ctx.Request.RequestURI = ctx.Request.URL.RequestURI()
+		}
+		ctx.Next()
+	}
+}
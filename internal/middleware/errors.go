@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+// Errors writes the last error added with ctx.Error as the error envelope, with the status of its kind.
+// handlers and middleware add the error and return without writing a body.
+func Errors() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		err := ctx.Errors.Last().Err
+		res := apperr.Response(err)
+		status := apperr.HTTPStatus(apperr.From(err).Kind)
+
+		logger := logging.FromContext(ctx.Request.Context()).With("code", res.Code, "status", status, "error", err.Error())
+		if status >= 500 {
+			logger.Error("request failed")
+		} else {
+			logger.Info("request rejected")
+		}
+
+		res.RequestID = requestctx.RequestID(ctx.Request.Context())
+		ctx.AbortWithStatusJSON(status, res)
+	}
+}
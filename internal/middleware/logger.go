@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/logging"
+)
+
+// AccessLog logs every request once it is served, through the request logger
+func AccessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		// the query string is left out, it may carry filters with user data.
+		logging.FromContext(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), level, "request served",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		)
+	}
+}
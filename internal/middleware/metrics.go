@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/metrics"
+)
+
+// Metrics records the count and latency of every request by route template and status
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		// the route template keeps the label cardinality bounded, unmatched paths are grouped together.
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+)
+
+type Authorizer interface {
+	Authorize(c context.Context, op rbac.Operation, actionID string) error
+}
+
+// RequirePermission rejects the request with 403 and the reason when the caller cannot perform op.
+func RequirePermission(authz Authorizer, op rbac.Operation) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := authz.Authorize(ctx.Request.Context(), op, ""); err != nil {
+			ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
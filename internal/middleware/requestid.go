@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds the client request IDs, they end up in every log line and span of the request
+const maxRequestIDLen = 128
+
+// ValidRequestID reports whether a client request ID can be propagated: 1 to 128 characters of [A-Za-z0-9._-]
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID propagates the X-Request-ID header or generates a new one when it is missing or not a ValidRequestID,
+// and stores it and a logger carrying it in the request context.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !ValidRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		trace.SpanFromContext(ctx.Request.Context()).SetAttributes(attribute.String("request.id", id))
+		c := requestctx.WithRequestID(ctx.Request.Context(), id)
+		ctx.Request = ctx.Request.WithContext(logging.With(c, slog.String("request_id", id)))
+		ctx.Header(RequestIDHeader, id)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		name string
+		id   string
+		kept bool
+	}{
+		{name: "missing"},
+		{name: "valid", id: "req-1.a_B", kept: true},
+		{name: "max length", id: strings.Repeat("a", 128), kept: true},
+		{name: "too long", id: strings.Repeat("a", 129)},
+		{name: "invalid characters", id: "req 1\r\nX-Injected: 1"},
+		{name: "non ascii", id: "réq"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.id)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if tt.kept && got != tt.id {
+				t.Errorf("expected the request id %q kept. got=%q", tt.id, got)
+			}
+			if !tt.kept && (got == tt.id || !ValidRequestID(got)) {
+				t.Errorf("expected a generated request id. got=%q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Shutdown cancels the request context once the shutdown context is done.
+// http.Server.Shutdown waits for the long-lived requests, e.g. the event streams, until its own timeout otherwise.
+func Shutdown(shutdown context.Context) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if shutdown == nil {
+			ctx.Next()
+			return
+		}
+
+		c, cancel := context.WithCancel(ctx.Request.Context())
+		defer cancel()
+		stop := context.AfterFunc(shutdown, cancel)
+		defer stop()
+
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// APIKey is a credential for service-to-service callers. only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID       string   `json:"id" bson:"_id,omitempty"`
+	TenantID string   `json:"tenant_id" bson:"tenant_id"`
+	Name     string   `json:"name" bson:"name"`
+	Prefix   string   `json:"prefix" bson:"prefix"`
+	Hash     string   `json:"-" bson:"hash"`
+	Actions  []string `json:"actions" bson:"actions"`
+	// ReadOnly keys only read schedules, they are the ones calendar subscriptions carry in their URL
+	ReadOnly  bool      `json:"read_only" bson:"read_only"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	// LastUsedAt and RevokedAt are zero until the key is used or revoked
+	LastUsedAt time.Time `json:"last_used_at" bson:"last_used_at"`
+	RevokedAt  time.Time `json:"revoked_at" bson:"revoked_at"`
+}
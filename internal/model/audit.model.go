@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	AuditRun    AuditAction = "run"
+	// AuditDeleteTenant is the teardown of the tenant, its entry has no schedule
+	AuditDeleteTenant AuditAction = "delete_tenant"
+)
+
+// AuditEntry is an append-only record of a mutation on a schedule, or on the tenant when ScheduleID is empty.
+type AuditEntry struct {
+	ID         string      `json:"id" bson:"_id,omitempty"`
+	TenantID   string      `json:"tenant_id" bson:"tenant_id"`
+	ScheduleID string      `json:"schedule_id" bson:"schedule_id"`
+	Action     AuditAction `json:"action" bson:"action"`
+	Actor      string      `json:"actor" bson:"actor"`
+	RequestID  string      `json:"request_id" bson:"request_id"`
+	Timestamp  time.Time   `json:"timestamp" bson:"timestamp"`
+	Before     *Schedule   `json:"before,omitempty" bson:"before,omitempty"`
+	After      *Schedule   `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+type AuditFilter struct {
+	ScheduleID string
+	Actor      string
+	From       time.Time
+	To         time.Time
+}
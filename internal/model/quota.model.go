@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type QuotaScope string
+
+const (
+	QuotaScopeUser   QuotaScope = "user"
+	QuotaScopeTenant QuotaScope = "tenant"
+	QuotaScopeAction QuotaScope = "action"
+	// QuotaScopeRate counts creations of a user within a one minute window
+	QuotaScopeRate QuotaScope = "rate"
+)
+
+// QuotaCounter is the usage of a single quota. counters of rate windows expire.
+type QuotaCounter struct {
+	ID        string     `json:"id" bson:"_id"`
+	TenantID  string     `json:"tenant_id" bson:"tenant_id"`
+	Scope     QuotaScope `json:"scope" bson:"scope"`
+	Key       string     `json:"key" bson:"key"`
+	Count     int64      `json:"count" bson:"count"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
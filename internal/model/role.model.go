@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"
+	RoleScheduler Role = "scheduler"
+	RoleAdmin     Role = "admin"
+)
+
+// AllActions grants permission on every action when present in RoleBinding.Actions
+const AllActions = "*"
+
+// RoleBinding assigns a role to a subject within a tenant. Actions are the action IDs the subject may schedule.
+type RoleBinding struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	TenantID  string    `json:"tenant_id" bson:"tenant_id"`
+	Subject   string    `json:"subject" bson:"subject"`
+	Role      Role      `json:"role" bson:"role"`
+	Actions   []string  `json:"actions" bson:"actions"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// ScheduleFilter narrows schedule queries. zero values are ignored.
+type ScheduleFilter struct {
+	CreatedBy string
+	ActionID  string
+	Type      ExpressionType
+	// Name is matched exactly
+	Name string
+}
+
+// ScheduleCount is the number of schedules of an expression type and action
+type ScheduleCount struct {
+	Type     ExpressionType `bson:"type"`
+	ActionID string         `bson:"action"`
+	Count    int64          `bson:"count"`
+}
@@ -1,36 +1,70 @@
 package model
 
 import (
+	"log/slog"
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ExpressionType string
 
 const (
 	MonthlyExpression ExpressionType = "monthly"
+	WeeklyExpression  ExpressionType = "weekly"
 	DailyExpression   ExpressionType = "daily"
 	OneTimeExpression ExpressionType = "one_time"
 )
 
+// Schedule is a stored schedule. the ID is opaque to everything but the store that generated it,
+// mongo decodes its ObjectIDs as hex.
 type Schedule struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	ID          string `json:"id" bson:"_id"`
+	TenantID    string `json:"tenant_id" bson:"tenant_id"`
 	Expression  `json:"expression" bson:"expression"`
 	Payload     map[string]any `json:"payload" bson:"payload"`
 	CreatedBy   string         `json:"created_by" bson:"created_by"`
 	ActionID    string         `json:"action_id" bson:"action"` // action ID
 	ClientToken string         `json:"-" bson:"client_token"`
 	Name        string         `json:"name" bson:"name"`
+	// Version starts at 1 and is incremented by every update. schedules stored before versioning are at 0.
+	Version int64 `json:"version" bson:"version"`
+}
+
+// LogValue keeps the payload out of the logs. only its size is logged.
+func (s Schedule) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.ID),
+		slog.String("tenant_id", s.TenantID),
+		slog.String("created_by", s.CreatedBy),
+		slog.String("name", s.Name),
+		slog.String("action", s.ActionID),
+		slog.String("expression", string(s.Expression.Type)),
+		slog.Int("payload_keys", len(s.Payload)),
+		slog.Int64("version", s.Version),
+	)
 }
 
 type CreateScheduleInput struct {
+	TenantID    string `json:"tenant_id" bson:"tenant_id"`
 	Expression  `json:"expression" bson:"expression"`
 	Payload     map[string]any `json:"payload" bson:"payload"`
 	CreatedBy   string         `json:"created_by" bson:"created_by"`
 	ActionID    string         `json:"action_id" bson:"action"` // action ID
 	ClientToken string         `json:"-" bson:"client_token"`
 	Name        string         `json:"name" bson:"name"`
+	// Version is set by the store
+	Version int64 `json:"version" bson:"version"`
+}
+
+// LogValue logs the input without its payload
+func (s CreateScheduleInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("tenant_id", s.TenantID),
+		slog.String("created_by", s.CreatedBy),
+		slog.String("name", s.Name),
+		slog.String("action", s.ActionID),
+		slog.String("expression", string(s.Expression.Type)),
+		slog.Int("payload_keys", len(s.Payload)),
+	)
 }
 
 type Expression struct {
@@ -38,3 +72,19 @@ type Expression struct {
 	End   time.Time      `json:"end" bson:"end"`
 	Type  ExpressionType `json:"type" bson:"type"`
 }
+
+type ChangeType string
+
+const (
+	ChangeInsert ChangeType = "insert"
+	ChangeUpdate ChangeType = "update"
+	ChangeDelete ChangeType = "delete"
+)
+
+// ScheduleChange is a write to a schedule. the schedule is the one written, as it was before a delete.
+type ScheduleChange struct {
+	// Token resumes the changes after this one
+	Token    string
+	Type     ChangeType
+	Schedule Schedule
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Tenant is the configuration of a customer organisation. its ID is the tenant_id claim of the caller's token.
+type Tenant struct {
+	ID             string    `json:"id" bson:"_id"`
+	Name           string    `json:"name" bson:"name"`
+	TimeZone       string    `json:"time_zone" bson:"time_zone"`
+	AllowedActions []string  `json:"allowed_actions" bson:"allowed_actions"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Webhook is a subscription of a URL to schedule events. the secret is kept in plain text as it signs the deliveries.
+type Webhook struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	TenantID  string    `json:"tenant_id" bson:"tenant_id"`
+	URL       string    `json:"url" bson:"url"`
+	Events    []string  `json:"events" bson:"events"`
+	Secret    string    `json:"-" bson:"secret"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// WebhookDelivery is a queued event for a webhook and the log of its attempts.
+// the body is stored as sent so retries and replays carry the same event.
+type WebhookDelivery struct {
+	ID        string `json:"id" bson:"_id,omitempty"`
+	TenantID  string `json:"tenant_id" bson:"tenant_id"`
+	WebhookID string `json:"webhook_id" bson:"webhook_id"`
+	EventID   string `json:"event_id" bson:"event_id"`
+	Event     string `json:"event" bson:"event"`
+	Body      string `json:"body" bson:"body"`
+	Status    string `json:"status" bson:"status"`
+	Attempts  int    `json:"attempts" bson:"attempts"`
+	// NextAttemptAt is when a pending delivery is due. LockedUntil is the lease of the worker delivering it.
+	NextAttemptAt  time.Time `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil    time.Time `json:"locked_until" bson:"locked_until"`
+	LastStatusCode int       `json:"last_status_code" bson:"last_status_code"`
+	LastError      string    `json:"last_error" bson:"last_error"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	DeliveredAt    time.Time `json:"delivered_at" bson:"delivered_at"`
+	ReplayOf       string    `json:"replay_of" bson:"replay_of"`
+}
+
+// DeliveryAttempt is the outcome of an attempt. Status stays pending while the delivery is retried at NextAttemptAt.
+type DeliveryAttempt struct {
+	Status        string
+	StatusCode    int
+	Error         string
+	At            time.Time
+	NextAttemptAt time.Time
+}
+
+type DeliveryFilter struct {
+	WebhookID string
+	Status    string
+}
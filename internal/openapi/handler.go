@@ -0,0 +1,39 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// spec is built once, the document never changes while the process runs
+var spec = sync.OnceValues(func() ([]byte, error) {
+	return json.MarshalIndent(Build(), "", "  ")
+})
+
+// Handler serves the document as JSON
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := spec()
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	})
+}
+
+// Docs serves the page rendering the document served at /openapi.json
+func Docs() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(docsPage)
+	})
+}
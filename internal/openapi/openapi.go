@@ -0,0 +1,236 @@
+// openapi package builds the OpenAPI 3 document of the HTTP API. the schemas are generated from the structs in internal/types.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps the lower case HTTP method to its operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security overrides the document security. an empty list makes the operation public.
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+func newDocument() *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Action Scheduler API",
+			Description: "Schedules actions (lambda functions) with AWS EventBridge Scheduler.",
+			Version:     "1.0.0",
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				// only .ics routes read it, for calendar subscriptions
+				"apiKeyQuery": {Type: "apiKey", In: "query", Name: "key"},
+			},
+		},
+		// every operation is authenticated unless it says otherwise
+		Security: []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}},
+	}
+}
+
+// Has reports whether the document describes the operation. path uses the gin syntax, /schedule/:id.
+func (d *Document) Has(method, path string) bool {
+	item, ok := d.Paths[ginPathToOpenAPI(path)]
+	if !ok {
+		return false
+	}
+	_, ok = (*item)[strings.ToLower(method)]
+	return ok
+}
+
+// add registers the operation. path uses the gin syntax and its parameters are documented as strings.
+func (d *Document) add(method, path string, op *Operation) {
+	oaPath := ginPathToOpenAPI(path)
+
+	for _, segment := range strings.Split(path, "/") {
+		if name, _, ok := pathParam(segment); ok {
+			op.Parameters = append([]Parameter{{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}}, op.Parameters...)
+		}
+	}
+
+	item, ok := d.Paths[oaPath]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[oaPath] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+func ginPathToOpenAPI(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, suffix, ok := pathParam(segment); ok {
+			segments[i] = "{" + name + "}" + suffix
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParam splits a parameter segment, e.g. :id.ics, into its name and the literal suffix after a dot
+func pathParam(segment string) (name, suffix string, ok bool) {
+	name, ok = strings.CutPrefix(segment, ":")
+	if !ok {
+		return "", "", false
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i], name[i:], true
+	}
+	return name, "", true
+}
+
+// jsonOf returns the schema of the type of v
+func (d *Document) jsonOf(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func body(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: schema}}}
+}
+
+func response(description string, schema *Schema) *Response {
+	if schema == nil {
+		return &Response{Description: description}
+	}
+	return &Response{Description: description, Content: map[string]*MediaType{"application/json": {Schema: schema}}}
+}
+
+// withETag adds the ETag of the returned schedule to the response
+func withETag(r *Response) *Response {
+	r.Headers = map[string]*Header{"ETag": {Description: "the version of the schedule, to send back as If-Match", Schema: &Schema{Type: "string"}}}
+	return r
+}
+
+// ifMatch is the If-Match header of the conditional operations. * matches any version.
+func ifMatch() Parameter {
+	return Parameter{Name: "If-Match", In: "header", Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// files are the media types of the import and export files
+func files(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json": {Schema: schema},
+		"application/yaml": {Schema: schema},
+		"text/csv":         {Schema: &Schema{Type: "string", Description: "header: id,name,action,type,start_date,end_date,payload,created_by"}},
+	}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func object(required []string, props map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: props, Required: required}
+}
+
+// public is the security of the operations that require no credentials
+func public() *[]map[string][]string {
+	return &[]map[string][]string{}
+}
+
+// calendar is the security of the .ics operations, which also take the API key in the query
+func calendar() *[]map[string][]string {
+	return &[]map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}, {"apiKeyQuery": {}}}
+}
+
+// errorResponses adds the error responses shared by every authenticated operation
+func errorResponses(responses map[string]*Response, statuses ...int) map[string]*Response {
+	for _, status := range statuses {
+		var r *Response
+		switch status {
+		case http.StatusBadRequest:
+			r = response("invalid request", ref("ValidationError"))
+		case http.StatusUnauthorized:
+			r = response("missing or invalid credentials", ref("ErrorResponse"))
+		case http.StatusForbidden:
+			r = response("the principal is not allowed to perform the operation", ref("ErrorResponse"))
+		case http.StatusNotFound:
+			r = response("not found", ref("ErrorResponse"))
+		case http.StatusConflict:
+			r = response("conflicts with the current state", ref("ErrorResponse"))
+		case http.StatusTooManyRequests:
+			r = response("quota exceeded", ref("QuotaExceededError"))
+		case http.StatusBadGateway:
+			r = response("a dependency (EventBridge) failed", ref("ErrorResponse"))
+		case http.StatusPreconditionFailed:
+			r = response("If-Match is not the ETag of the current version", ref("ErrorResponse"))
+		case http.StatusPreconditionRequired:
+			r = response("If-Match is missing", ref("ErrorResponse"))
+		default:
+			r = response(http.StatusText(status), ref("ErrorResponse"))
+		}
+		responses[statusCode(status)] = r
+	}
+	return responses
+}
@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScheduleSchemasFollowBindingTags(t *testing.T) {
+	d := Build()
+
+	input, ok := d.Components.Schemas["CreateScheduleInput"]
+	if !ok {
+		t.Fatal("expected a CreateScheduleInput schema")
+	}
+
+	for _, field := range []string{"action", "name", "expression"} {
+		if !slices.Contains(input.Required, field) {
+			t.Errorf("expected %s to be required. got=%v", field, input.Required)
+		}
+	}
+	if _, ok := input.Properties["created_by"]; ok {
+		t.Error("created_by is stamped by the server and must not be part of the input")
+	}
+	if min := input.Properties["name"].MinLength; min == nil || *min != 2 {
+		t.Errorf("expected name minLength 2. got=%v", min)
+	}
+
+	expression := d.Components.Schemas["Expression"]
+	if got := expression.Properties["type"].Enum; !slices.Equal(got, []string{"monthly", "weekly", "daily", "one_time"}) {
+		t.Errorf("expected the expression type enum from the oneof tag. got=%v", got)
+	}
+	if got := expression.Properties["start_date"].Format; got != "date-time" {
+		t.Errorf("expected start_date to be a date-time. got=%s", got)
+	}
+
+	if _, ok := d.Components.Schemas["PaginatedResultSchedule"]; !ok {
+		t.Error("expected the paginated schedules schema")
+	}
+}
+
+func TestHasUsesGinPaths(t *testing.T) {
+	d := Build()
+
+	if !d.Has("DELETE", "/schedule/:id") {
+		t.Error("expected DELETE /schedule/:id to be documented")
+	}
+	if d.Has("PATCH", "/schedule/:id") {
+		t.Error("PATCH /schedule/:id is not a route")
+	}
+
+	item, ok := d.Paths["/schedule/{id}.ics"]
+	if !ok {
+		t.Fatal("expected the parameter of /schedule/:id.ics to stop at the dot")
+	}
+	if params := (*item)["get"].Parameters; len(params) != 1 || params[0].Name != "id" {
+		t.Errorf("expected the id parameter. got=%+v", params)
+	}
+}
@@ -0,0 +1,187 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// types with a custom JSON encoding
+var dateTime = reflect.TypeOf(time.Time{})
+var scheduleDate = reflect.TypeOf(types.ScheduleDate{})
+
+// schemaOf returns the schema of t. named structs are added to the components and referenced.
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == dateTime || t == scheduleDate:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		// payloads are free form objects
+		allowed := true
+		return &Schema{Type: "object", AdditionalProperties: &allowed}
+	case reflect.Struct:
+		name := componentName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// the placeholder stops recursive types from looping
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// structSchema maps the json tags to properties and the binding tags to validation keywords
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// untagged embedded structs are flattened by encoding/json
+		if field.Anonymous && name == "" {
+			embedded := d.structSchema(field.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := d.schemaOf(field.Type)
+		if applyBinding(prop, field.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// applyBinding applies the validator rules gin enforces. it reports whether the field is required.
+// rules after "dive" apply to the items of the array.
+func applyBinding(s *Schema, binding string) (required bool) {
+	if binding == "" {
+		return false
+	}
+
+	rules, itemRules, dive := strings.Cut(binding, ",dive")
+	for _, rule := range strings.Split(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "required":
+			required = true
+		case "oneof":
+			s.Enum = strings.Fields(value)
+		case "min":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			switch s.Type {
+			case "string":
+				s.MinLength = &n
+			case "array":
+				s.MinItems = &n
+			case "integer", "number":
+				f := float64(n)
+				s.Minimum = &f
+			}
+		}
+	}
+
+	if dive && s.Items != nil && s.Items.Ref == "" {
+		if applyBinding(s.Items, strings.TrimPrefix(itemRules, ",")) && s.Items.Type == "string" && s.Items.MinLength == nil {
+			one := 1
+			s.Items.MinLength = &one
+		}
+	}
+	return required
+}
+
+// componentName strips the package path from generic type arguments, PaginatedResult[types.Schedule] is PaginatedResultSchedule
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+
+	args = strings.TrimSuffix(args, "]")
+	if i := strings.LastIndex(args, "."); i >= 0 {
+		args = args[i+1:]
+	}
+	return base + args
+}
+
+// queryParameters maps the form tags of t to query parameters
+func (d *Document) queryParameters(t reflect.Type) []Parameter {
+	params := make([]Parameter, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Tag.Get("form") == "" {
+			params = append(params, d.queryParameters(field.Type)...)
+			continue
+		}
+
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := d.schemaOf(field.Type)
+		required := applyBinding(schema, field.Tag.Get("binding"))
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
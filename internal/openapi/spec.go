@@ -0,0 +1,362 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// Build returns the document of every route registered by the router.
+// a route added to the router without an operation here fails the router tests.
+func Build() *Document {
+	d := newDocument()
+
+	// every error uses the ErrorResponse envelope, the details depend on the status
+	d.jsonOf(types.ErrorResponse{})
+	d.Components.Schemas["ValidationError"] = errorEnvelope(d, &Schema{Type: "array", Items: d.jsonOf(types.FieldError{})})
+	d.Components.Schemas["QuotaExceededError"] = errorEnvelope(d, d.jsonOf(types.QuotaUsage{}))
+
+	// probes and metrics
+	d.add(http.MethodGet, "/healthz", &Operation{
+		OperationID: "healthz",
+		Summary:     "Liveness probe. it never checks dependencies",
+		Tags:        []string{"health"},
+		Responses: map[string]*Response{
+			"200": response("the process is up", object([]string{"status"}, map[string]*Schema{"status": {Type: "string", Enum: []string{types.HealthStatusOK}}})),
+		},
+		Security: public(),
+	})
+	d.add(http.MethodGet, "/readyz", &Operation{
+		OperationID: "readyz",
+		Summary:     "Readiness probe. checks mongo, the AWS credentials and the scheduler API",
+		Tags:        []string{"health"},
+		Responses: map[string]*Response{
+			"200": response("every dependency is reachable", d.jsonOf(types.Readiness{})),
+			"503": response("at least one dependency is failing", d.jsonOf(types.Readiness{})),
+		},
+		Security: public(),
+	})
+	d.add(http.MethodGet, "/metrics", &Operation{
+		OperationID: "metrics",
+		Summary:     "Prometheus metrics in the text exposition format",
+		Tags:        []string{"health"},
+		Responses: map[string]*Response{
+			"200": {Description: "metrics", Content: map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}},
+		},
+		Security: public(),
+	})
+
+	// schedules
+	pagination := d.queryParameters(reflect.TypeOf(types.PaginationOps{}))
+
+	d.add(http.MethodGet, "/schedule", &Operation{
+		OperationID: "listSchedules",
+		Summary:     "List the schedules visible to the caller. non admins only see their own schedules",
+		Tags:        []string{"schedule"},
+		Parameters:  pagination,
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a page of schedules", d.jsonOf(types.PaginatedResult[types.Schedule]{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/schedule", &Operation{
+		OperationID: "createSchedule",
+		Summary:     "Create a schedule. created_by is stamped from the authenticated principal",
+		Tags:        []string{"schedule"},
+		RequestBody: body(d.jsonOf(types.CreateScheduleInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"201": withETag(response("the created schedule", d.jsonOf(types.Schedule{}))),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway),
+	})
+	records := d.jsonOf([]types.ScheduleRecord{})
+	exports := files(records)
+	exports["text/calendar"] = &MediaType{Schema: &Schema{Type: "string", Description: "the payloads are in X-ACTION-SCHEDULER-PAYLOAD, see importCalendar"}}
+	d.add(http.MethodGet, "/schedule/export", &Operation{
+		OperationID: "exportSchedules",
+		Summary:     "Stream every schedule matching the filter as JSON, YAML, CSV or iCalendar. CSV payloads are JSON objects",
+		Tags:        []string{"schedule"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.ExportQuery{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": {Description: "the schedules, in the requested format", Content: exports},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/schedule/stream", &Operation{
+		OperationID: "streamSchedules",
+		Summary:     "Stream the inserts, updates and deletes of the visible schedules as server-sent events. each event's data is the JSON schema below and its id resumes the stream. a reset event means events were lost and the schedules must be reloaded",
+		Tags:        []string{"schedule"},
+		Parameters: []Parameter{
+			{Name: "Last-Event-ID", In: "header", Schema: &Schema{Type: "string"}},
+			{Name: "after", In: "query", Schema: &Schema{Type: "string"}},
+		},
+		Responses: errorResponses(map[string]*Response{
+			"200": {Description: "the event stream, until the client disconnects", Content: map[string]*MediaType{"text/event-stream": {Schema: d.jsonOf(types.ScheduleEvent{})}}},
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/schedule/import", &Operation{
+		OperationID: "importSchedules",
+		Summary:     "Create the schedules of an export file. every row is validated. dry_run only reports what would be created or skipped",
+		Tags:        []string{"schedule"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.ImportQuery{})),
+		RequestBody: &RequestBody{Required: true, Content: files(records)},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a result per row", d.jsonOf(types.ImportResult{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/schedule/import/ics", &Operation{
+		OperationID: "importCalendar",
+		Summary:     "Create a schedule per VEVENT of an iCalendar file. supported RRULEs: FREQ=DAILY, FREQ=WEEKLY with one BYDAY, FREQ=MONTHLY with one BYMONTHDAY or BYSETPOS over BYMONTHDAY. other rules fail their row",
+		Tags:        []string{"schedule", "calendar"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.CalendarImportQuery{})),
+		RequestBody: &RequestBody{Required: true, Content: map[string]*MediaType{"text/calendar": {Schema: &Schema{Type: "string"}}}},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a result per event", d.jsonOf(types.ImportResult{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/schedule/:id.ics", &Operation{
+		OperationID: "getScheduleCalendar",
+		Summary:     "Get a schedule as an iCalendar event with its RRULE, in the time zone of the tenant. a read-only API key can be passed as ?key=. the payload is left out",
+		Tags:        []string{"schedule", "calendar"},
+		Responses: errorResponses(map[string]*Response{
+			"200": {Description: "a calendar with the event of the schedule", Content: map[string]*MediaType{"text/calendar": {Schema: &Schema{Type: "string"}}}},
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+		Security: calendar(),
+	})
+	d.add(http.MethodGet, "/calendar.ics", &Operation{
+		OperationID: "getCalendarFeed",
+		Summary:     "iCalendar feed of the schedules matching the filter, for calendar apps to subscribe to. a read-only API key can be passed as ?key=. the payloads are left out, see exportSchedules",
+		Tags:        []string{"calendar"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.ScheduleFilterInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": {Description: "a calendar with an event per schedule", Content: map[string]*MediaType{"text/calendar": {Schema: &Schema{Type: "string"}}}},
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+		Security: calendar(),
+	})
+	d.add(http.MethodGet, "/schedule/:id", &Operation{
+		OperationID: "getSchedule",
+		Summary:     "Get a schedule by ID. the ETag is its version",
+		Tags:        []string{"schedule"},
+		Responses: errorResponses(map[string]*Response{
+			"200": withETag(response("the schedule", d.jsonOf(types.Schedule{}))),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPut, "/schedule/:id", &Operation{
+		OperationID: "updateSchedule",
+		Summary:     "Replace the expression and payload of a schedule. If-Match must be the ETag the schedule was read with, or *",
+		Tags:        []string{"schedule"},
+		Parameters:  []Parameter{ifMatch()},
+		RequestBody: body(d.jsonOf(types.UpdateScheduleInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": withETag(response("the updated schedule", d.jsonOf(types.Schedule{}))),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired, http.StatusInternalServerError, http.StatusBadGateway),
+	})
+	d.add(http.MethodDelete, "/schedule/:id", &Operation{
+		OperationID: "deleteSchedule",
+		Summary:     "Delete a schedule from the scheduler and the database. If-Match must be the ETag the schedule was read with, or *",
+		Tags:        []string{"schedule"},
+		Parameters:  []Parameter{ifMatch()},
+		Responses: errorResponses(map[string]*Response{
+			"204": response("deleted", nil),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired, http.StatusInternalServerError, http.StatusBadGateway),
+	})
+	d.add(http.MethodPost, "/schedule/:id/runs", &Operation{
+		OperationID: "reportScheduleRun",
+		Summary:     "Report that a schedule fired or failed. called by the action, it emits schedule.fired or schedule.failed to the webhooks",
+		Tags:        []string{"schedule"},
+		RequestBody: body(d.jsonOf(types.ScheduleRunInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"204": response("reported", nil),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/schedule:batch", &Operation{
+		OperationID: "batchCreateSchedules",
+		Summary:     "Create up to batch.max_items schedules. items fail independently and get their own result",
+		Tags:        []string{"schedule"},
+		RequestBody: body(d.jsonOf(types.BatchCreateInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a result per item, in request order", d.jsonOf(types.BatchResult{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/schedule:batchDelete", &Operation{
+		OperationID: "batchDeleteSchedules",
+		Summary:     "Delete schedules by ID, or up to batch.max_items schedules matching a filter. remaining counts the matches left over",
+		Tags:        []string{"schedule"},
+		RequestBody: body(d.jsonOf(types.BatchDeleteInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a result per item", d.jsonOf(types.BatchResult{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+
+	// audit and quota
+	d.add(http.MethodGet, "/audit", &Operation{
+		OperationID: "listAuditEntries",
+		Summary:     "List the audit log of the tenant, newest first",
+		Tags:        []string{"audit"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.AuditFilter{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a page of audit entries", d.jsonOf(types.PaginatedResult[types.AuditEntry]{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/quota", &Operation{
+		OperationID: "getQuota",
+		Summary:     "Usage of every quota that applies to the caller",
+		Tags:        []string{"quota"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("quota usage", object([]string{"usage"}, map[string]*Schema{
+				"usage": {Type: "array", Items: d.jsonOf(types.QuotaUsage{})},
+			})),
+		}, http.StatusUnauthorized, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/quota/reconcile", &Operation{
+		OperationID: "reconcileQuota",
+		Summary:     "Recount the active schedule counters of the tenant from its schedules, e.g. after enabling the quotas on existing schedules",
+		Tags:        []string{"quota"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the recounted counters", object([]string{"usage"}, map[string]*Schema{
+				"usage": {Type: "array", Items: d.jsonOf(types.QuotaUsage{})},
+			})),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+
+	// tenant
+	d.add(http.MethodGet, "/tenant", &Operation{
+		OperationID: "getTenant",
+		Summary:     "Get the configuration of the caller's tenant",
+		Tags:        []string{"tenant"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the tenant", d.jsonOf(types.Tenant{})),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPut, "/tenant", &Operation{
+		OperationID: "upsertTenant",
+		Summary:     "Configure the caller's tenant, creating its schedule group if needed",
+		Tags:        []string{"tenant"},
+		RequestBody: body(d.jsonOf(types.UpsertTenantInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the tenant", d.jsonOf(types.Tenant{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError, http.StatusBadGateway),
+	})
+	d.add(http.MethodDelete, "/tenant", &Operation{
+		OperationID: "deleteTenant",
+		Summary:     "Delete the caller's tenant, its schedule group and every schedule in it, its roles, webhooks and deliveries, and revoke its API keys",
+		Tags:        []string{"tenant"},
+		Responses: errorResponses(map[string]*Response{
+			"204": response("deleted", nil),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError, http.StatusBadGateway),
+	})
+
+	// roles
+	d.add(http.MethodGet, "/role", &Operation{
+		OperationID: "listRoles",
+		Summary:     "List the role bindings of the tenant",
+		Tags:        []string{"role"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the role bindings", &Schema{Type: "array", Items: d.jsonOf(types.RoleBinding{})}),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPut, "/role/:subject", &Operation{
+		OperationID: "upsertRole",
+		Summary:     "Assign a role and its allowed actions to a subject",
+		Tags:        []string{"role"},
+		RequestBody: body(d.jsonOf(types.UpsertRoleInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the role binding", d.jsonOf(types.RoleBinding{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodDelete, "/role/:subject", &Operation{
+		OperationID: "deleteRole",
+		Summary:     "Remove the role of a subject",
+		Tags:        []string{"role"},
+		Responses: errorResponses(map[string]*Response{
+			"204": response("deleted", nil),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+
+	// api keys
+	d.add(http.MethodPost, "/apikey", &Operation{
+		OperationID: "createAPIKey",
+		Summary:     "Mint an API key. the plain key is only returned once",
+		Tags:        []string{"apikey"},
+		RequestBody: body(d.jsonOf(types.CreateAPIKeyInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"201": response("the minted key", d.jsonOf(types.MintedAPIKey{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/apikey", &Operation{
+		OperationID: "listAPIKeys",
+		Summary:     "List the API keys of the tenant",
+		Tags:        []string{"apikey"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the API keys", &Schema{Type: "array", Items: d.jsonOf(types.APIKey{})}),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodDelete, "/apikey/:id", &Operation{
+		OperationID: "revokeAPIKey",
+		Summary:     "Revoke an API key",
+		Tags:        []string{"apikey"},
+		Responses: errorResponses(map[string]*Response{
+			"204": response("revoked", nil),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+
+	// webhooks
+	d.add(http.MethodPost, "/webhook", &Operation{
+		OperationID: "createWebhook",
+		Summary:     "Subscribe a URL to schedule events. the secret is only returned once. deliveries are POSTed with the headers X-Webhook-Delivery, X-Webhook-Event, X-Webhook-Timestamp and X-Webhook-Signature: sha256=<hex HMAC-SHA256 of \"<timestamp>.<body>\" keyed by the secret>. non 2xx responses are retried with an exponential backoff",
+		Tags:        []string{"webhook"},
+		RequestBody: body(d.jsonOf(types.CreateWebhookInput{})),
+		Responses: errorResponses(map[string]*Response{
+			"201": response("the webhook and its secret", d.jsonOf(types.CreatedWebhook{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/webhook", &Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List the webhooks of the tenant",
+		Tags:        []string{"webhook"},
+		Responses: errorResponses(map[string]*Response{
+			"200": response("the webhooks", &Schema{Type: "array", Items: d.jsonOf(types.Webhook{})}),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError),
+	})
+	d.add(http.MethodDelete, "/webhook/:id", &Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a webhook. its pending deliveries fail",
+		Tags:        []string{"webhook"},
+		Responses: errorResponses(map[string]*Response{
+			"204": response("deleted", nil),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+	d.add(http.MethodGet, "/webhook/:id/deliveries", &Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Delivery log of a webhook, newest first",
+		Tags:        []string{"webhook"},
+		Parameters:  d.queryParameters(reflect.TypeOf(types.DeliveryFilter{})),
+		Responses: errorResponses(map[string]*Response{
+			"200": response("a page of deliveries", d.jsonOf(types.PaginatedResult[types.WebhookDelivery]{})),
+		}, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+	d.add(http.MethodPost, "/webhook/:id/deliveries/:delivery/replay", &Operation{
+		OperationID: "replayWebhookDelivery",
+		Summary:     "Queue the event of a delivery again, as a new delivery",
+		Tags:        []string{"webhook"},
+		Responses: errorResponses(map[string]*Response{
+			"202": response("the queued delivery", d.jsonOf(types.WebhookDelivery{})),
+		}, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError),
+	})
+
+	return d
+}
+
+// errorEnvelope is the ErrorResponse schema with typed details
+func errorEnvelope(d *Document, details *Schema) *Schema {
+	envelope := *d.Components.Schemas["ErrorResponse"]
+	envelope.Properties = map[string]*Schema{}
+	for k, v := range d.Components.Schemas["ErrorResponse"].Properties {
+		envelope.Properties[k] = v
+	}
+	envelope.Properties["details"] = details
+	return &envelope
+}
+
+func statusCode(status int) string {
+	return strconv.Itoa(status)
+}
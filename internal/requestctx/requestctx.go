@@ -0,0 +1,47 @@
+// requestctx package carries request scoped values (actor, request ID, tenant) through context.Context
+package requestctx
+
+import "context"
+
+type ctxKey int
+
+const (
+	actorKey ctxKey = iota
+	requestIDKey
+	tenantKey
+)
+
+// UnknownActor is used when no actor could be resolved for the request.
+const UnknownActor = "unknown"
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor stored in the context or UnknownActor.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return UnknownActor
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID stored in the context or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// Tenant returns the tenant ID stored in the context. ok is false when no tenant was set.
+func Tenant(ctx context.Context) (tenantID string, ok bool) {
+	tenantID, _ = ctx.Value(tenantKey).(string)
+	return tenantID, tenantID != ""
+}
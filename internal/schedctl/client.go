@@ -0,0 +1,254 @@
+package schedctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/middleware"
+	"github.com/japb1998/action-scheduler/internal/transfer"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// pageSize is used when every page is fetched
+const pageSize = 100
+
+// APIError is an error envelope returned by the API
+type APIError struct {
+	Status int
+	types.ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: %s (status=%d", e.Code, e.Message, e.Status)
+	if e.RequestID != "" {
+		msg += " request_id=" + e.RequestID
+	}
+	return msg + ")"
+}
+
+// Client calls the schedule REST API
+type Client struct {
+	baseURL string
+	token   string
+	apiKey  string
+	http    *http.Client
+}
+
+func NewClient(p Profile) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(p.URL, "/"),
+		token:   p.Token,
+		apiKey:  p.APIKey,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var (
+		reader io.Reader
+		header = http.Header{}
+	)
+
+	if body != nil {
+		by, err := json.Marshal(body)
+
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(by)
+		header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.send(ctx, method, path, query, header, reader)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	by, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if out == nil || len(by) == 0 {
+		return nil
+	}
+	return json.Unmarshal(by, out)
+}
+
+// send makes the request. an error status is returned as an APIError, otherwise the caller closes the body.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, c.apiKey)
+	} else if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.http.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	by, _ := io.ReadAll(res.Body)
+	apiErr := &APIError{Status: res.StatusCode}
+	if err := json.Unmarshal(by, &apiErr.ErrorResponse); err != nil || apiErr.Code == "" {
+		apiErr.Code = "unexpected_response"
+		apiErr.Message = strings.TrimSpace(string(by))
+		apiErr.RequestID = res.Header.Get(middleware.RequestIDHeader)
+	}
+	return nil, apiErr
+}
+
+func (c *Client) ListSchedules(ctx context.Context, page, limit int) (*types.PaginatedResult[types.Schedule], error) {
+	var result types.PaginatedResult[types.Schedule]
+	query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(limit)}}
+
+	if err := c.do(ctx, http.MethodGet, "/schedule", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListAllSchedules fetches every page
+func (c *Client) ListAllSchedules(ctx context.Context) ([]types.Schedule, error) {
+	var schedules []types.Schedule
+
+	for page := 0; ; page++ {
+		result, err := c.ListSchedules(ctx, page, pageSize)
+
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, result.Items...)
+
+		if len(result.Items) < pageSize || len(schedules) >= result.Total {
+			return schedules, nil
+		}
+	}
+}
+
+func (c *Client) GetSchedule(ctx context.Context, id string) (*types.Schedule, error) {
+	var sch types.Schedule
+
+	if err := c.do(ctx, http.MethodGet, "/schedule/"+url.PathEscape(id), nil, nil, &sch); err != nil {
+		return nil, err
+	}
+	return &sch, nil
+}
+
+func (c *Client) CreateSchedule(ctx context.Context, input *types.CreateScheduleInput) (*types.Schedule, error) {
+	var sch types.Schedule
+
+	if err := c.do(ctx, http.MethodPost, "/schedule", nil, input, &sch); err != nil {
+		return nil, err
+	}
+	return &sch, nil
+}
+
+// DeleteSchedule deletes the schedule if it is at the version. types.AnyVersion deletes whatever the version.
+func (c *Client) DeleteSchedule(ctx context.Context, id string, version int64) error {
+	ifMatch := "*"
+	if version != types.AnyVersion {
+		ifMatch = strconv.Quote(strconv.FormatInt(version, 10))
+	}
+
+	res, err := c.send(ctx, http.MethodDelete, "/schedule/"+url.PathEscape(id), nil, http.Header{"If-Match": {ifMatch}}, nil)
+
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (c *Client) BatchCreateSchedules(ctx context.Context, items []types.CreateScheduleInput) (*types.BatchResult, error) {
+	var result types.BatchResult
+
+	if err := c.do(ctx, http.MethodPost, "/schedule:batch", nil, &types.BatchCreateInput{Items: items}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BatchDeleteSchedules(ctx context.Context, input *types.BatchDeleteInput) (*types.BatchResult, error) {
+	var result types.BatchResult
+
+	if err := c.do(ctx, http.MethodPost, "/schedule:batchDelete", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExportSchedules streams the schedules matching the filter to w in the given format
+func (c *Client) ExportSchedules(ctx context.Context, format string, filter types.ScheduleFilterInput, w io.Writer) error {
+	query := url.Values{"format": {format}}
+	for key, value := range map[string]string{"action": filter.ActionID, "type": filter.Type, "created_by": filter.CreatedBy} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	// exports outlive the default timeout
+	client := *c
+	client.http = &http.Client{}
+
+	res, err := client.send(ctx, http.MethodGet, "/schedule/export", query, nil, nil)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// ImportSchedules sends an import file of the given format
+func (c *Client) ImportSchedules(ctx context.Context, format string, file io.Reader, dryRun bool) (*types.ImportResult, error) {
+	query := url.Values{"format": {format}, "dry_run": {strconv.FormatBool(dryRun)}}
+
+	client := *c
+	client.http = &http.Client{}
+
+	res, err := client.send(ctx, http.MethodPost, "/schedule/import", query, http.Header{"Content-Type": {transfer.ContentType(format)}}, file)
+
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var result types.ImportResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
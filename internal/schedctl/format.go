@@ -0,0 +1,232 @@
+package schedctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/transfer"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+var ErrInvalidFormat = errors.New("invalid format")
+
+// relative dates are "now" or "+" followed by a duration, where d stands for days. e.g. +90m, +2d
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDate reads an absolute date in loc, or a date relative to now
+func ParseDate(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "now" {
+		return now, nil
+	}
+
+	if rel, ok := strings.CutPrefix(s, "+"); ok {
+		if days, ok := strings.CutSuffix(rel, "d"); ok {
+			d, err := time.ParseDuration(days + "h")
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid relative date %q", s)
+			}
+			return now.Add(24 * d), nil
+		}
+		d, err := time.ParseDuration(rel)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", s)
+		}
+		return now.Add(d), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q. wanted RFC3339, YYYY-MM-DD[ HH:MM[:SS]], now or +<duration>", s)
+}
+
+// formatOf infers the format of a file from its extension
+func formatOf(path, fallback string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".csv":
+		return transfer.CSV
+	}
+	return fallback
+}
+
+// encode writes v as JSON or YAML. YAML goes through JSON so both use the API field names.
+func encode(w io.Writer, format string, v any) error {
+	by, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		_, err = fmt.Fprintln(w, string(by))
+		return err
+	case FormatYAML:
+		var doc any
+		if err := json.Unmarshal(by, &doc); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("%w. wanted=%s|%s|%s. got=%s", ErrInvalidFormat, FormatTable, FormatJSON, FormatYAML, format)
+}
+
+// printSchedules writes the schedules in the given format
+func printSchedules(w io.Writer, format string, loc *time.Location, schedules []types.Schedule) error {
+	if format != FormatTable {
+		return encode(w, format, schedules)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tACTION\tTYPE\tSTART\tEND\tCREATED BY")
+	for _, sch := range schedules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			sch.ID, sch.Name, sch.Action.Id, sch.Expression.Type,
+			formatDate(sch.Expression.Start, loc), formatDate(sch.Expression.End, loc), sch.CreatedBy)
+	}
+	return tw.Flush()
+}
+
+func formatDate(d types.ScheduleDate, loc *time.Location) string {
+	t := time.Time(d)
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(loc).Format("2006-01-02 15:04 MST")
+}
+
+// printImport writes the result of an import in the given format
+func printImport(w io.Writer, format string, result *types.ImportResult) error {
+	if format != FormatTable {
+		return encode(w, format, result)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROW\tNAME\tSTATUS\tID\tREASON")
+	for _, row := range result.Rows {
+		reason := ""
+		if row.Error != nil {
+			reason = row.Error.Code + ": " + row.Error.Message
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", row.Row, row.Name, row.Status, row.ID, reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	verb := "created"
+	if result.DryRun {
+		verb = "would create"
+	}
+	_, err := fmt.Fprintf(w, "%s %d, skipped %d, failed %d\n", verb, result.Created, result.Skipped, result.Failed)
+	return err
+}
+
+// readInputs reads one schedule or a list of schedules from a JSON or YAML file, "-" being stdin.
+// dates may use any format accepted by ParseDate.
+func readInputs(path string, now time.Time, loc *time.Location) ([]types.CreateScheduleInput, error) {
+	var (
+		by  []byte
+		err error
+	)
+
+	if path == "-" {
+		by, err = io.ReadAll(os.Stdin)
+	} else {
+		by, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML
+	var doc any
+	if err := yaml.Unmarshal(by, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s. error=%w", path, err)
+	}
+
+	docs, ok := doc.([]any)
+	if !ok {
+		docs = []any{doc}
+	}
+
+	inputs := make([]types.CreateScheduleInput, 0, len(docs))
+	for i, d := range docs {
+		if err := normalizeDates(d, now, loc); err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i, err)
+		}
+
+		by, err := json.Marshal(d)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i, err)
+		}
+
+		var input types.CreateScheduleInput
+		if err := json.Unmarshal(by, &input); err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i, err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+// normalizeDates rewrites the expression dates of a decoded schedule as RFC3339
+func normalizeDates(doc any, now time.Time, loc *time.Location) error {
+	sch, ok := doc.(map[string]any)
+	if !ok {
+		return errors.New("expected an object")
+	}
+	expression, ok := sch["expression"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"start_date", "end_date"} {
+		switch v := expression[key].(type) {
+		case string:
+			t, err := ParseDate(v, now, loc)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			expression[key] = t.Format(time.RFC3339)
+		case time.Time:
+			expression[key] = v.Format(time.RFC3339)
+		}
+	}
+	return nil
+}
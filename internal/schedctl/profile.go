@@ -0,0 +1,111 @@
+package schedctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnv points at the profiles file. it defaults to schedctl/config.yaml in the user config dir.
+const ConfigEnv = "SCHEDCTL_CONFIG"
+
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// Profile is a connection to one deployment of the API
+type Profile struct {
+	URL    string `yaml:"url"`
+	Token  string `yaml:"token,omitempty"`
+	APIKey string `yaml:"api_key,omitempty"`
+	// TimeZone is used to read and print dates. defaults to the local time zone.
+	TimeZone string `yaml:"time_zone,omitempty"`
+}
+
+// Profiles is the config file
+type Profiles struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	path string
+}
+
+func profilesPath() (string, error) {
+	if p := os.Getenv(ConfigEnv); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return "", fmt.Errorf("failed to find the config dir, set %s. error=%w", ConfigEnv, err)
+	}
+	return filepath.Join(dir, "schedctl", "config.yaml"), nil
+}
+
+// LoadProfiles reads the profiles file. a missing file is an empty config.
+func LoadProfiles() (*Profiles, error) {
+	path, err := profilesPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Profiles{Profiles: map[string]Profile{}, path: path}
+	by, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s. error=%w", path, err)
+	}
+
+	if err := yaml.Unmarshal(by, p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s. error=%w", path, err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = map[string]Profile{}
+	}
+	return p, nil
+}
+
+// Save writes the profiles file. it is only readable by the user as it holds credentials.
+func (p *Profiles) Save() error {
+	by, err := yaml.Marshal(p)
+
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, by, 0o600)
+}
+
+// Get returns the named profile, or the current one when name is empty
+func (p *Profiles) Get(name string) (Profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w. name=%s", ErrUnknownProfile, name)
+	}
+	return profile, nil
+}
+
+// Names returns the profile names sorted
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,706 @@
+// schedctl package implements the schedctl command line client of the REST API
+package schedctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/transfer"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+const usage = `schedctl manages schedules through the REST API.
+
+usage: schedctl <command> [flags]
+
+commands:
+  list      list schedules. --name, --action, --type and --created-by filter them
+  get       print a schedule
+  create    create a schedule from flags, or schedules from a JSON/YAML file (-f)
+  delete    delete schedules
+  preview   print the next runs of a schedule or of an expression given with flags
+  export    write the schedules to a JSON, YAML or CSV file that import accepts
+  import    create the schedules of a JSON, YAML or CSV file. --dry-run only validates them
+  profile   manage connection profiles (list, set, use)
+
+run schedctl <command> -h for the flags of a command.
+`
+
+// ErrUsage is returned for invalid arguments. the usage has already been printed.
+var ErrUsage = errors.New("invalid usage")
+
+// cli holds the flags shared by every command
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	now    func() time.Time
+
+	profile string
+	url     string
+	token   string
+	apiKey  string
+	output  string
+	tz      string
+}
+
+// Run executes the command in args and returns the process exit code
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr, now: time.Now}
+
+	if err := c.run(ctx, args); err != nil {
+		if !errors.Is(err, ErrUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(stderr, "error:", err)
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, usage)
+		return ErrUsage
+	}
+
+	commands := map[string]func(context.Context, []string) error{
+		"list":    c.list,
+		"get":     c.get,
+		"create":  c.create,
+		"delete":  c.delete,
+		"preview": c.preview,
+		"export":  c.export,
+		"import":  c.importSchedules,
+		"profile": c.profiles,
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			fmt.Fprint(c.stdout, usage)
+			return nil
+		}
+		fmt.Fprintf(c.stderr, "unknown command %q\n\n%s", args[0], usage)
+		return ErrUsage
+	}
+	return cmd(ctx, args[1:])
+}
+
+// flags returns the flag set of a command with the shared flags registered
+func (c *cli) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: schedctl %s [flags] %s\n\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&c.profile, "profile", os.Getenv("SCHEDCTL_PROFILE"), "connection profile. defaults to the current profile")
+	fs.StringVar(&c.url, "url", os.Getenv("SCHEDCTL_URL"), "API base URL. overrides the profile")
+	fs.StringVar(&c.token, "token", os.Getenv("SCHEDCTL_TOKEN"), "bearer token. overrides the profile")
+	fs.StringVar(&c.apiKey, "api-key", os.Getenv("SCHEDCTL_API_KEY"), "API key. overrides the profile")
+	fs.StringVar(&c.output, "o", FormatTable, "output format: table, json or yaml")
+	fs.StringVar(&c.tz, "tz", "", "time zone used to read and print dates. overrides the profile, defaults to local")
+	return fs
+}
+
+// parse allows flags after the positional arguments, e.g. schedctl get <id> -o json. the positional arguments are kept in fs.Args().
+func (c *cli) parse(fs *flag.FlagSet, args []string) error {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return ErrUsage
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	// every argument follows --, so this only stores the positional arguments
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+// connection resolves the profile with the flag overrides
+func (c *cli) connection() (Profile, error) {
+	profiles, err := LoadProfiles()
+
+	if err != nil {
+		return Profile{}, err
+	}
+
+	p, err := profiles.Get(c.profile)
+
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if c.url != "" {
+		p.URL = c.url
+	}
+	if c.token != "" {
+		p.Token = c.token
+	}
+	if c.apiKey != "" {
+		p.APIKey = c.apiKey
+	}
+	if c.tz != "" {
+		p.TimeZone = c.tz
+	}
+
+	if p.URL == "" {
+		return Profile{}, errors.New("no API URL. set one with schedctl profile set or --url")
+	}
+	return p, nil
+}
+
+func (c *cli) client() (*Client, *time.Location, error) {
+	p, err := c.connection()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loc, err := c.location(p.TimeZone)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewClient(p), loc, nil
+}
+
+func (c *cli) location(tz string) (*time.Location, error) {
+	if c.tz != "" {
+		tz = c.tz
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q. error=%w", tz, err)
+	}
+	return loc, nil
+}
+
+func (c *cli) list(ctx context.Context, args []string) error {
+	fs := c.flags("list", "")
+	page := fs.Int("page", 0, "page to fetch. ignored when filtering or with --all")
+	limit := fs.Int("limit", 10, "page size")
+	all := fs.Bool("all", false, "fetch every page")
+	var filter scheduleFilter
+	fs.StringVar(&filter.name, "name", "", "only schedules whose name contains this text")
+	fs.StringVar(&filter.action, "action", "", "only schedules of this action ID")
+	fs.StringVar(&filter.expression, "type", "", "only schedules of this type: monthly, weekly, daily or one_time")
+	fs.StringVar(&filter.createdBy, "created-by", "", "only schedules created by this subject")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	client, loc, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	var schedules []types.Schedule
+
+	// the API does not filter, so filtering needs every page
+	if *all || !filter.empty() {
+		schedules, err = client.ListAllSchedules(ctx)
+	} else {
+		var result *types.PaginatedResult[types.Schedule]
+		result, err = client.ListSchedules(ctx, *page, *limit)
+		if result != nil {
+			schedules = result.Items
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	return printSchedules(c.stdout, c.output, loc, filter.apply(schedules))
+}
+
+type scheduleFilter struct {
+	name       string
+	action     string
+	expression string
+	createdBy  string
+}
+
+func (f scheduleFilter) empty() bool {
+	return f == scheduleFilter{}
+}
+
+func (f scheduleFilter) apply(schedules []types.Schedule) []types.Schedule {
+	if f.empty() {
+		return schedules
+	}
+
+	filtered := make([]types.Schedule, 0, len(schedules))
+	for _, sch := range schedules {
+		if f.name != "" && !strings.Contains(strings.ToLower(sch.Name), strings.ToLower(f.name)) {
+			continue
+		}
+		if f.action != "" && sch.Action.Id != f.action {
+			continue
+		}
+		if f.expression != "" && sch.Expression.Type != f.expression {
+			continue
+		}
+		if f.createdBy != "" && sch.CreatedBy != f.createdBy {
+			continue
+		}
+		filtered = append(filtered, sch)
+	}
+	return filtered
+}
+
+func (c *cli) get(ctx context.Context, args []string) error {
+	fs := c.flags("get", "<id>")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	client, loc, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	sch, err := client.GetSchedule(ctx, fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+	return printSchedules(c.stdout, c.output, loc, []types.Schedule{*sch})
+}
+
+// expressionFlags registers the flags that build an expression
+func expressionFlags(fs *flag.FlagSet) (expression, start, end *string) {
+	expression = fs.String("type", "", "schedule type: monthly, weekly, daily or one_time")
+	start = fs.String("start", "", "start date: RFC3339, YYYY-MM-DD[ HH:MM[:SS]] in --tz, now or +<duration> (e.g. +2h, +7d)")
+	end = fs.String("end", "", "end date, same formats as --start")
+	return
+}
+
+func (c *cli) expression(typ, start, end string, loc *time.Location) (types.Expression, error) {
+	e := types.Expression{Type: typ}
+	now := c.now()
+
+	if start != "" {
+		t, err := ParseDate(start, now, loc)
+		if err != nil {
+			return e, fmt.Errorf("--start: %w", err)
+		}
+		e.Start = types.ScheduleDate(t)
+	}
+	if end != "" {
+		t, err := ParseDate(end, now, loc)
+		if err != nil {
+			return e, fmt.Errorf("--end: %w", err)
+		}
+		e.End = types.ScheduleDate(t)
+	}
+	return e, nil
+}
+
+func (c *cli) create(ctx context.Context, args []string) error {
+	fs := c.flags("create", "")
+	file := fs.String("f", "", "JSON or YAML file with a schedule or a list of schedules. - reads stdin")
+	name := fs.String("name", "", "schedule name")
+	action := fs.String("action", "", "action ID")
+	payload := fs.String("payload", "", "payload as a JSON object")
+	typ, start, end := expressionFlags(fs)
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	client, loc, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	var inputs []types.CreateScheduleInput
+
+	if *file != "" {
+		inputs, err = readInputs(*file, c.now(), loc)
+		if err != nil {
+			return err
+		}
+	} else {
+		input := types.CreateScheduleInput{Name: *name, ActionID: *action}
+
+		input.Expression, err = c.expression(*typ, *start, *end, loc)
+		if err != nil {
+			return err
+		}
+		if *payload != "" {
+			if err := json.Unmarshal([]byte(*payload), &input.Payload); err != nil {
+				return fmt.Errorf("--payload must be a JSON object. error=%w", err)
+			}
+		}
+		inputs = append(inputs, input)
+	}
+
+	if len(inputs) == 1 {
+		sch, err := client.CreateSchedule(ctx, &inputs[0])
+		if err != nil {
+			return err
+		}
+		return printSchedules(c.stdout, c.output, loc, []types.Schedule{*sch})
+	}
+
+	result, err := client.BatchCreateSchedules(ctx, inputs)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		created []types.Schedule
+		errs    []error
+	)
+	for _, item := range result.Items {
+		if item.Status != types.BatchCreated {
+			errs = append(errs, fmt.Errorf("failed to create %q: %s", inputs[item.Index].Name, itemError(item)))
+			continue
+		}
+		created = append(created, *item.Schedule)
+	}
+
+	if err := printSchedules(c.stdout, c.output, loc, created); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	fs := c.flags("delete", "<id>...")
+	version := fs.Int64("version", types.AnyVersion, "only delete the schedule if it is still at this version. single id only, any version by default")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	client, _, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 {
+		if err := client.DeleteSchedule(ctx, fs.Arg(0), *version); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, "deleted", fs.Arg(0))
+		return nil
+	}
+
+	result, err := client.BatchDeleteSchedules(ctx, &types.BatchDeleteInput{IDs: fs.Args()})
+
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, item := range result.Items {
+		if item.Status != types.BatchDeleted {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %s", item.ID, itemError(item)))
+			continue
+		}
+		fmt.Fprintln(c.stdout, "deleted", item.ID)
+	}
+	return errors.Join(errs...)
+}
+
+func itemError(item types.BatchItemResult) string {
+	if item.Error == nil {
+		return item.Status
+	}
+	return item.Error.Code + ": " + item.Error.Message
+}
+
+// preview computes the runs locally with the rules of the scheduler, so an expression can be checked before creating it
+func (c *cli) preview(ctx context.Context, args []string) error {
+	fs := c.flags("preview", "[<id>]")
+	count := fs.Int("n", 5, "number of runs")
+	typ, start, end := expressionFlags(fs)
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	var (
+		e   types.Expression
+		loc *time.Location
+		err error
+	)
+
+	if fs.NArg() == 1 {
+		var client *Client
+		client, loc, err = c.client()
+		if err != nil {
+			return err
+		}
+
+		sch, err := client.GetSchedule(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		e = sch.Expression
+	} else {
+		// an expression given with flags does not need a connection
+		profile := Profile{}
+		if profiles, err := LoadProfiles(); err == nil {
+			profile, _ = profiles.Get(c.profile)
+		}
+
+		loc, err = c.location(profile.TimeZone)
+		if err != nil {
+			return err
+		}
+
+		e, err = c.expression(*typ, *start, *end, loc)
+		if err != nil {
+			return err
+		}
+	}
+
+	exp, err := scheduler.NewExpression(time.Time(e.Start), time.Time(e.End), e.Type)
+
+	if err != nil {
+		return err
+	}
+
+	runs := exp.Next(c.now(), *count, loc)
+
+	switch c.output {
+	case FormatTable:
+		if len(runs) == 0 {
+			fmt.Fprintln(c.stdout, "no upcoming runs")
+		}
+		for _, run := range runs {
+			fmt.Fprintln(c.stdout, run.Format("Mon 2006-01-02 15:04 MST"))
+		}
+		return nil
+	default:
+		return encode(c.stdout, c.output, runs)
+	}
+}
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	fs := c.flags("export", "")
+	file := fs.String("f", "-", "file to write. - writes stdout")
+	format := fs.String("format", "", "json, yaml or csv. defaults to the extension of -f, then json")
+	var filter types.ScheduleFilterInput
+	fs.StringVar(&filter.ActionID, "action", "", "only schedules of this action ID")
+	fs.StringVar(&filter.Type, "type", "", "only schedules of this type: monthly, weekly, daily or one_time")
+	fs.StringVar(&filter.CreatedBy, "created-by", "", "only schedules created by this subject")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	client, _, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = formatOf(*file, FormatJSON)
+	}
+
+	if *file == "-" {
+		return client.ExportSchedules(ctx, *format, filter, c.stdout)
+	}
+
+	f, err := os.Create(*file)
+
+	if err != nil {
+		return err
+	}
+
+	if err := client.ExportSchedules(ctx, *format, filter, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "exported to %s\n", *file)
+	return nil
+}
+
+// importSchedules sends the file to the import endpoint. JSON and YAML files are read first, so their dates may use any format accepted by ParseDate.
+func (c *cli) importSchedules(ctx context.Context, args []string) error {
+	fs := c.flags("import", "")
+	file := fs.String("f", "", "JSON, YAML or CSV file, e.g. written by export. - reads stdin")
+	format := fs.String("format", "", "json, yaml or csv. defaults to the extension of -f, then yaml")
+	dryRun := fs.Bool("dry-run", false, "only validate the rows and report what would be created or skipped")
+
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	client, loc, err := c.client()
+
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = formatOf(*file, FormatYAML)
+	}
+
+	var body io.Reader
+
+	switch *format {
+	case transfer.CSV:
+		f := os.Stdin
+		if *file != "-" {
+			if f, err = os.Open(*file); err != nil {
+				return err
+			}
+			defer f.Close()
+		}
+		body = f
+	default:
+		inputs, err := readInputs(*file, c.now(), loc)
+		if err != nil {
+			return err
+		}
+
+		by, err := json.Marshal(inputs)
+		if err != nil {
+			return err
+		}
+		*format = transfer.JSON
+		body = bytes.NewReader(by)
+	}
+
+	result, err := client.ImportSchedules(ctx, *format, body, *dryRun)
+
+	if err != nil {
+		return err
+	}
+
+	if err := printImport(c.stdout, c.output, result); err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d rows failed", result.Failed)
+	}
+	return nil
+}
+
+func (c *cli) profiles(ctx context.Context, args []string) error {
+	const profileUsage = "usage: schedctl profile list | set <name> [--url] [--token] [--api-key] [--tz] | use <name>\n"
+
+	if len(args) == 0 {
+		fmt.Fprint(c.stderr, profileUsage)
+		return ErrUsage
+	}
+
+	profiles, err := LoadProfiles()
+
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		for _, name := range profiles.Names() {
+			marker := " "
+			if name == profiles.Current {
+				marker = "*"
+			}
+			fmt.Fprintf(c.stdout, "%s %s\t%s\n", marker, name, profiles.Profiles[name].URL)
+		}
+		return nil
+	case "set":
+		fs := c.flags("profile set", "<name>")
+		if err := c.parse(fs, args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return ErrUsage
+		}
+
+		name := fs.Arg(0)
+		p := profiles.Profiles[name]
+		if c.url != "" {
+			p.URL = c.url
+		}
+		if c.token != "" {
+			p.Token = c.token
+		}
+		if c.apiKey != "" {
+			p.APIKey = c.apiKey
+		}
+		if c.tz != "" {
+			if _, err := c.location(c.tz); err != nil {
+				return err
+			}
+			p.TimeZone = c.tz
+		}
+
+		profiles.Profiles[name] = p
+		if profiles.Current == "" {
+			profiles.Current = name
+		}
+		return profiles.Save()
+	case "use":
+		if len(args) != 2 {
+			fmt.Fprint(c.stderr, profileUsage)
+			return ErrUsage
+		}
+		if _, ok := profiles.Profiles[args[1]]; !ok {
+			return fmt.Errorf("%w. name=%s", ErrUnknownProfile, args[1])
+		}
+		profiles.Current = args[1]
+		return profiles.Save()
+	}
+
+	fmt.Fprint(c.stderr, profileUsage)
+	return ErrUsage
+}
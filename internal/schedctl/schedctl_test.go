@@ -0,0 +1,147 @@
+package schedctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC)
+	loc, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{in: "now", want: now},
+		{in: "+90m", want: now.Add(90 * time.Minute)},
+		{in: "+2d", want: now.Add(48 * time.Hour)},
+		{in: "2030-02-01T10:00:00Z", want: time.Date(2030, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{in: "2030-02-01 10:00", want: time.Date(2030, 2, 1, 10, 0, 0, 0, loc)},
+		{in: "2030-02-01", want: time.Date(2030, 2, 1, 0, 0, 0, 0, loc)},
+		{in: "+2w", err: true},
+		{in: "01/02/2030", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in, now, loc)
+
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s: expected %s. got=%s error=%v", tt.in, tt.want, got, err)
+		}
+	}
+}
+
+// fakeAPI serves the schedule routes from memory
+func fakeAPI(t *testing.T, schedules []types.Schedule) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schedule", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(types.ErrorResponse{Code: "invalid_token", Message: "invalid token", RequestID: "req-1"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			result := types.PaginatedResult[types.Schedule]{Total: len(schedules), Page: page, Limit: limit, Items: []types.Schedule{}}
+			for i := page * limit; i < len(schedules) && i < (page+1)*limit; i++ {
+				result.Items = append(result.Items, schedules[i])
+			}
+			json.NewEncoder(w).Encode(&result)
+		case http.MethodPost:
+			var input types.CreateScheduleInput
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(types.ErrorResponse{Code: "invalid_request", Message: err.Error()})
+				return
+			}
+			sch := types.Schedule{ID: fmt.Sprint(len(schedules) + 1), Name: input.Name, Expression: input.Expression, Action: types.Action{Id: input.ActionID}}
+			schedules = append(schedules, sch)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&sch)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "config.yaml"))
+	return srv
+}
+
+func TestRun(t *testing.T) {
+	var schedules []types.Schedule
+	for i := 0; i < 150; i++ {
+		schedules = append(schedules, types.Schedule{ID: strconv.Itoa(i), Name: fmt.Sprintf("report-%d", i), Expression: types.Expression{Type: types.DAILY}})
+	}
+	schedules[120].Name = "invoice"
+
+	srv := fakeAPI(t, schedules)
+	run := func(args ...string) (string, string, int) {
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), args, &stdout, &stderr)
+		return stdout.String(), stderr.String(), code
+	}
+
+	if _, stderr, code := run("profile", "set", "local", "--url", srv.URL, "--token", "token", "--tz", "UTC"); code != 0 {
+		t.Fatalf("profile set failed: %s", stderr)
+	}
+
+	t.Run("filter across pages", func(t *testing.T) {
+		stdout, stderr, code := run("list", "--name", "INVOICE", "-o", "json")
+		if code != 0 {
+			t.Fatalf("list failed: %s", stderr)
+		}
+
+		var got []types.Schedule
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("invalid json output: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != "120" {
+			t.Errorf("expected schedule 120. got=%v", got)
+		}
+	})
+
+	t.Run("create from yaml", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "schedules.yaml")
+		os.WriteFile(file, []byte("- name: weekly digest\n  action: \"1\"\n  expression:\n    type: one_time\n    start_date: 2099-02-01 10:00\n"), 0o600)
+
+		stdout, stderr, code := run("create", "-f", file)
+		if code != 0 {
+			t.Fatalf("create failed: %s", stderr)
+		}
+		if !strings.Contains(stdout, "weekly digest") || !strings.Contains(stdout, "2099-02-01 10:00 UTC") {
+			t.Errorf("unexpected table output:\n%s", stdout)
+		}
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		_, stderr, code := run("list", "--token", "other")
+		if code != 1 || !strings.Contains(stderr, "invalid_token") || !strings.Contains(stderr, "request_id=req-1") {
+			t.Errorf("expected the error envelope. code=%d stderr=%s", code, stderr)
+		}
+	})
+}
@@ -3,43 +3,48 @@ package action
 import (
 	"context"
 	"fmt"
-	"os"
 
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/config"
 	"github.com/japb1998/action-scheduler/internal/types"
 )
 
-type ActionService struct{}
+// ErrInvalidAction is returned for an action ID that doesn't exist
+var ErrInvalidAction = apperr.Validation("invalid_action", "Invalid action ID provided", nil)
 
-func New() *ActionService {
-	return &ActionService{}
+// actions. this will also have a collection at some point.
+type ActionService struct {
+	actions []types.Action
 }
 
-// actions. this will also have a collection at some point.
-var (
-	single_email = types.Action{
-		Id:   "1",
-		Name: "single_email",
-		Arn:  os.Getenv("SINGLE_EMAIL_FUNCTION"),
-		Role: os.Getenv("SINGLE_EMAIL_ROLE"),
+func New(cfg config.Actions) *ActionService {
+	return &ActionService{
+		actions: []types.Action{
+			{
+				Id:   "1",
+				Name: "single_email",
+				Arn:  cfg.SingleEmailArn,
+				Role: cfg.SingleEmailRole,
+			},
+			{
+				Id:   "2",
+				Name: "mass_email",
+				Arn:  cfg.MassEmailArn,
+				Role: cfg.MassEmailRole,
+			},
+		},
 	}
-	mass_email = types.Action{
-		Id:   "2",
-		Name: "mass_email",
-		Arn:  os.Getenv("MASS_EMAIL_ARN"),
-		Role: os.Getenv("MASS_EMAIL_ROLE"),
-	}
-)
-
-var actions = []types.Action{
-	single_email,
-	mass_email,
 }
 
 func (as *ActionService) GetActionByID(ctx context.Context, id string) (types.Action, error) {
-	for _, ac := range actions {
+	for _, ac := range as.actions {
 		if ac.Id == id {
 			return ac, nil
 		}
 	}
-	return types.Action{}, fmt.Errorf("Invalid action ID provided")
+	return types.Action{}, fmt.Errorf("%w. action=%s", ErrInvalidAction, id)
+}
+
+func (as *ActionService) GetActions(ctx context.Context) ([]types.Action, error) {
+	return as.actions, nil
 }
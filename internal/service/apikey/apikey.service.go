@@ -0,0 +1,156 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+var (
+	ErrInvalidAPIKey  = apperr.Unauthorized("invalid_api_key", "invalid api key")
+	ErrAPIKeyNotFound = apperr.NotFound("api_key_not_found", "api key not found")
+	ErrMissingActions = apperr.Validation("missing_actions", "actions are required unless the key is read only", nil)
+)
+
+const (
+	keyPrefix = "ask_"
+	// visible part of the key used to identify it in listings
+	displayPrefixLen = len(keyPrefix) + 8
+)
+
+type APIKeyStore interface {
+	Create(ctx context.Context, key *model.APIKey) (string, error)
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	Get(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+type APIKeyService struct {
+	store APIKeyStore
+}
+
+func New(s APIKeyStore) *APIKeyService {
+	return &APIKeyService{
+		store: s,
+	}
+}
+
+func (s *APIKeyService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "apikey"))
+}
+
+// Subject returns the principal subject of an API key. it is used as created_by and audit actor.
+func Subject(id string) string {
+	return fmt.Sprintf("apikey:%s", id)
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Mint creates a new key for the tenant in the context. the plain key is only returned here.
+func (s *APIKeyService) Mint(c context.Context, input types.CreateAPIKeyInput) (*types.MintedAPIKey, error) {
+	if input.ReadOnly {
+		input.Actions = nil
+	} else if len(input.Actions) == 0 {
+		return nil, ErrMissingActions
+	}
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate api key")
+	}
+
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	m := &model.APIKey{
+		Name:      input.Name,
+		Prefix:    key[:displayPrefixLen],
+		Hash:      hash(key),
+		Actions:   input.Actions,
+		ReadOnly:  input.ReadOnly,
+		CreatedBy: requestctx.Actor(c),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	id, err := s.store.Create(c, m)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key")
+	}
+	m.ID = id
+
+	s.log(c).Info("minted api key", "id", id, "prefix", m.Prefix)
+
+	return &types.MintedAPIKey{
+		APIKey: *mapper.MapAPIKeyModelToType(m),
+		Key:    key,
+	}, nil
+}
+
+func (s *APIKeyService) GetAll(c context.Context) ([]types.APIKey, error) {
+	models, err := s.store.Get(c)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys")
+	}
+
+	keys := make([]types.APIKey, 0, len(models))
+	for _, m := range models {
+		keys = append(keys, *mapper.MapAPIKeyModelToType(&m))
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(c context.Context, id string) error {
+	if err := s.store.Revoke(c, id, time.Now().UTC()); err != nil {
+		if errors.Is(err, store.ErrAPIKeyNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke api key with ID='%s'", id)
+	}
+
+	s.log(c).Info("revoked api key", "id", id)
+	return nil
+}
+
+// Authenticate resolves the principal of a plain API key and records its last use.
+func (s *APIKeyService) Authenticate(c context.Context, key string) (auth.Principal, error) {
+	m, err := s.store.GetByHash(c, hash(key))
+
+	if err != nil {
+		if !errors.Is(err, store.ErrAPIKeyNotFound) {
+			s.log(c).Error("error authenticating api key", "error", err.Error())
+		}
+		return auth.Principal{}, ErrInvalidAPIKey
+	}
+
+	// last used is best effort, a failure must not reject the caller.
+	_ = s.store.TouchLastUsed(context.WithoutCancel(c), m.ID, time.Now().UTC())
+
+	return auth.Principal{
+		Subject:  Subject(m.ID),
+		TenantID: m.TenantID,
+		APIKeyID: m.ID,
+		Actions:  m.Actions,
+		ReadOnly: m.ReadOnly,
+	}, nil
+}
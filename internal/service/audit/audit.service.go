@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+type AuditStore interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+	Get(ctx context.Context, filter model.AuditFilter, pagination *types.PaginationOps) (int64, []model.AuditEntry, error)
+}
+
+type AuditService struct {
+	store AuditStore
+}
+
+func New(s AuditStore) *AuditService {
+	return &AuditService{
+		store: s,
+	}
+}
+
+func (s *AuditService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "audit"))
+}
+
+// Record appends an audit entry for the given schedule. actor and request ID are taken from the context.
+func (s *AuditService) Record(c context.Context, action model.AuditAction, scheduleID string, before, after *model.Schedule) error {
+	entry := &model.AuditEntry{
+		ScheduleID: scheduleID,
+		Action:     action,
+		Actor:      requestctx.Actor(c),
+		RequestID:  requestctx.RequestID(c),
+		Timestamp:  time.Now().UTC(),
+		Before:     before,
+		After:      after,
+	}
+
+	if err := s.store.Create(c, entry); err != nil {
+		s.log(c).Error("error recording audit entry", "schedule_id", scheduleID, "action", action, "error", err.Error())
+		return fmt.Errorf("failed to record audit entry for schedule with ID='%s'", scheduleID)
+	}
+
+	return nil
+}
+
+func (s *AuditService) GetPaginated(c context.Context, filter *types.AuditFilter) (*types.PaginatedResult[types.AuditEntry], error) {
+	s.log(c).Info("getting audit entries", "filter", filter)
+
+	if filter == nil {
+		return nil, fmt.Errorf("Invalid filter provided. got=%v", filter)
+	}
+
+	count, models, err := s.store.Get(c, mapper.MapAuditFilterTypeToModel(filter), &filter.PaginationOps)
+
+	if err != nil {
+		s.log(c).Error("error getting audit entries", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("error getting audit entries")
+	}
+
+	entries := make([]types.AuditEntry, 0, len(models))
+	for _, entry := range models {
+		entries = append(entries, *mapper.MapAuditModelToType(&entry))
+	}
+
+	return &types.PaginatedResult[types.AuditEntry]{
+		Total: int(count),
+		Items: entries,
+		Limit: filter.Limit,
+		Page:  filter.Page,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// Check reports whether a single dependency is usable
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthService struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func New(timeout time.Duration, checks ...Check) *HealthService {
+	return &HealthService{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+func (s *HealthService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "health"))
+}
+
+// Ready runs every check concurrently, each bounded by the configured timeout
+func (s *HealthService) Ready(c context.Context) *types.Readiness {
+	results := make([]types.HealthCheck, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = s.run(c, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	readiness := &types.Readiness{Status: types.HealthStatusOK, Checks: results}
+	for _, r := range results {
+		if r.Status != types.HealthStatusOK {
+			readiness.Status = types.HealthStatusError
+		}
+	}
+	return readiness
+}
+
+func (s *HealthService) run(c context.Context, check Check) types.HealthCheck {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+
+	result := types.HealthCheck{
+		Name:      check.Name,
+		Status:    types.HealthStatusOK,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		s.log(c).Error("readiness check failed", "check", check.Name, "error", err.Error())
+		result.Status = types.HealthStatusError
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,237 @@
+package quota
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError carries the usage of the quota that rejected the request
+type QuotaExceededError struct {
+	Usage types.QuotaUsage
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s. scope=%s key=%s used=%d limit=%d", ErrQuotaExceeded, e.Usage.Scope, e.Usage.Key, e.Usage.Used, e.Usage.Limit)
+}
+
+// Unwrap exposes the usage as the details of the domain error, ErrQuotaExceeded is at the end of the chain.
+func (e *QuotaExceededError) Unwrap() error {
+	return &apperr.Error{
+		Kind:    apperr.KindQuotaExceeded,
+		Code:    "quota_exceeded",
+		Message: e.Error(),
+		Details: e.Usage,
+		Err:     ErrQuotaExceeded,
+	}
+}
+
+const rateWindow = time.Minute
+
+type QuotaStore interface {
+	Increment(ctx context.Context, scope model.QuotaScope, key string, limit int64, expiresAt *time.Time) (int64, bool, error)
+	Decrement(ctx context.Context, scope model.QuotaScope, key string) error
+	Get(ctx context.Context, scope model.QuotaScope, key string) (int64, error)
+	Set(ctx context.Context, scope model.QuotaScope, key string, count int64) error
+	DeleteAll(ctx context.Context, scopes ...model.QuotaScope) (int64, error)
+}
+
+// ScheduleStore lists the schedules the active counters are reconciled from
+type ScheduleStore interface {
+	Get(ctx context.Context, filter model.ScheduleFilter, pagination *types.PaginationOps) (int64, []model.Schedule, error)
+}
+
+type ActionSvc interface {
+	GetActions(ctx context.Context) ([]types.Action, error)
+}
+
+type QuotaService struct {
+	store     QuotaStore
+	schedules ScheduleStore
+	actionSvc ActionSvc
+	limits    config.Quota
+}
+
+func New(s QuotaStore, schedules ScheduleStore, actionSvc ActionSvc, limits config.Quota) *QuotaService {
+	return &QuotaService{
+		store:     s,
+		schedules: schedules,
+		actionSvc: actionSvc,
+		limits:    limits,
+	}
+}
+
+func (s *QuotaService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "quota"))
+}
+
+type counter struct {
+	scope model.QuotaScope
+	key   string
+	limit int64
+}
+
+// active schedule counters of a creation. the tenant itself is implied by the context.
+func (s *QuotaService) activeCounters(c context.Context, createdBy, actionID string) []counter {
+	tenantID, _ := requestctx.Tenant(c)
+
+	return []counter{
+		{scope: model.QuotaScopeTenant, key: tenantID, limit: s.limits.MaxActivePerTenant},
+		{scope: model.QuotaScopeUser, key: createdBy, limit: s.limits.MaxActivePerUser},
+		{scope: model.QuotaScopeAction, key: actionID, limit: s.limits.MaxActivePerAction},
+	}
+}
+
+func rateKey(createdBy string, now time.Time) (string, time.Time) {
+	window := now.Truncate(rateWindow)
+	return fmt.Sprintf("%s:%d", createdBy, window.Unix()), window.Add(2 * rateWindow)
+}
+
+// Acquire reserves one active schedule for the creator and action and counts the creation against the rate limit.
+// everything acquired is rolled back when a quota is exceeded. successful acquisitions must be paired with Release.
+func (s *QuotaService) Acquire(c context.Context, createdBy, actionID string) error {
+	key, expiresAt := rateKey(createdBy, time.Now().UTC())
+	count, ok, err := s.store.Increment(c, model.QuotaScopeRate, key, s.limits.MaxCreatesPerMin, &expiresAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to check creation rate")
+	}
+	if !ok {
+		return &QuotaExceededError{Usage: types.QuotaUsage{Scope: string(model.QuotaScopeRate), Key: createdBy, Used: count, Limit: s.limits.MaxCreatesPerMin}}
+	}
+
+	acquired := make([]counter, 0, 3)
+	for _, ct := range s.activeCounters(c, createdBy, actionID) {
+		count, ok, err := s.store.Increment(c, ct.scope, ct.key, ct.limit, nil)
+
+		if err != nil || !ok {
+			s.release(c, acquired)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check %s quota", ct.scope)
+		}
+		if !ok {
+			s.log(c).Info("quota exceeded", "scope", ct.scope, "key", ct.key, "used", count, "limit", ct.limit)
+			return &QuotaExceededError{Usage: types.QuotaUsage{Scope: string(ct.scope), Key: ct.key, Used: count, Limit: ct.limit}}
+		}
+		acquired = append(acquired, ct)
+	}
+
+	return nil
+}
+
+// Release frees the active schedule reserved by Acquire. creations already counted against the rate limit are kept.
+func (s *QuotaService) Release(c context.Context, createdBy, actionID string) {
+	s.release(c, s.activeCounters(c, createdBy, actionID))
+}
+
+func (s *QuotaService) release(c context.Context, counters []counter) {
+	for _, ct := range counters {
+		if err := s.store.Decrement(c, ct.scope, ct.key); err != nil {
+			s.log(c).Error("error releasing quota", "scope", ct.scope, "key", ct.key, "error", err.Error())
+		}
+	}
+}
+
+// Usage returns the usage of every quota that applies to the creator, including the one of each action.
+func (s *QuotaService) Usage(c context.Context, createdBy string) ([]types.QuotaUsage, error) {
+	rate, _ := rateKey(createdBy, time.Now().UTC())
+	tenantID, _ := requestctx.Tenant(c)
+
+	actions, err := s.actionSvc.GetActions(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	counters := []counter{
+		{scope: model.QuotaScopeTenant, key: tenantID, limit: s.limits.MaxActivePerTenant},
+		{scope: model.QuotaScopeUser, key: createdBy, limit: s.limits.MaxActivePerUser},
+		{scope: model.QuotaScopeRate, key: rate, limit: s.limits.MaxCreatesPerMin},
+	}
+	for _, action := range actions {
+		counters = append(counters, counter{scope: model.QuotaScopeAction, key: action.Id, limit: s.limits.MaxActivePerAction})
+	}
+
+	usage := make([]types.QuotaUsage, 0, len(counters))
+	for _, ct := range counters {
+		used, err := s.store.Get(c, ct.scope, ct.key)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s quota usage", ct.scope)
+		}
+
+		key := ct.key
+		if ct.scope == model.QuotaScopeRate {
+			key = createdBy
+		}
+		usage = append(usage, types.QuotaUsage{Scope: string(ct.scope), Key: key, Used: used, Limit: ct.limit})
+	}
+	return usage, nil
+}
+
+// Reset deletes every counter of the tenant in the context. it is part of the tenant teardown.
+func (s *QuotaService) Reset(c context.Context) error {
+	count, err := s.store.DeleteAll(c)
+
+	if err != nil {
+		return fmt.Errorf("failed to reset quota counters")
+	}
+	s.log(c).Info("reset quota counters", "count", count)
+	return nil
+}
+
+// Reconcile recounts the active schedule counters of the tenant in the context from its schedules, e.g. the schedules created before the quotas were enabled.
+// creations and deletions racing with it can leave a counter off by their count, reconciling again fixes it.
+func (s *QuotaService) Reconcile(c context.Context) ([]types.QuotaUsage, error) {
+	_, schedules, err := s.schedules.Get(c, model.ScheduleFilter{}, &types.PaginationOps{})
+
+	if err != nil {
+		s.log(c).Error("error getting schedules to reconcile", "error", err.Error())
+		return nil, fmt.Errorf("failed to get schedules to reconcile")
+	}
+
+	// the tenant counter is set even without schedules so it always shows up in the result
+	tenantID, _ := requestctx.Tenant(c)
+	counts := map[counter]int64{{scope: model.QuotaScopeTenant, key: tenantID, limit: s.limits.MaxActivePerTenant}: 0}
+	for _, schedule := range schedules {
+		for _, ct := range s.activeCounters(c, schedule.CreatedBy, schedule.ActionID) {
+			counts[ct]++
+		}
+	}
+
+	// counters of users and actions without schedules left go away
+	if _, err := s.store.DeleteAll(c, model.QuotaScopeTenant, model.QuotaScopeUser, model.QuotaScopeAction); err != nil {
+		return nil, fmt.Errorf("failed to reset quota counters")
+	}
+
+	usage := make([]types.QuotaUsage, 0, len(counts))
+	for ct, count := range counts {
+		if err := s.store.Set(c, ct.scope, ct.key, count); err != nil {
+			return nil, fmt.Errorf("failed to set %s quota counter", ct.scope)
+		}
+		usage = append(usage, types.QuotaUsage{Scope: string(ct.scope), Key: ct.key, Used: count, Limit: ct.limit})
+	}
+	s.log(c).Info("reconciled quota counters", "schedules", len(schedules), "counters", len(usage))
+
+	slices.SortFunc(usage, func(a, b types.QuotaUsage) int {
+		if a.Scope != b.Scope {
+			return cmp.Compare(a.Scope, b.Scope)
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return usage, nil
+}
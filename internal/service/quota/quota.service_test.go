@@ -0,0 +1,107 @@
+package quota
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store/storetest"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// fakeCounters are the counters of a single tenant
+type fakeCounters map[model.QuotaScope]map[string]int64
+
+func (f fakeCounters) Increment(ctx context.Context, scope model.QuotaScope, key string, limit int64, expiresAt *time.Time) (int64, bool, error) {
+	if limit > 0 && f[scope][key] >= limit {
+		return f[scope][key], false, nil
+	}
+	_ = f.Set(ctx, scope, key, f[scope][key]+1)
+	return f[scope][key], true, nil
+}
+
+func (f fakeCounters) Decrement(ctx context.Context, scope model.QuotaScope, key string) error {
+	if f[scope][key] > 0 {
+		f[scope][key]--
+	}
+	return nil
+}
+
+func (f fakeCounters) Get(ctx context.Context, scope model.QuotaScope, key string) (int64, error) {
+	return f[scope][key], nil
+}
+
+func (f fakeCounters) Set(ctx context.Context, scope model.QuotaScope, key string, count int64) error {
+	if f[scope] == nil {
+		f[scope] = map[string]int64{}
+	}
+	f[scope][key] = count
+	return nil
+}
+
+func (f fakeCounters) DeleteAll(ctx context.Context, scopes ...model.QuotaScope) (int64, error) {
+	var n int64
+	for scope, counters := range f {
+		if len(scopes) == 0 || slices.Contains(scopes, scope) {
+			n += int64(len(counters))
+			delete(f, scope)
+		}
+	}
+	return n, nil
+}
+
+type fakeActions struct{}
+
+func (fakeActions) GetActions(ctx context.Context) ([]types.Action, error) { return nil, nil }
+
+func TestReconcile(t *testing.T) {
+	ctx := requestctx.WithTenant(context.Background(), "acme")
+	schedules := storetest.NewMemoryScheduleStore()
+	for _, s := range []model.CreateScheduleInput{
+		{Name: "a", CreatedBy: "u1", ActionID: "email"},
+		{Name: "b", CreatedBy: "u1", ActionID: "sms"},
+		{Name: "c", CreatedBy: "u2", ActionID: "email"},
+	} {
+		if _, err := schedules.Create(ctx, &s); err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+	}
+	// drifted counters: a user without schedules and a tenant counter at its limit
+	counters := fakeCounters{
+		model.QuotaScopeTenant: {"acme": 10},
+		model.QuotaScopeUser:   {"gone": 4},
+		model.QuotaScopeRate:   {"u1:0": 1},
+	}
+	svc := New(counters, schedules, fakeActions{}, config.Quota{MaxActivePerTenant: 10})
+
+	usage, err := svc.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := fakeCounters{
+		model.QuotaScopeTenant: {"acme": 3},
+		model.QuotaScopeUser:   {"u1": 2, "u2": 1},
+		model.QuotaScopeAction: {"email": 2, "sms": 1},
+		model.QuotaScopeRate:   {"u1:0": 1},
+	}
+	for scope, keys := range want {
+		for key, count := range keys {
+			if counters[scope][key] != count {
+				t.Errorf("expected %s %s at %d. got=%d", scope, key, count, counters[scope][key])
+			}
+		}
+	}
+	if _, ok := counters[model.QuotaScopeUser]["gone"]; ok || len(usage) != 5 {
+		t.Errorf("expected the 5 counters of the schedules only. got=%+v", usage)
+	}
+
+	// the tenant can create again once the counters are reset, e.g. after a teardown
+	if err := svc.Reset(ctx); err != nil || len(counters) != 0 {
+		t.Errorf("expected every counter reset. got=%v %v", counters, err)
+	}
+}
@@ -0,0 +1,176 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+var (
+	ErrForbidden           = apperr.Forbidden("forbidden", "forbidden")
+	ErrRoleBindingNotFound = apperr.NotFound("role_binding_not_found", "role binding not found")
+)
+
+type Operation string
+
+const (
+	OpReadSchedule   Operation = "schedule:read"
+	OpCreateSchedule Operation = "schedule:create"
+	OpUpdateSchedule Operation = "schedule:update"
+	OpDeleteSchedule Operation = "schedule:delete"
+	// OpReportRun lets an action report the runs of its schedules, see SchedulerService.ReportRun
+	OpReportRun      Operation = "run:report"
+	OpReadAudit      Operation = "audit:read"
+	OpManageTenant   Operation = "tenant:manage"
+	OpManageRoles    Operation = "role:manage"
+	OpManageAPIKeys  Operation = "apikey:manage"
+	OpManageWebhooks Operation = "webhook:manage"
+)
+
+// permissions granted to each role. admins are granted every operation.
+var permissions = map[model.Role][]Operation{
+	model.RoleViewer:    {OpReadSchedule},
+	model.RoleScheduler: {OpReadSchedule, OpCreateSchedule, OpUpdateSchedule, OpDeleteSchedule, OpReportRun},
+}
+
+// operations that schedule an action and therefore require a per-action permission
+var actionOperations = []Operation{OpCreateSchedule, OpUpdateSchedule, OpReportRun}
+
+type RoleStore interface {
+	GetBySubject(ctx context.Context, subject string) (*model.RoleBinding, error)
+	Get(ctx context.Context) ([]model.RoleBinding, error)
+	Upsert(ctx context.Context, binding *model.RoleBinding) error
+	Delete(ctx context.Context, subject string) error
+}
+
+type RBACService struct {
+	store RoleStore
+	// subjects that are admins of every tenant, used to bootstrap role bindings.
+	bootstrapAdmins []string
+}
+
+func New(s RoleStore, bootstrapAdmins []string) *RBACService {
+	return &RBACService{
+		store:           s,
+		bootstrapAdmins: bootstrapAdmins,
+	}
+}
+
+func (s *RBACService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "rbac"))
+}
+
+// binding resolves the role binding of the principal in the context. subjects without a binding are viewers.
+// API keys act as schedulers restricted to the actions they were minted for, read-only keys as viewers.
+func (s *RBACService) binding(c context.Context) (*model.RoleBinding, error) {
+	p, ok := auth.PrincipalFromContext(c)
+
+	if !ok {
+		return nil, fmt.Errorf("%w. reason: no authenticated principal", ErrForbidden)
+	}
+
+	if p.APIKeyID != "" && p.ReadOnly {
+		return &model.RoleBinding{Subject: p.Subject, Role: model.RoleViewer}, nil
+	}
+	if p.APIKeyID != "" {
+		return &model.RoleBinding{Subject: p.Subject, Role: model.RoleScheduler, Actions: p.Actions}, nil
+	}
+
+	if slices.Contains(s.bootstrapAdmins, p.Subject) {
+		return &model.RoleBinding{Subject: p.Subject, Role: model.RoleAdmin}, nil
+	}
+
+	b, err := s.store.GetBySubject(c, p.Subject)
+
+	if err != nil {
+		if errors.Is(err, store.ErrRoleBindingNotFound) {
+			return &model.RoleBinding{Subject: p.Subject, Role: model.RoleViewer}, nil
+		}
+		s.log(c).Error("error getting role binding", "subject", p.Subject, "error", err.Error())
+		return nil, fmt.Errorf("failed to resolve role for subject='%s'", p.Subject)
+	}
+	return b, nil
+}
+
+// Authorize returns ErrForbidden with the reason when the principal in the context cannot perform op.
+// actionID is only checked for operations that schedule an action.
+func (s *RBACService) Authorize(c context.Context, op Operation, actionID string) error {
+	b, err := s.binding(c)
+
+	if err != nil {
+		return err
+	}
+
+	if b.Role == model.RoleAdmin {
+		return nil
+	}
+
+	if !slices.Contains(permissions[b.Role], op) {
+		return fmt.Errorf("%w. reason: role '%s' is not allowed to perform '%s'", ErrForbidden, b.Role, op)
+	}
+
+	if slices.Contains(actionOperations, op) && !slices.Contains(b.Actions, model.AllActions) && !slices.Contains(b.Actions, actionID) {
+		return fmt.Errorf("%w. reason: role '%s' is not allowed to schedule action '%s'", ErrForbidden, b.Role, actionID)
+	}
+
+	return nil
+}
+
+// IsAdmin reports whether the principal in the context is an admin of its tenant
+func (s *RBACService) IsAdmin(c context.Context) (bool, error) {
+	b, err := s.binding(c)
+
+	if err != nil {
+		return false, err
+	}
+	return b.Role == model.RoleAdmin, nil
+}
+
+func (s *RBACService) GetRoles(c context.Context) ([]types.RoleBinding, error) {
+	bindings, err := s.store.Get(c)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role bindings")
+	}
+
+	roles := make([]types.RoleBinding, 0, len(bindings))
+	for _, b := range bindings {
+		roles = append(roles, *mapper.MapRoleBindingModelToType(&b))
+	}
+	return roles, nil
+}
+
+func (s *RBACService) UpsertRole(c context.Context, subject string, input types.UpsertRoleInput) (*types.RoleBinding, error) {
+	b := &model.RoleBinding{
+		Subject:   subject,
+		Role:      model.Role(input.Role),
+		Actions:   input.Actions,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := s.store.Upsert(c, b); err != nil {
+		return nil, fmt.Errorf("failed to save role binding for subject='%s'", subject)
+	}
+	return mapper.MapRoleBindingModelToType(b), nil
+}
+
+func (s *RBACService) DeleteRole(c context.Context, subject string) error {
+	if err := s.store.Delete(c, subject); err != nil {
+		if errors.Is(err, store.ErrRoleBindingNotFound) {
+			return ErrRoleBindingNotFound
+		}
+		return fmt.Errorf("failed to delete role binding for subject='%s'", subject)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/store"
+)
+
+type roleStore map[string]model.RoleBinding
+
+func (s roleStore) GetBySubject(ctx context.Context, subject string) (*model.RoleBinding, error) {
+	b, ok := s[subject]
+	if !ok {
+		return nil, store.ErrRoleBindingNotFound
+	}
+	return &b, nil
+}
+func (s roleStore) Get(ctx context.Context) ([]model.RoleBinding, error)         { return nil, nil }
+func (s roleStore) Upsert(ctx context.Context, binding *model.RoleBinding) error { return nil }
+func (s roleStore) Delete(ctx context.Context, subject string) error             { return nil }
+
+func TestAuthorize(t *testing.T) {
+	svc := New(roleStore{
+		"scheduler": {Subject: "scheduler", Role: model.RoleScheduler, Actions: []string{"1"}},
+		"any":       {Subject: "any", Role: model.RoleScheduler, Actions: []string{model.AllActions}},
+		"admin":     {Subject: "admin", Role: model.RoleAdmin},
+	}, []string{"root"})
+
+	tests := []struct {
+		subject string
+		op      Operation
+		action  string
+		allowed bool
+	}{
+		{subject: "viewer", op: OpReadSchedule, allowed: true},
+		{subject: "viewer", op: OpCreateSchedule, action: "1", allowed: false},
+		{subject: "scheduler", op: OpCreateSchedule, action: "1", allowed: true},
+		{subject: "scheduler", op: OpCreateSchedule, action: "2", allowed: false},
+		{subject: "scheduler", op: OpReportRun, action: "2", allowed: false},
+		{subject: "scheduler", op: OpDeleteSchedule, allowed: true},
+		{subject: "scheduler", op: OpReadAudit, allowed: false},
+		{subject: "any", op: OpCreateSchedule, action: "2", allowed: true},
+		{subject: "admin", op: OpManageRoles, allowed: true},
+		{subject: "root", op: OpManageTenant, allowed: true},
+		{subject: "apikey", op: OpCreateSchedule, action: "2", allowed: true},
+		{subject: "apikey", op: OpCreateSchedule, action: "1", allowed: false},
+		{subject: "apikey", op: OpManageAPIKeys, allowed: false},
+		{subject: "readonly", op: OpReadSchedule, allowed: true},
+		{subject: "readonly", op: OpCreateSchedule, action: "2", allowed: false},
+		{subject: "readonly", op: OpDeleteSchedule, allowed: false},
+	}
+
+	for _, tt := range tests {
+		p := auth.Principal{Subject: tt.subject}
+		if tt.subject == "apikey" {
+			p.APIKeyID, p.Actions = "key", []string{"2"}
+		}
+		if tt.subject == "readonly" {
+			p.APIKeyID, p.ReadOnly = "key", true
+		}
+		ctx := auth.WithPrincipal(context.Background(), p)
+		err := svc.Authorize(ctx, tt.op, tt.action)
+
+		if tt.allowed && err != nil {
+			t.Errorf("%s %s %s: expected allowed. got error=%v", tt.subject, tt.op, tt.action, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s %s %s: expected ErrForbidden. got=%v", tt.subject, tt.op, tt.action, err)
+		}
+	}
+}
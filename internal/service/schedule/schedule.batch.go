@@ -0,0 +1,194 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/tracing"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrBatchTooLarge = apperr.Validation("batch_too_large", "too many items in batch", nil)
+	ErrInvalidBatch  = apperr.Validation("invalid_batch", "either ids or a filter is required, not both", nil)
+)
+
+// BatchCreate creates every item like Create does. items fail independently, each one gets its result at its index.
+func (s *SchedulerService) BatchCreate(c context.Context, items []types.CreateScheduleInput) (result *types.BatchResult, err error) {
+	c, span := tracing.Start(c, "SchedulerService.BatchCreate", attribute.Int("batch.size", len(items)))
+	defer func() { tracing.End(span, err) }()
+
+	if len(items) > s.batch.MaxItems {
+		return nil, fmt.Errorf("%w. max=%d. got=%d", ErrBatchTooLarge, s.batch.MaxItems, len(items))
+	}
+
+	s.log(c).Info("creating schedules in batch", "count", len(items))
+
+	results := make([]types.BatchItemResult, len(items))
+	s.forEach(c, len(items), func(c context.Context, i int) {
+		results[i] = types.BatchItemResult{Index: i}
+
+		sch, err := s.Create(c, items[i])
+		if err != nil {
+			results[i].Status = types.BatchFailed
+			results[i].Error = batchError(err)
+			return
+		}
+
+		results[i].ID = sch.ID
+		results[i].Status = types.BatchCreated
+		results[i].Schedule = sch
+	})
+
+	return batchResult(results, 0), nil
+}
+
+// BatchDelete deletes the schedules of the given IDs, or up to the batch limit of the schedules matching the filter.
+func (s *SchedulerService) BatchDelete(c context.Context, input types.BatchDeleteInput) (result *types.BatchResult, err error) {
+	c, span := tracing.Start(c, "SchedulerService.BatchDelete", attribute.Int("batch.size", len(input.IDs)))
+	defer func() { tracing.End(span, err) }()
+
+	if (len(input.IDs) == 0) == (input.Filter == nil) {
+		return nil, ErrInvalidBatch
+	}
+
+	if len(input.IDs) > s.batch.MaxItems {
+		return nil, fmt.Errorf("%w. max=%d. got=%d", ErrBatchTooLarge, s.batch.MaxItems, len(input.IDs))
+	}
+
+	ids := input.IDs
+	remaining := 0
+
+	if input.Filter != nil {
+		ids, remaining, err = s.matchingIDs(c, *input.Filter)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.log(c).Info("deleting schedules in batch", "count", len(ids), "remaining", remaining)
+
+	results := make([]types.BatchItemResult, len(ids))
+	s.forEach(c, len(ids), func(c context.Context, i int) {
+		results[i] = types.BatchItemResult{Index: i, ID: ids[i]}
+
+		if err := s.Delete(c, ids[i], types.AnyVersion); err != nil {
+			results[i].Status = types.BatchFailed
+			results[i].Error = batchError(err)
+			return
+		}
+		results[i].Status = types.BatchDeleted
+	})
+
+	return batchResult(results, remaining), nil
+}
+
+// matchingIDs returns the IDs of the first schedules matching the filter, up to the batch limit, and how many are left over.
+// non admins only match their own schedules.
+func (s *SchedulerService) matchingIDs(c context.Context, f types.ScheduleFilterInput) ([]string, int, error) {
+	if err := s.authz.Authorize(c, rbac.OpDeleteSchedule, ""); err != nil {
+		return nil, 0, err
+	}
+
+	filter, err := s.scheduleFilter(c, f)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, models, err := s.store.Get(c, filter, &types.PaginationOps{Limit: s.batch.MaxItems})
+
+	if err != nil {
+		s.log(c).Error("error getting schedules matching filter", "error", err.Error())
+		return nil, 0, fmt.Errorf("error getting schedules")
+	}
+
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	return ids, int(count) - len(ids), nil
+}
+
+// scheduleFilter restricts the filter of a caller to the schedules it can see
+func (s *SchedulerService) scheduleFilter(c context.Context, f types.ScheduleFilterInput) (model.ScheduleFilter, error) {
+	visibility, err := s.visibilityFilter(c)
+
+	if err != nil {
+		return model.ScheduleFilter{}, err
+	}
+
+	filter := model.ScheduleFilter{
+		CreatedBy: f.CreatedBy,
+		ActionID:  f.ActionID,
+		Type:      model.ExpressionType(f.Type),
+	}
+
+	if visibility.CreatedBy != "" {
+		if filter.CreatedBy != "" && filter.CreatedBy != visibility.CreatedBy {
+			return model.ScheduleFilter{}, fmt.Errorf("%w. reason: only admins can access schedules created by other users", rbac.ErrForbidden)
+		}
+		filter.CreatedBy = visibility.CreatedBy
+	}
+	return filter, nil
+}
+
+// forEach calls fn for every index with at most batch.Concurrency calls at once.
+// indexes not started when c is done are not called.
+func (s *SchedulerService) forEach(c context.Context, count int, fn func(c context.Context, i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.batch.Concurrency)
+
+	for i := 0; i < count; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-c.Done():
+		}
+
+		// select picks at random when both are ready
+		if c.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(c, i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func batchError(err error) *types.ErrorResponse {
+	res := apperr.Response(err)
+	return &res
+}
+
+// batchResult counts the outcomes. items not processed because the request was cancelled are reported as failed.
+func batchResult(results []types.BatchItemResult, remaining int) *types.BatchResult {
+	result := &types.BatchResult{Items: results, Remaining: remaining}
+
+	for i := range results {
+		if results[i].Status == "" {
+			results[i].Index = i
+			results[i].Status = types.BatchFailed
+			results[i].Error = &types.ErrorResponse{Code: "cancelled", Message: "request cancelled before the item was processed"}
+		}
+
+		if results[i].Status == types.BatchFailed {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestForEach(t *testing.T) {
+	s := &SchedulerService{batch: config.Batch{MaxItems: 100, Concurrency: 3}}
+
+	var running, peak, calls atomic.Int32
+	s.forEach(context.Background(), 20, func(c context.Context, i int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		calls.Add(1)
+	})
+
+	if calls.Load() != 20 {
+		t.Errorf("expected 20 calls. got=%d", calls.Load())
+	}
+	if peak.Load() > 3 {
+		t.Errorf("expected at most 3 calls at once. got=%d", peak.Load())
+	}
+}
+
+func TestForEachCancelled(t *testing.T) {
+	s := &SchedulerService{batch: config.Batch{MaxItems: 100, Concurrency: 1}}
+	c, cancel := context.WithCancel(context.Background())
+
+	results := make([]types.BatchItemResult, 5)
+	s.forEach(c, len(results), func(c context.Context, i int) {
+		results[i].Status = types.BatchCreated
+		cancel()
+	})
+
+	result := batchResult(results, 0)
+	if result.Succeeded != 1 || result.Failed != 4 {
+		t.Fatalf("expected 1 succeeded and 4 cancelled. got=%+v", result)
+	}
+	if result.Items[4].Error == nil || result.Items[4].Error.Code != "cancelled" {
+		t.Errorf("expected the unprocessed items to be cancelled. got=%+v", result.Items[4])
+	}
+}
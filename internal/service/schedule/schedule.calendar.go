@@ -0,0 +1,24 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeZone returns the time zone the schedules of the tenant fire in
+func (s *SchedulerService) TimeZone(c context.Context) (*time.Location, error) {
+	tenant, err := s.tenantSvc.GetCurrent(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(tenant.TimeZone)
+
+	if err != nil {
+		s.log(c).Error("invalid tenant time zone", "time_zone", tenant.TimeZone, "error", err.Error())
+		return nil, fmt.Errorf("invalid time zone of tenant. tz=%s", tenant.TimeZone)
+	}
+	return loc, nil
+}
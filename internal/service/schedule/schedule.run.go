@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/tracing"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ReportRun records a run of the schedule reported by its action and emits schedule.fired or schedule.failed.
+// EventBridge invokes the action without telling us, so the action is the only one who knows.
+func (s *SchedulerService) ReportRun(c context.Context, id string, run types.ScheduleRunInput) (err error) {
+	c, span := tracing.Start(c, "SchedulerService.ReportRun", attribute.String("schedule.id", id), attribute.String("run.status", run.Status))
+	defer func() { tracing.End(span, err) }()
+
+	modelS, err := s.store.GetByID(c, id)
+
+	if err != nil {
+		s.log(c).Error("error getting schedule by ID", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrScheduleNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return ErrScheduleNotFound
+		}
+		return fmt.Errorf("failed to get schedule with ID='%s'", id)
+	}
+
+	// runs are reported by the action, e.g. with an API key minted for it, not by whoever created the schedule
+	if err := s.authz.Authorize(c, rbac.OpReportRun, modelS.ActionID); err != nil {
+		return err
+	}
+
+	s.log(c).Info("schedule run reported", "id", id, "status", run.Status)
+	auditErr := s.audit(c, model.AuditRun, id, nil, nil)
+
+	event := types.EventScheduleFired
+	if run.Status == types.RunFailed {
+		event = types.EventScheduleFailed
+	}
+	s.emit(c, types.WebhookEvent{Type: event, Schedule: s.withAction(c, modelS), Run: &run})
+
+	return auditErr
+}
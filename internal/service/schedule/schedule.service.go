@@ -5,100 +5,190 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"slices"
 
 	"log/slog"
 
 	"github.com/google/uuid"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/logging"
 	"github.com/japb1998/action-scheduler/internal/mapper"
 	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
 	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/tracing"
 	"github.com/japb1998/action-scheduler/internal/types"
 	"github.com/japb1998/action-scheduler/pkg/scheduler"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
-	ErrScheduleNotFound = fmt.Errorf("schedule not found")
-	ErrorInvalidPayload = errors.New("invalid payload")
+	ErrScheduleNotFound  = apperr.NotFound("schedule_not_found", "schedule not found")
+	ErrorInvalidPayload  = apperr.Validation("invalid_payload", "invalid payload", nil)
+	ErrInvalidExpression = apperr.Validation("invalid_expression", "invalid schedule expression", nil)
+	ErrMissingPrincipal  = apperr.Unauthorized("missing_principal", "no authenticated principal")
+	ErrActionNotAllowed  = apperr.Forbidden("action_not_allowed", "action not allowed for tenant")
+	ErrScheduleConflict  = apperr.Conflict("schedule_conflict", "schedule already exists in the scheduler")
+	ErrVersionMismatch   = apperr.PreconditionFailed("version_mismatch", "schedule was modified since the given version")
+	// ErrAuditFailed is returned when the schedule was written but its audit entry was not
+	ErrAuditFailed = apperr.New(apperr.KindInternal, "audit_failed", "the schedule was written but its audit entry could not be recorded")
 )
 
 type SchedulerStore interface {
 	GetByID(context.Context, string) (*model.Schedule, error)
-	Get(context.Context, *types.PaginationOps) (int64, []model.Schedule, error)
+	Get(context.Context, model.ScheduleFilter, *types.PaginationOps) (int64, []model.Schedule, error)
 	Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error)
-	Delete(c context.Context, id string) error
-	Update(c context.Context, id string, notification model.Schedule) (*model.Schedule, error)
+	// Delete deletes the schedule only if it is still at the given version, otherwise it returns store.ErrVersionMismatch.
+	// types.AnyVersion deletes it whatever the version.
+	Delete(c context.Context, id string, version int64) error
+	// Update writes the schedule only if it is still at the given version, otherwise it returns store.ErrVersionMismatch
+	Update(c context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error)
+	// Restore puts a deleted schedule back, with its id
+	Restore(c context.Context, schedule model.Schedule) error
 }
 
 type ActionSvc interface {
 	GetActionByID(ctx context.Context, id string) (types.Action, error)
 }
 
+// TenantSvc resolves the configuration of the tenant in the context
+type TenantSvc interface {
+	GetCurrent(c context.Context) (*types.Tenant, error)
+}
+
+// Authorizer checks the permissions of the principal in the context
+type Authorizer interface {
+	Authorize(c context.Context, op rbac.Operation, actionID string) error
+	IsAdmin(c context.Context) (bool, error)
+}
+
+// Quota reserves active schedules. every successful Acquire is paired with a Release once the schedule is gone.
+type Quota interface {
+	Acquire(c context.Context, createdBy, actionID string) error
+	Release(c context.Context, createdBy, actionID string)
+}
+
+// Auditor records schedule mutations
+type Auditor interface {
+	Record(c context.Context, action model.AuditAction, scheduleID string, before, after *model.Schedule) error
+}
+
+// Events publishes the schedule events to the webhooks subscribed to them
+type Events interface {
+	Emit(c context.Context, event types.WebhookEvent) error
+}
+
 type SchedulerService struct {
 	// repository
 	store     SchedulerStore
 	actionSvc ActionSvc
+	tenantSvc TenantSvc
+	authz     Authorizer
+	quota     Quota
 	scheduler scheduler.Scheduler
-	logger    *slog.Logger
+	auditor   Auditor
+	events    Events
+	changes   Changes
+	batch     config.Batch
 }
 
-func New(s SchedulerStore, actionSvc ActionSvc, scheduler scheduler.Scheduler) *SchedulerService {
+func New(s SchedulerStore, actionSvc ActionSvc, tenantSvc TenantSvc, authz Authorizer, quota Quota, scheduler scheduler.Scheduler, auditor Auditor, events Events, changes Changes, batch config.Batch) *SchedulerService {
 	return &SchedulerService{
 		store:     s,
 		scheduler: scheduler,
 		actionSvc: actionSvc,
-		logger:    slog.New(slog.NewTextHandler(os.Stdout, nil).WithAttrs([]slog.Attr{slog.String("service", "notification")})),
+		tenantSvc: tenantSvc,
+		authz:     authz,
+		quota:     quota,
+		auditor:   auditor,
+		events:    events,
+		changes:   changes,
+		batch:     batch,
 	}
 }
 
-func (s *SchedulerService) GetByID(c context.Context, id string) (*types.Schedule, error) {
-	s.logger.Info("getting schedule by ID", "id", id)
+func (s *SchedulerService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "schedule"))
+}
+
+func (s *SchedulerService) GetByID(c context.Context, id string) (sch *types.Schedule, err error) {
+	c, span := tracing.Start(c, "SchedulerService.GetByID", attribute.String("schedule.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	s.log(c).Info("getting schedule by ID", "id", id)
+
+	if err := s.authz.Authorize(c, rbac.OpReadSchedule, ""); err != nil {
+		return nil, err
+	}
+
 	modelS, err := s.store.GetByID(c, id)
 
 	if err != nil {
-		s.logger.Error("error getting schedule by ID", "id", id, "error", err.Error())
-		if errors.Is(store.ErrScheduleNotFound, err) {
+		s.log(c).Error("error getting schedule by ID", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrScheduleNotFound) || errors.Is(err, store.ErrInvalidID) {
 			return nil, ErrScheduleNotFound
 		}
 		return nil, fmt.Errorf("failed to get schedule with ID='%s'", id)
 	}
 
+	// schedules of other users are hidden from non admins
+	if filter, err := s.visibilityFilter(c); err != nil {
+		return nil, err
+	} else if filter.CreatedBy != "" && filter.CreatedBy != modelS.CreatedBy {
+		return nil, ErrScheduleNotFound
+	}
+
 	action, err := s.actionSvc.GetActionByID(c, modelS.ActionID)
 
 	if err != nil {
-		s.logger.Error("error finding action", "action_id", modelS.ActionID, "error", err.Error())
+		s.log(c).Error("error finding action", "action_id", modelS.ActionID, "error", err.Error())
 		return nil, err
 	}
 
 	return mapper.MapScheduleModelToType(modelS, action), nil
 }
 
-func (s *SchedulerService) GetPaginated(c context.Context, pagination *types.PaginationOps) (*types.PaginatedResult[types.Schedule], error) {
-	s.logger.Info("getting schedules", "pagination", pagination)
+func (s *SchedulerService) GetPaginated(c context.Context, pagination *types.PaginationOps) (result *types.PaginatedResult[types.Schedule], err error) {
+	c, span := tracing.Start(c, "SchedulerService.GetPaginated")
+	defer func() { tracing.End(span, err) }()
+
+	s.log(c).Info("getting schedules", "pagination", pagination)
 
 	if pagination == nil {
 		return nil, fmt.Errorf("Invalid pagination provider. got=%v", pagination)
 	}
 
-	count, models, err := s.store.Get(c, pagination)
+	if err := s.authz.Authorize(c, rbac.OpReadSchedule, ""); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.visibilityFilter(c)
 
 	if err != nil {
-		s.logger.Error("error getting schedules", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	count, models, err := s.store.Get(c, filter, pagination)
+
+	if err != nil {
+		s.log(c).Error("error getting schedules", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("error getting schedules")
 	}
 	schedules := make([]types.Schedule, 0, len(models))
 	for _, schedule := range models {
 
-		s.logger.Info("getting action for schedule", "scheduleID", schedule.ID, "actionID", schedule.ActionID)
+		s.log(c).Info("getting action for schedule", "scheduleID", schedule.ID, "actionID", schedule.ActionID)
 		action, err := s.actionSvc.GetActionByID(c, schedule.ActionID)
 
 		if err != nil {
-			s.logger.Error("failed to get Action By ID", slog.String("error", err.Error()))
+			s.log(c).Error("failed to get Action By ID", slog.String("error", err.Error()))
 			return nil, fmt.Errorf("failed to get schedules.")
 		}
 		schedules = append(schedules, *mapper.MapScheduleModelToType(&schedule, action))
 	}
-	s.logger.Info("succeeded to get schedules", "count", len(schedules))
+	s.log(c).Info("succeeded to get schedules", "count", len(schedules))
 	return &types.PaginatedResult[types.Schedule]{
 		Total: int(count),
 		Items: schedules,
@@ -108,13 +198,17 @@ func (s *SchedulerService) GetPaginated(c context.Context, pagination *types.Pag
 }
 
 func (s *SchedulerService) Create(c context.Context, schedule types.CreateScheduleInput) (sch *types.Schedule, err error) {
+	c, span := tracing.Start(c, "SchedulerService.Create", attribute.String("schedule.action", schedule.ActionID))
+	defer func() { tracing.End(span, err) }()
+
 	defer func() {
 		if r := recover(); r != nil {
-			s.logger.Error("recover from panic", "recover", r)
+			s.log(c).Error("recover from panic", "recover", r)
 			err = fmt.Errorf("unknown failure creating schedule")
 		}
 	}()
-	action, err := s.actionSvc.GetActionByID(c, schedule.ActionID)
+
+	tenant, action, err := s.checkCreate(c, &schedule)
 
 	if err != nil {
 		return nil, err
@@ -131,85 +225,342 @@ func (s *SchedulerService) Create(c context.Context, schedule types.CreateSchedu
 		ClientToken: ct.String(),
 	}
 
-	schedulerExpression, err := scheduler.NewExpression(cs.Start, cs.End, string(cs.Expression.Type))
+	// both were validated by checkCreate
+	schedulerExpression, _ := scheduler.NewExpression(cs.Start, cs.End, string(cs.Expression.Type))
+	by, _ := json.Marshal(schedule.Payload)
 
-	if err != nil {
+	if err := s.quota.Acquire(c, cs.CreatedBy, cs.ActionID); err != nil {
 		return nil, err
 	}
-	// TODO: input validation before creating schedule
-	// the payload validation should be based on the action type
-	by, err := json.Marshal(schedule.Payload)
-
-	if err != nil {
-		return nil, ErrorInvalidPayload
-	}
 	// schedules name should be unique
 	id, err := s.store.Create(c, &cs)
 	scheduleName := fmt.Sprintf("%s-%s", cs.Name, id)
 	if err != nil {
-		s.logger.Error("error creating schedule", slog.String("error", err.Error()))
+		s.log(c).Error("error creating schedule", slog.String("error", err.Error()))
+		s.quota.Release(c, cs.CreatedBy, cs.ActionID)
 
 		return nil, err
 	}
 
-	schedulerInput := scheduler.NewSchedule(scheduleName, action.Arn, action.Role, scheduler.TimeZoneETD, string(by), *schedulerExpression)
+	schedulerInput := scheduler.NewSchedule(scheduleName, tenant.ScheduleGroup, action.Arn, action.Role, tenant.TimeZone, string(by), *schedulerExpression)
 
-	_, err = s.scheduler.CreateSchedule(schedulerInput, cs.ClientToken)
+	_, err = s.scheduler.CreateSchedule(c, schedulerInput, cs.ClientToken)
 
 	if err != nil {
-		s.logger.Error("error creating eb schedule", slog.String("error", err.Error()))
-		err = s.store.Delete(c, id)
+		s.log(c).Error("error creating eb schedule", slog.String("error", err.Error()))
+		s.quota.Release(c, cs.CreatedBy, cs.ActionID)
 
-		if err != nil {
-			s.logger.Error("error deleting schedule from DB", slog.String("error", err.Error()))
+		if delErr := s.store.Delete(c, id, types.AnyVersion); delErr != nil {
+			s.log(c).Error("error deleting schedule from DB", slog.String("error", delErr.Error()))
 			/* TODO: retry. if fails again take action.*/
-			return nil, fmt.Errorf("error creating schedule. schedule may have ")
+			return nil, fmt.Errorf("error creating schedule. schedule may have been left in the DB. id=%s", id)
 		}
 		/*
 			TODO: delete schedule from scheduler
 		*/
-		return nil, err
+		return nil, schedulerError(err, "failed to create schedule in the scheduler")
 	}
 
 	if createdModel, err := s.store.GetByID(c, id); err != nil {
-		s.logger.Error("error getting schedule", slog.String("error", err.Error()))
+		s.log(c).Error("error getting schedule", slog.String("error", err.Error()))
 		return nil, err
 	} else {
+		auditErr := s.audit(c, model.AuditCreate, id, nil, createdModel)
+		sch := mapper.MapScheduleModelToType(createdModel, action)
+		s.emit(c, types.WebhookEvent{Type: types.EventScheduleCreated, Schedule: sch})
 
-		return mapper.MapScheduleModelToType(createdModel, action), nil
+		if auditErr != nil {
+			return nil, auditErr
+		}
+		return sch, nil
 	}
 }
 
-func (s *SchedulerService) Delete(c context.Context, id string) error {
-	s.logger.Info("deleting schedule", "id", id)
+// checkCreate runs every check of Create that has no side effect and stamps created_by from the principal.
+// it returns the tenant and the action the schedule is created with.
+func (s *SchedulerService) checkCreate(c context.Context, schedule *types.CreateScheduleInput) (*types.Tenant, types.Action, error) {
+	// created_by is never trusted from the client.
+	principal, ok := auth.PrincipalFromContext(c)
+	if !ok {
+		return nil, types.Action{}, ErrMissingPrincipal
+	}
+	schedule.CreatedBy = principal.Subject
+
+	if err := s.authz.Authorize(c, rbac.OpCreateSchedule, schedule.ActionID); err != nil {
+		return nil, types.Action{}, err
+	}
+
+	tenant, err := s.tenantSvc.GetCurrent(c)
+
+	if err != nil {
+		return nil, types.Action{}, err
+	}
+
+	if len(tenant.AllowedActions) > 0 && !slices.Contains(tenant.AllowedActions, schedule.ActionID) {
+		return nil, types.Action{}, fmt.Errorf("%w. action=%s", ErrActionNotAllowed, schedule.ActionID)
+	}
+
+	action, err := s.actionSvc.GetActionByID(c, schedule.ActionID)
+
+	if err != nil {
+		return nil, types.Action{}, err
+	}
+
+	expression := mapper.MapTypeExpressionToModel(schedule.Expression)
+	if _, err := scheduler.NewExpression(expression.Start, expression.End, string(expression.Type)); err != nil {
+		return nil, types.Action{}, fmt.Errorf("%w. %w", ErrInvalidExpression, err)
+	}
+
+	// TODO: the payload validation should be based on the action type
+	if _, err := json.Marshal(schedule.Payload); err != nil {
+		return nil, types.Action{}, ErrorInvalidPayload
+	}
+
+	return tenant, action, nil
+}
+
+// Update replaces the expression and payload of the schedule, in the DB and then in the scheduler.
+// version is the version the caller last read, the update fails with ErrVersionMismatch if the schedule was written since.
+func (s *SchedulerService) Update(c context.Context, id string, version int64, input types.UpdateScheduleInput) (sch *types.Schedule, err error) {
+	c, span := tracing.Start(c, "SchedulerService.Update", attribute.String("schedule.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	s.log(c).Info("updating schedule", "id", id, "version", version, "input", input)
+
 	modelS, err := s.store.GetByID(c, id)
 
 	if err != nil {
-		s.logger.Error("error getting schedule by ID", "id", id, "error", err.Error())
-		if errors.Is(store.ErrScheduleNotFound, err) {
+		s.log(c).Error("error getting schedule by ID", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrScheduleNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get schedule with ID='%s'", id)
+	}
+
+	// rescheduling is scheduling the action again
+	if err := s.authz.Authorize(c, rbac.OpUpdateSchedule, modelS.ActionID); err != nil {
+		return nil, err
+	}
+
+	if filter, err := s.visibilityFilter(c); err != nil {
+		return nil, err
+	} else if filter.CreatedBy != "" && filter.CreatedBy != modelS.CreatedBy {
+		return nil, fmt.Errorf("%w. reason: only admins can update schedules created by other users", rbac.ErrForbidden)
+	}
+
+	if err := checkVersion(modelS, version); err != nil {
+		return nil, err
+	}
+
+	expression := mapper.MapTypeExpressionToModel(input.Expression)
+	schedulerExpression, err := scheduler.NewExpression(expression.Start, expression.End, string(expression.Type))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w. %w", ErrInvalidExpression, err)
+	}
+
+	by, err := json.Marshal(input.Payload)
+
+	if err != nil {
+		return nil, ErrorInvalidPayload
+	}
+
+	tenant, err := s.tenantSvc.GetCurrent(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := s.actionSvc.GetActionByID(c, modelS.ActionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	next := *modelS
+	next.Expression = expression
+	next.Payload = input.Payload
+
+	// the DB is written first so that of two concurrent updates only one reaches the scheduler
+	updated, err := s.store.Update(c, id, modelS.Version, next)
+
+	if err != nil {
+		s.log(c).Error("error updating schedule", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrVersionMismatch) {
+			return nil, fmt.Errorf("%w. id=%s", ErrVersionMismatch, id)
+		}
+		if errors.Is(err, store.ErrScheduleNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to update schedule with ID='%s'", id)
+	}
+
+	schedulerInput := scheduler.NewSchedule(fmt.Sprintf("%s-%s", modelS.Name, modelS.ID), tenant.ScheduleGroup, action.Arn, action.Role, tenant.TimeZone, string(by), *schedulerExpression)
+
+	if _, err := s.scheduler.UpdateSchedule(c, schedulerInput); err != nil {
+		s.log(c).Error("error updating eb schedule", "id", id, "error", err.Error())
+
+		// the restore is another write, the version moves on either way
+		if _, restoreErr := s.store.Update(c, id, updated.Version, *modelS); restoreErr != nil {
+			s.log(c).Error("error restoring schedule in DB", "id", id, "error", restoreErr.Error())
+			return nil, fmt.Errorf("error updating schedule. the DB may not match the scheduler. id=%s", id)
+		}
+		return nil, schedulerError(err, "failed to update schedule in the scheduler")
+	}
+
+	auditErr := s.audit(c, model.AuditUpdate, id, modelS, updated)
+	sch = mapper.MapScheduleModelToType(updated, action)
+	s.emit(c, types.WebhookEvent{Type: types.EventScheduleUpdated, Schedule: sch})
+
+	if auditErr != nil {
+		return nil, auditErr
+	}
+	return sch, nil
+}
+
+// Delete deletes the schedule from the DB and then from the scheduler, the DB is restored if the scheduler fails.
+// version is the version the caller last read, the delete fails with ErrVersionMismatch if the schedule was written since. types.AnyVersion deletes whatever the version.
+func (s *SchedulerService) Delete(c context.Context, id string, version int64) (err error) {
+	c, span := tracing.Start(c, "SchedulerService.Delete", attribute.String("schedule.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	s.log(c).Info("deleting schedule", "id", id)
+
+	if err := s.authz.Authorize(c, rbac.OpDeleteSchedule, ""); err != nil {
+		return err
+	}
+
+	modelS, err := s.store.GetByID(c, id)
+
+	if err != nil {
+		s.log(c).Error("error getting schedule by ID", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrScheduleNotFound) || errors.Is(err, store.ErrInvalidID) {
 			return ErrScheduleNotFound
 		}
 		return fmt.Errorf("failed to get schedule with ID='%s'", id)
 	}
 
-	err = s.scheduler.DeleteSchedule(fmt.Sprintf("%s-%s", modelS.Name, modelS.ID.Hex()), modelS.ClientToken)
+	if filter, err := s.visibilityFilter(c); err != nil {
+		return err
+	} else if filter.CreatedBy != "" && filter.CreatedBy != modelS.CreatedBy {
+		return fmt.Errorf("%w. reason: only admins can delete schedules created by other users", rbac.ErrForbidden)
+	}
+
+	if err := checkVersion(modelS, version); err != nil {
+		return err
+	}
+
+	tenant, err := s.tenantSvc.GetCurrent(c)
+
+	if err != nil {
+		return err
+	}
+
+	// the DB is written first so that of two concurrent writes only one reaches the scheduler
+	if err := s.store.Delete(c, id, modelS.Version); err != nil {
+		s.log(c).Error("error deleting schedule", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrVersionMismatch) {
+			return fmt.Errorf("%w. id=%s", ErrVersionMismatch, id)
+		}
+		if errors.Is(err, store.ErrScheduleNotFound) {
+			return ErrScheduleNotFound
+		}
+		return fmt.Errorf("failed to delete schedule with ID='%s'", id)
+	}
+
+	err = s.scheduler.DeleteSchedule(c, tenant.ScheduleGroup, fmt.Sprintf("%s-%s", modelS.Name, modelS.ID), modelS.ClientToken)
 
 	if err != nil {
-		s.logger.Error("error deleting schedule", "id", id, "error", err.Error())
+		s.log(c).Error("error deleting eb schedule", "id", id, "error", err.Error())
 		if errors.Is(scheduler.ErrNotFound, err) {
-			s.logger.Error("schedule not found in scheduler", "id", id, "error", err.Error())
-			s.logger.Error("deleting schedule from DB", "id", id)
+			s.log(c).Error("schedule not found in scheduler", "id", id, "error", err.Error())
 		} else {
-			return fmt.Errorf("failed to delete schedule with ID='%s'", id)
+			// the restore is another write, the version moves on either way
+			restored := *modelS
+			restored.Version++
+			if restoreErr := s.store.Restore(c, restored); restoreErr != nil {
+				s.log(c).Error("error restoring schedule in DB", "id", id, "error", restoreErr.Error())
+				return fmt.Errorf("error deleting schedule. the DB may not match the scheduler. id=%s", id)
+			}
+			return schedulerError(err, fmt.Sprintf("failed to delete schedule with ID='%s' from the scheduler", id))
 		}
 	}
 
-	err = s.store.Delete(c, id)
+	s.quota.Release(c, modelS.CreatedBy, modelS.ActionID)
+	auditErr := s.audit(c, model.AuditDelete, id, modelS, nil)
+	s.emit(c, types.WebhookEvent{Type: types.EventScheduleDeleted, Schedule: s.withAction(c, modelS)})
+
+	return auditErr
+}
+
+// checkVersion returns ErrVersionMismatch unless the schedule is at the version or the version is types.AnyVersion
+func checkVersion(m *model.Schedule, version int64) error {
+	if version != types.AnyVersion && version != m.Version {
+		return fmt.Errorf("%w. id=%s. version=%d. got=%d", ErrVersionMismatch, m.ID, m.Version, version)
+	}
+	return nil
+}
+
+// schedulerError classifies a scheduler failure. anything but a conflict is an upstream failure.
+func schedulerError(err error, message string) error {
+	if errors.Is(err, scheduler.ErrConflict) {
+		return fmt.Errorf("%w. %w", ErrScheduleConflict, err)
+	}
+	return apperr.Upstream("scheduler_failed", message, err)
+}
+
+// visibilityFilter restricts non admins to the schedules they created
+func (s *SchedulerService) visibilityFilter(c context.Context) (model.ScheduleFilter, error) {
+	admin, err := s.authz.IsAdmin(c)
 
 	if err != nil {
-		s.logger.Error("error deleting schedule", "id", id, "error", err.Error())
-		return fmt.Errorf("failed to delete schedule with ID='%s'", id)
+		return model.ScheduleFilter{}, err
+	}
+
+	if admin {
+		return model.ScheduleFilter{}, nil
+	}
+
+	principal, ok := auth.PrincipalFromContext(c)
+
+	if !ok {
+		return model.ScheduleFilter{}, ErrMissingPrincipal
 	}
+	return model.ScheduleFilter{CreatedBy: principal.Subject}, nil
+}
 
+// audit records the mutation. the mutation already happened at this point, so the caller still emits its event
+// and then returns ErrAuditFailed, the mutation is not rolled back.
+func (s *SchedulerService) audit(c context.Context, action model.AuditAction, id string, before, after *model.Schedule) error {
+	if s.auditor == nil {
+		return nil
+	}
+
+	if err := s.auditor.Record(c, action, id, before, after); err != nil {
+		s.log(c).Error("error auditing schedule mutation", "id", id, "action", action, "error", err.Error())
+		return fmt.Errorf("%w. id=%s. action=%s. error=%w", ErrAuditFailed, id, action, err)
+	}
 	return nil
 }
+
+// emit publishes the event. the change already happened so failures are only logged.
+func (s *SchedulerService) emit(c context.Context, event types.WebhookEvent) {
+	if s.events == nil {
+		return
+	}
+
+	if err := s.events.Emit(c, event); err != nil {
+		s.log(c).Error("error emitting schedule event", "event", event.Type, "id", event.Schedule.ID, "error", err.Error())
+	}
+}
+
+// withAction maps the schedule with its action. the schedule keeps the bare action ID when the action can't be resolved.
+func (s *SchedulerService) withAction(c context.Context, m *model.Schedule) *types.Schedule {
+	action, err := s.actionSvc.GetActionByID(c, m.ActionID)
+
+	if err != nil {
+		s.log(c).Warn("error finding action", "action_id", m.ActionID, "error", err.Error())
+		action = types.Action{Id: m.ActionID}
+	}
+	return mapper.MapScheduleModelToType(m, action)
+}
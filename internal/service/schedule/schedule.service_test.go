@@ -0,0 +1,346 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/store/storetest"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+	"github.com/japb1998/action-scheduler/pkg/scheduler/schedulertest"
+)
+
+type fakeActions struct{}
+
+func (fakeActions) GetActionByID(ctx context.Context, id string) (types.Action, error) {
+	return types.Action{Id: id, Name: id, Arn: "arn:aws:lambda:us-east-1:000000000000:function:" + id, Role: "arn:aws:iam::000000000000:role/scheduler"}, nil
+}
+
+type fakeTenants struct{}
+
+func (fakeTenants) GetCurrent(c context.Context) (*types.Tenant, error) {
+	return &types.Tenant{ID: "acme", TimeZone: "UTC", ScheduleGroup: "acme"}, nil
+}
+
+// allowAll authorizes everything, as an admin
+type allowAll struct{}
+
+func (allowAll) Authorize(c context.Context, op rbac.Operation, actionID string) error { return nil }
+func (allowAll) IsAdmin(c context.Context) (bool, error)                               { return true, nil }
+
+// countingQuota tracks the schedules reserved and not released
+type countingQuota struct{ active int }
+
+func (q *countingQuota) Acquire(c context.Context, createdBy, actionID string) error {
+	q.active++
+	return nil
+}
+
+func (q *countingQuota) Release(c context.Context, createdBy, actionID string) { q.active-- }
+
+// failingDeletes fails the deletes of the store it wraps
+type failingDeletes struct {
+	*storetest.MemoryScheduleStore
+}
+
+func (failingDeletes) Delete(ctx context.Context, id string, version int64) error {
+	return errors.New("connection reset")
+}
+
+// staleReads returns the schedules as they were before the last write, as if it raced with the read
+type staleReads struct {
+	*storetest.MemoryScheduleStore
+}
+
+func (s staleReads) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
+	sch, err := s.MemoryScheduleStore.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sch.Version--
+	return sch, nil
+}
+
+// failingAudit fails every audit write
+type failingAudit struct{}
+
+func (failingAudit) Record(c context.Context, action model.AuditAction, scheduleID string, before, after *model.Schedule) error {
+	return errors.New("connection reset")
+}
+
+type fixture struct {
+	svc       *SchedulerService
+	store     *storetest.MemoryScheduleStore
+	scheduler *schedulertest.Fake
+	quota     *countingQuota
+	ctx       context.Context
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		store:     storetest.NewMemoryScheduleStore(),
+		scheduler: schedulertest.New(),
+		quota:     &countingQuota{},
+		ctx:       auth.WithPrincipal(requestctx.WithTenant(context.Background(), "acme"), auth.Principal{Subject: "u1"}),
+	}
+	if err := f.scheduler.CreateScheduleGroup(f.ctx, "acme", ""); err != nil {
+		t.Fatalf("error creating group: %v", err)
+	}
+	f.svc = New(f.store, fakeActions{}, fakeTenants{}, allowAll{}, f.quota, f.scheduler, nil, nil, nil, config.Batch{MaxItems: 10, Concurrency: 1})
+	return f
+}
+
+func input() types.CreateScheduleInput {
+	return types.CreateScheduleInput{
+		Name:       "digest",
+		ActionID:   "email",
+		Expression: types.Expression{Type: types.DAILY, Start: types.ScheduleDate(time.Now().Add(time.Hour))},
+		Payload:    map[string]any{"to": "a@b.c"},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	f := newFixture(t)
+
+	sch, err := f.svc.Create(f.ctx, input())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sch.CreatedBy != "u1" || sch.TenantID != "acme" {
+		t.Errorf("expected a schedule of u1 in acme. got=%+v", sch)
+	}
+	scheduled := f.scheduler.Schedule("acme", "digest-"+sch.ID)
+	if scheduled == nil {
+		t.Fatalf("expected the schedule in the scheduler. got=%+v", f.scheduler.Calls())
+	}
+	if scheduled.Payload() != `{"to":"a@b.c"}` || scheduled.TimeZone() != "UTC" {
+		t.Errorf("unexpected scheduled payload %s in %s", scheduled.Payload(), scheduled.TimeZone())
+	}
+	if calls := f.scheduler.CallsOf(schedulertest.OpCreateSchedule); calls[0].Token != sch.ClientToken {
+		t.Errorf("expected the client token %s. got=%s", sch.ClientToken, calls[0].Token)
+	}
+	if f.quota.active != 1 {
+		t.Errorf("expected 1 reserved schedule. got=%d", f.quota.active)
+	}
+}
+
+func TestCreateCompensates(t *testing.T) {
+	tests := []struct {
+		name          string
+		schedulerErr  error
+		failDeletes   bool
+		wantCode      string
+		wantMessage   string
+		wantRowsAfter int
+	}{
+		{name: "scheduler failure", schedulerErr: errors.New("throttled"), wantCode: "scheduler_failed"},
+		{name: "scheduler conflict", schedulerErr: scheduler.ErrConflict, wantCode: "schedule_conflict"},
+		{name: "store delete failure", schedulerErr: errors.New("throttled"), failDeletes: true, wantMessage: "schedule may have been left in the DB", wantRowsAfter: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.failDeletes {
+				f.svc.store = failingDeletes{f.store}
+			}
+			f.scheduler.Fail(schedulertest.OpCreateSchedule, tt.schedulerErr)
+
+			_, err := f.svc.Create(f.ctx, input())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.wantCode != "" && apperr.From(err).Code != tt.wantCode {
+				t.Errorf("expected code %s. got=%v", tt.wantCode, err)
+			}
+			if tt.wantMessage != "" && !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("expected %q. got=%v", tt.wantMessage, err)
+			}
+
+			if f.store.Len() != tt.wantRowsAfter {
+				t.Errorf("expected %d stored schedules after the failure. got=%d", tt.wantRowsAfter, f.store.Len())
+			}
+			if f.scheduler.Len() != 0 {
+				t.Errorf("expected nothing in the scheduler. got=%d", f.scheduler.Len())
+			}
+			if f.quota.active != 0 {
+				t.Errorf("expected the quota released. got=%d reserved", f.quota.active)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name          string
+		missing       bool
+		schedulerErr  error
+		version       int64
+		wantErr       bool
+		wantRowsAfter int
+		wantActive    int
+	}{
+		{name: "deleted", version: 1},
+		{name: "any version", version: types.AnyVersion},
+		{name: "missing from the scheduler", missing: true, version: 1},
+		{name: "scheduler failure", schedulerErr: errors.New("throttled"), version: 1, wantErr: true, wantRowsAfter: 1, wantActive: 1},
+		{name: "stale version", version: 2, wantErr: true, wantRowsAfter: 1, wantActive: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			sch, err := f.svc.Create(f.ctx, input())
+			if err != nil {
+				t.Fatalf("error creating: %v", err)
+			}
+			if tt.missing {
+				_ = f.scheduler.DeleteSchedule(f.ctx, "acme", "digest-"+sch.ID, "")
+			}
+			f.scheduler.Fail(schedulertest.OpDeleteSchedule, tt.schedulerErr)
+
+			err = f.svc.Delete(f.ctx, sch.ID, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v. got=%v", tt.wantErr, err)
+			}
+			if f.store.Len() != tt.wantRowsAfter {
+				t.Errorf("expected %d stored schedules. got=%d", tt.wantRowsAfter, f.store.Len())
+			}
+			if f.quota.active != tt.wantActive {
+				t.Errorf("expected %d reserved schedules. got=%d", tt.wantActive, f.quota.active)
+			}
+		})
+	}
+}
+
+// a write between the read and the delete stops the delete before the scheduler
+func TestDeleteConcurrentWrite(t *testing.T) {
+	f := newFixture(t)
+	sch, err := f.svc.Create(f.ctx, input())
+	if err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	f.svc.store = staleReads{f.store}
+
+	if err := f.svc.Delete(f.ctx, sch.ID, types.AnyVersion); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected %v. got=%v", ErrVersionMismatch, err)
+	}
+	if calls := f.scheduler.CallsOf(schedulertest.OpDeleteSchedule); len(calls) != 0 {
+		t.Errorf("expected the scheduler untouched. got=%d calls", len(calls))
+	}
+	if f.store.Len() != 1 || f.quota.active != 1 {
+		t.Errorf("expected the schedule kept. got=%d stored %d reserved", f.store.Len(), f.quota.active)
+	}
+}
+
+// a failed audit write is reported but the schedule stays written
+func TestAuditFailure(t *testing.T) {
+	f := newFixture(t)
+	f.svc.auditor = failingAudit{}
+
+	if _, err := f.svc.Create(f.ctx, input()); !errors.Is(err, ErrAuditFailed) {
+		t.Fatalf("expected %v. got=%v", ErrAuditFailed, err)
+	}
+	if f.store.Len() != 1 || f.scheduler.Len() != 1 {
+		t.Errorf("expected the schedule kept. got=%d stored %d scheduled", f.store.Len(), f.scheduler.Len())
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	weekly := types.UpdateScheduleInput{
+		Expression: types.Expression{Type: types.WEEKLY, Start: types.ScheduleDate(time.Now().Add(2 * time.Hour))},
+		Payload:    map[string]any{"to": "c@d.e"},
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		f := newFixture(t)
+		sch, err := f.svc.Create(f.ctx, input())
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+
+		updated, err := f.svc.Update(f.ctx, sch.ID, sch.Version, weekly)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if updated.Version != sch.Version+1 || updated.Expression.Type != types.WEEKLY || updated.Name != sch.Name {
+			t.Errorf("expected a weekly %s at version %d. got=%+v", sch.Name, sch.Version+1, updated)
+		}
+		if got := f.scheduler.Schedule("acme", "digest-"+sch.ID); got == nil || got.Payload() != `{"to":"c@d.e"}` || got.Expression().Type != scheduler.Weekly {
+			t.Errorf("expected the scheduler updated. got=%+v", got)
+		}
+
+		// the version read before the first update is stale now
+		if _, err := f.svc.Update(f.ctx, sch.ID, sch.Version, weekly); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("expected %v. got=%v", ErrVersionMismatch, err)
+		}
+		if calls := f.scheduler.CallsOf(schedulertest.OpUpdateSchedule); len(calls) != 1 {
+			t.Errorf("expected the stale update to stop before the scheduler. got=%d calls", len(calls))
+		}
+	})
+
+	t.Run("scheduler failure", func(t *testing.T) {
+		f := newFixture(t)
+		sch, err := f.svc.Create(f.ctx, input())
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+		f.scheduler.Fail(schedulertest.OpUpdateSchedule, errors.New("throttled"))
+
+		if _, err := f.svc.Update(f.ctx, sch.ID, sch.Version, weekly); apperr.From(err).Code != "scheduler_failed" {
+			t.Fatalf("expected scheduler_failed. got=%v", err)
+		}
+
+		// the schedule is restored, at a new version
+		got, err := f.svc.GetByID(f.ctx, sch.ID)
+		if err != nil {
+			t.Fatalf("error getting: %v", err)
+		}
+		if got.Expression.Type != types.DAILY || got.Payload["to"] != "a@b.c" || got.Version != sch.Version+2 {
+			t.Errorf("expected the daily schedule restored at version %d. got=%+v", sch.Version+2, got)
+		}
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		f := newFixture(t)
+		sch, err := f.svc.Create(f.ctx, input())
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+
+		invalid := types.UpdateScheduleInput{Expression: types.Expression{Type: types.ONE}}
+		if _, err := f.svc.Update(f.ctx, sch.ID, types.AnyVersion, invalid); !errors.Is(err, ErrInvalidExpression) {
+			t.Errorf("expected %v. got=%v", ErrInvalidExpression, err)
+		}
+	})
+}
+
+// malformed ids are missing schedules, not internal errors
+func TestMalformedID(t *testing.T) {
+	f := newFixture(t)
+
+	if _, err := f.svc.GetByID(f.ctx, "nope"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound from GetByID. got=%v", err)
+	}
+	if _, err := f.svc.Update(f.ctx, "nope", types.AnyVersion, types.UpdateScheduleInput{Expression: input().Expression}); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound from Update. got=%v", err)
+	}
+	if err := f.svc.Delete(f.ctx, "nope", types.AnyVersion); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound from Delete. got=%v", err)
+	}
+	if err := f.svc.ReportRun(f.ctx, "nope", types.ScheduleRunInput{Status: "succeeded"}); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected ErrScheduleNotFound from ReportRun. got=%v", err)
+	}
+}
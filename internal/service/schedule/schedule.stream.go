@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/tracing"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Changes streams the writes to the schedules of the tenant in the context, see store.MongoScheduleStore.Watch
+type Changes interface {
+	Watch(c context.Context, filter model.ScheduleFilter, after string) (store.ChangeStream, error)
+}
+
+// Watch streams the changes of the schedules visible to the principal after the resume token. "" starts from now.
+// when the changes after the token are lost the stream starts from now with a reset event.
+func (s *SchedulerService) Watch(c context.Context, after string) (events types.ScheduleEvents, err error) {
+	c, span := tracing.Start(c, "SchedulerService.Watch", attribute.Bool("schedule.resumed", after != ""))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.authz.Authorize(c, rbac.OpReadSchedule, ""); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.visibilityFilter(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w := &scheduleEvents{svc: s, filter: filter}
+	w.changes, err = s.changes.Watch(c, filter, after)
+
+	if errors.Is(err, store.ErrChangesLost) {
+		s.log(c).Warn("schedule changes lost, watching from now", "after", after)
+		w.reset = true
+		w.changes, err = s.changes.Watch(c, filter, "")
+	}
+
+	if err != nil {
+		s.log(c).Error("error watching schedules", "error", err.Error())
+		return nil, err
+	}
+	return w, nil
+}
+
+type scheduleEvents struct {
+	svc     *SchedulerService
+	filter  model.ScheduleFilter
+	changes store.ChangeStream
+	// reset is set when the next event is a reset
+	reset bool
+}
+
+func (w *scheduleEvents) Next(ctx context.Context) (*types.ScheduleEvent, error) {
+	if w.reset {
+		w.reset = false
+		return &types.ScheduleEvent{Type: types.ScheduleEventReset}, nil
+	}
+
+	if w.changes == nil {
+		return nil, store.ErrChangesLost
+	}
+
+	change, err := w.changes.Next(ctx)
+
+	// the watcher fell behind, start over from now
+	if errors.Is(err, store.ErrChangesLost) {
+		w.svc.log(ctx).Warn("schedule changes lost, watching from now")
+		_ = w.changes.Close()
+
+		changes, err := w.svc.changes.Watch(ctx, w.filter, "")
+		if err != nil {
+			w.changes = nil
+			return nil, err
+		}
+		w.changes = changes
+		return &types.ScheduleEvent{Type: types.ScheduleEventReset}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &types.ScheduleEvent{ID: change.Token, Type: string(change.Type), Schedule: w.svc.withAction(ctx, &change.Schedule)}, nil
+}
+
+func (w *scheduleEvents) Close() error {
+	if w.changes == nil {
+		return nil
+	}
+	return w.changes.Close()
+}
@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/auth"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// member authorizes everything, as a non admin
+type member struct{ allowAll }
+
+func (member) IsAdmin(c context.Context) (bool, error) { return false, nil }
+
+func TestWatch(t *testing.T) {
+	f := newFixture(t)
+	changes := store.NewBroadcastScheduleStore(f.store)
+	f.svc = New(changes, fakeActions{}, fakeTenants{}, member{}, f.quota, f.scheduler, nil, nil, changes, config.Batch{MaxItems: 10, Concurrency: 1})
+
+	next := func(t *testing.T, events types.ScheduleEvents) *types.ScheduleEvent {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(f.ctx, time.Second)
+		defer cancel()
+		event, err := events.Next(ctx)
+		if err != nil {
+			t.Fatalf("error getting the next event: %v", err)
+		}
+		return event
+	}
+
+	events, err := f.svc.Watch(f.ctx, "")
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	defer events.Close()
+
+	// the schedules of other users are not streamed to non admins
+	other := auth.WithPrincipal(f.ctx, auth.Principal{Subject: "u2"})
+	if _, err := f.svc.Create(other, input()); err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	sch, err := f.svc.Create(f.ctx, input())
+	if err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	if err := f.svc.Delete(f.ctx, sch.ID, sch.Version); err != nil {
+		t.Fatalf("error deleting: %v", err)
+	}
+
+	inserted := next(t, events)
+	if inserted.Type != types.ScheduleEventInsert || inserted.Schedule.ID != sch.ID || inserted.Schedule.Action.Id != "email" {
+		t.Errorf("expected the insert of %s with its action. got=%+v", sch.ID, inserted)
+	}
+	if deleted := next(t, events); deleted.Type != types.ScheduleEventDelete || deleted.Schedule.ID != sch.ID {
+		t.Errorf("expected the delete of %s. got=%+v", sch.ID, deleted)
+	}
+
+	// resuming after the insert replays the delete
+	resumed, err := f.svc.Watch(f.ctx, inserted.ID)
+	if err != nil {
+		t.Fatalf("error resuming: %v", err)
+	}
+	defer resumed.Close()
+	if event := next(t, resumed); event.Type != types.ScheduleEventDelete {
+		t.Errorf("expected the delete after the insert. got=%+v", event)
+	}
+
+	// an unknown token starts from now with a reset
+	reset, err := f.svc.Watch(f.ctx, "stale")
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	defer reset.Close()
+	if event := next(t, reset); event.Type != types.ScheduleEventReset || event.ID != "" {
+		t.Errorf("expected a reset without id. got=%+v", event)
+	}
+}
@@ -0,0 +1,181 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/service/rbac"
+	"github.com/japb1998/action-scheduler/internal/tracing"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportPageSize is how many schedules are read from the store at once while exporting
+const exportPageSize = 100
+
+var (
+	ErrScheduleExists = apperr.Conflict("schedule_exists", "a schedule with this name and action already exists")
+	ErrDuplicateRow   = apperr.Conflict("duplicate_row", "an earlier row has the same name and action")
+)
+
+// Export calls fn with every schedule matching the filter, page by page, until fn fails
+func (s *SchedulerService) Export(c context.Context, f types.ScheduleFilterInput, fn func(*types.Schedule) error) (err error) {
+	c, span := tracing.Start(c, "SchedulerService.Export")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.authz.Authorize(c, rbac.OpReadSchedule, ""); err != nil {
+		return err
+	}
+
+	filter, err := s.scheduleFilter(c, f)
+
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	for page := 0; ; page++ {
+		_, models, err := s.store.Get(c, filter, &types.PaginationOps{Page: page, Limit: exportPageSize})
+
+		if err != nil {
+			s.log(c).Error("error getting schedules to export", "page", page, "error", err.Error())
+			return fmt.Errorf("error getting schedules")
+		}
+
+		for i := range models {
+			action, err := s.actionSvc.GetActionByID(c, models[i].ActionID)
+
+			if err != nil {
+				s.log(c).Error("error finding action", "action_id", models[i].ActionID, "error", err.Error())
+				return err
+			}
+
+			if err := fn(mapper.MapScheduleModelToType(&models[i], action)); err != nil {
+				return err
+			}
+		}
+		exported += len(models)
+
+		if len(models) < exportPageSize {
+			s.log(c).Info("exported schedules", "count", exported)
+			return nil
+		}
+	}
+}
+
+// Import checks every row like Create does and creates the valid ones through Create, unless dryRun is set.
+// rows with the name and action of an existing schedule, or of an earlier row, are skipped.
+func (s *SchedulerService) Import(c context.Context, rows []types.ImportRow, dryRun bool) (result *types.ImportResult, err error) {
+	c, span := tracing.Start(c, "SchedulerService.Import", attribute.Int("import.rows", len(rows)), attribute.Bool("import.dry_run", dryRun))
+	defer func() { tracing.End(span, err) }()
+
+	if len(rows) > s.batch.MaxImportRows {
+		return nil, fmt.Errorf("%w. max=%d. got=%d", ErrBatchTooLarge, s.batch.MaxImportRows, len(rows))
+	}
+
+	visibility, err := s.visibilityFilter(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(c).Info("importing schedules", "rows", len(rows), "dry_run", dryRun)
+
+	results := make([]types.ImportRowResult, len(rows))
+
+	// duplicates are found before the rows are processed concurrently
+	seen := map[[2]string]bool{}
+	for i, row := range rows {
+		results[i] = types.ImportRowResult{Row: row.Row, Name: row.Input.Name}
+		key := [2]string{row.Input.Name, row.Input.ActionID}
+
+		if row.Err != nil {
+			continue
+		}
+		if seen[key] {
+			results[i].Status = types.ImportSkipped
+			results[i].Error = batchError(ErrDuplicateRow)
+		}
+		seen[key] = true
+	}
+
+	s.forEach(c, len(rows), func(c context.Context, i int) {
+		row := rows[i]
+		res := &results[i]
+
+		if res.Status != "" {
+			return
+		}
+
+		if row.Err != nil {
+			res.Status = types.ImportInvalid
+			res.Error = batchError(rowError(row.Err))
+			return
+		}
+
+		filter := visibility
+		filter.Name = row.Input.Name
+		filter.ActionID = row.Input.ActionID
+
+		count, _, err := s.store.Get(c, filter, &types.PaginationOps{Limit: 1})
+		if err != nil {
+			s.log(c).Error("error looking up existing schedule", "row", row.Row, "error", err.Error())
+			res.Status = types.BatchFailed
+			res.Error = batchError(fmt.Errorf("error looking up existing schedules"))
+			return
+		}
+		if count > 0 {
+			res.Status = types.ImportSkipped
+			res.Error = batchError(ErrScheduleExists)
+			return
+		}
+
+		input := row.Input
+		if _, _, err := s.checkCreate(c, &input); err != nil {
+			res.Status = types.ImportInvalid
+			res.Error = batchError(err)
+			return
+		}
+
+		if dryRun {
+			res.Status = types.ImportWouldCreate
+			return
+		}
+
+		sch, err := s.Create(c, row.Input)
+		if err != nil {
+			res.Status = types.BatchFailed
+			res.Error = batchError(err)
+			return
+		}
+		res.Status = types.BatchCreated
+		res.ID = sch.ID
+	})
+
+	result = &types.ImportResult{DryRun: dryRun, Rows: results}
+	for i := range results {
+		switch results[i].Status {
+		case types.BatchCreated, types.ImportWouldCreate:
+			result.Created++
+		case types.ImportSkipped:
+			result.Skipped++
+		case "":
+			results[i].Status = types.BatchFailed
+			results[i].Error = &types.ErrorResponse{Code: "cancelled", Message: "request cancelled before the row was processed"}
+			result.Failed++
+		default:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// rowError makes the read error of a row a validation error, unless it is already typed
+func rowError(err error) error {
+	if e := apperr.From(err); e.Kind != apperr.KindInternal {
+		return err
+	}
+	return apperr.Validation("invalid_row", err.Error(), nil)
+}
@@ -0,0 +1,221 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+var (
+	ErrTenantNotFound  = apperr.NotFound("tenant_not_found", "tenant not found")
+	ErrInvalidTenantID = apperr.Validation("invalid_tenant_id", "invalid tenant id", nil)
+	ErrInvalidTimeZone = apperr.Validation("invalid_time_zone", "invalid time zone", nil)
+)
+
+// tenant IDs become part of the EventBridge schedule group name. [0-9a-zA-Z-_.]{1,64} minus the "tenant-" prefix.
+var tenantIDRegexp = regexp.MustCompile(`^[0-9a-zA-Z_.-]{1,57}$`)
+
+type TenantStore interface {
+	GetByID(ctx context.Context, id string) (*model.Tenant, error)
+	Upsert(ctx context.Context, tenant *model.Tenant) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ScheduleStore is used to tear down every schedule of a tenant.
+type ScheduleStore interface {
+	DeleteAll(ctx context.Context) (int64, error)
+}
+
+// Quota resets the quota counters of a tenant, its active schedules are gone once it is torn down.
+type Quota interface {
+	Reset(c context.Context) error
+}
+
+// APIKeyStore is used to revoke every API key of a tenant, so they stop authenticating once it is torn down.
+type APIKeyStore interface {
+	RevokeAll(ctx context.Context, at time.Time) (int64, error)
+}
+
+// TenantData is a store of tenant scoped data torn down with the tenant: roles, webhooks and deliveries.
+type TenantData interface {
+	DeleteAll(ctx context.Context) (int64, error)
+}
+
+// Auditor records the teardown
+type Auditor interface {
+	Record(c context.Context, action model.AuditAction, scheduleID string, before, after *model.Schedule) error
+}
+
+type TenantService struct {
+	store     TenantStore
+	schedules ScheduleStore
+	scheduler scheduler.Scheduler
+	quota     Quota
+	apiKeys   APIKeyStore
+	roles     TenantData
+	// webhooks are deleted before their deliveries so no new delivery is enqueued in between
+	webhooks   TenantData
+	deliveries TenantData
+	auditor    Auditor
+}
+
+func New(s TenantStore, schedules ScheduleStore, scheduler scheduler.Scheduler, quota Quota, apiKeys APIKeyStore, roles, webhooks, deliveries TenantData, auditor Auditor) *TenantService {
+	return &TenantService{
+		store:      s,
+		schedules:  schedules,
+		scheduler:  scheduler,
+		quota:      quota,
+		apiKeys:    apiKeys,
+		roles:      roles,
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		auditor:    auditor,
+	}
+}
+
+func (s *TenantService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "tenant"))
+}
+
+// GroupName returns the EventBridge schedule group of the tenant
+func GroupName(tenantID string) string {
+	return fmt.Sprintf("tenant-%s", tenantID)
+}
+
+func currentTenantID(c context.Context) (string, error) {
+	id, ok := requestctx.Tenant(c)
+
+	if !ok || !tenantIDRegexp.MatchString(id) {
+		return "", fmt.Errorf("%w. got=%s", ErrInvalidTenantID, id)
+	}
+	return id, nil
+}
+
+// GetCurrent returns the configuration of the tenant in the context
+func (s *TenantService) GetCurrent(c context.Context) (*types.Tenant, error) {
+	id, err := currentTenantID(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.store.GetByID(c, id)
+
+	if err != nil {
+		s.log(c).Error("error getting tenant", "id", id, "error", err.Error())
+		if errors.Is(err, store.ErrTenantNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant with ID='%s'", id)
+	}
+
+	return mapper.MapTenantModelToType(t, GroupName(t.ID)), nil
+}
+
+// Upsert configures the tenant in the context, creating its schedule group if needed.
+func (s *TenantService) Upsert(c context.Context, input types.UpsertTenantInput) (*types.Tenant, error) {
+	id, err := currentTenantID(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := time.LoadLocation(input.TimeZone); err != nil {
+		return nil, fmt.Errorf("%w. got=%s", ErrInvalidTimeZone, input.TimeZone)
+	}
+
+	group := GroupName(id)
+	if err := s.scheduler.CreateScheduleGroup(c, group, uuid.NewString()); err != nil {
+		s.log(c).Error("error creating schedule group", "id", id, "group", group, "error", err.Error())
+		return nil, apperr.Upstream("scheduler_failed", fmt.Sprintf("failed to create schedule group for tenant with ID='%s'", id), err)
+	}
+
+	t := &model.Tenant{
+		ID:             id,
+		Name:           input.Name,
+		TimeZone:       input.TimeZone,
+		AllowedActions: input.AllowedActions,
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err := s.store.Upsert(c, t); err != nil {
+		return nil, fmt.Errorf("failed to save tenant with ID='%s'", id)
+	}
+
+	return s.GetCurrent(c)
+}
+
+// Delete tears down the tenant in the context: its schedule group (and every EventBridge schedule in it), its schedules,
+// its quota counters, its API keys (revoked, not deleted), roles, webhooks and deliveries and its configuration. the configuration goes last so a failed teardown can be retried.
+func (s *TenantService) Delete(c context.Context) error {
+	id, err := currentTenantID(c)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.GetByID(c, id); err != nil {
+		if errors.Is(err, store.ErrTenantNotFound) {
+			return ErrTenantNotFound
+		}
+		return fmt.Errorf("failed to get tenant with ID='%s'", id)
+	}
+
+	if err := s.scheduler.DeleteScheduleGroup(c, GroupName(id), uuid.NewString()); err != nil && !errors.Is(err, scheduler.ErrNotFound) {
+		s.log(c).Error("error deleting schedule group", "id", id, "error", err.Error())
+		return apperr.Upstream("scheduler_failed", fmt.Sprintf("failed to delete schedule group for tenant with ID='%s'", id), err)
+	}
+
+	count, err := s.schedules.DeleteAll(c)
+
+	if err != nil {
+		return fmt.Errorf("failed to delete schedules for tenant with ID='%s'", id)
+	}
+	s.log(c).Info("deleted tenant schedules", "id", id, "count", count)
+
+	// a tenant created again with the same ID starts with no schedule counted
+	if err := s.quota.Reset(c); err != nil {
+		return err
+	}
+
+	if count, err = s.apiKeys.RevokeAll(c, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to revoke api keys for tenant with ID='%s'", id)
+	}
+	s.log(c).Info("revoked tenant api keys", "id", id, "count", count)
+
+	for _, data := range []struct {
+		name  string
+		store TenantData
+	}{{"roles", s.roles}, {"webhooks", s.webhooks}, {"deliveries", s.deliveries}} {
+		count, err := data.store.DeleteAll(c)
+
+		if err != nil {
+			return fmt.Errorf("failed to delete %s for tenant with ID='%s'", data.name, id)
+		}
+		s.log(c).Info("deleted tenant "+data.name, "id", id, "count", count)
+	}
+
+	if err := s.store.Delete(c, id); err != nil && !errors.Is(err, store.ErrTenantNotFound) {
+		return fmt.Errorf("failed to delete tenant with ID='%s'", id)
+	}
+
+	// the tenant is gone at this point so failures are only logged
+	if err := s.auditor.Record(c, model.AuditDeleteTenant, "", nil, nil); err != nil {
+		s.log(c).Error("error auditing tenant teardown", "id", id, "error", err.Error())
+	}
+
+	return nil
+}
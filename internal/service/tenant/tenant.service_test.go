@@ -0,0 +1,94 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/store/storetest"
+	"github.com/japb1998/action-scheduler/pkg/scheduler/schedulertest"
+)
+
+type fakeTenants map[string]*model.Tenant
+
+func (f fakeTenants) GetByID(ctx context.Context, id string) (*model.Tenant, error) {
+	if t, ok := f[id]; ok {
+		return t, nil
+	}
+	return nil, store.ErrTenantNotFound
+}
+
+func (f fakeTenants) Upsert(ctx context.Context, tenant *model.Tenant) error {
+	f[tenant.ID] = tenant
+	return nil
+}
+
+func (f fakeTenants) Delete(ctx context.Context, id string) error {
+	delete(f, id)
+	return nil
+}
+
+// fakeData counts the tenant scoped records left
+type fakeData struct{ left int64 }
+
+func (f *fakeData) DeleteAll(ctx context.Context) (int64, error) {
+	n := f.left
+	f.left = 0
+	return n, nil
+}
+
+func (f *fakeData) RevokeAll(ctx context.Context, at time.Time) (int64, error) {
+	return f.DeleteAll(ctx)
+}
+
+type fakeQuota struct{ reset bool }
+
+func (f *fakeQuota) Reset(c context.Context) error {
+	f.reset = true
+	return nil
+}
+
+type fakeAuditor struct{ actions []model.AuditAction }
+
+func (f *fakeAuditor) Record(c context.Context, action model.AuditAction, scheduleID string, before, after *model.Schedule) error {
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+func TestDelete(t *testing.T) {
+	ctx := requestctx.WithTenant(context.Background(), "acme")
+	tenants := fakeTenants{"acme": {ID: "acme", TimeZone: "UTC"}}
+	schedules := storetest.NewMemoryScheduleStore()
+	if _, err := schedules.Create(ctx, &model.CreateScheduleInput{Name: "digest"}); err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	keys, roles, webhooks, deliveries := &fakeData{2}, &fakeData{1}, &fakeData{1}, &fakeData{3}
+	auditor, quota := &fakeAuditor{}, &fakeQuota{}
+	svc := New(tenants, schedules, schedulertest.New(), quota, keys, roles, webhooks, deliveries, auditor)
+
+	if err := svc.Delete(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := tenants["acme"]; ok || schedules.Len() != 0 {
+		t.Errorf("expected the tenant and its schedules deleted. got=%v %d", tenants, schedules.Len())
+	}
+	for name, data := range map[string]*fakeData{"api keys": keys, "roles": roles, "webhooks": webhooks, "deliveries": deliveries} {
+		if data.left != 0 {
+			t.Errorf("expected the %s torn down. got=%d left", name, data.left)
+		}
+	}
+	if !quota.reset {
+		t.Errorf("expected the quota counters reset")
+	}
+	if len(auditor.actions) != 1 || auditor.actions[0] != model.AuditDeleteTenant {
+		t.Errorf("expected the teardown audited. got=%v", auditor.actions)
+	}
+
+	if err := svc.Delete(ctx); err != ErrTenantNotFound {
+		t.Errorf("expected ErrTenantNotFound once deleted. got=%v", err)
+	}
+}
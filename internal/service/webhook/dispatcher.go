@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// headers of a delivery
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader is sha256=<hex>, see Sign
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// maxResponseBytes bounds what is read of a subscriber response
+const maxResponseBytes = 64 << 10
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the webhook secret.
+// subscribers recompute it and reject old timestamps, so a captured delivery can't be replayed to them later.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher sends the queued deliveries of every tenant. failed attempts are retried with an exponential backoff until MaxAttempts.
+type Dispatcher struct {
+	webhooks   WebhookStore
+	deliveries DeliveryStore
+	cfg        config.Webhooks
+	client     *http.Client
+	now        func() time.Time
+}
+
+func NewDispatcher(webhooks WebhookStore, deliveries DeliveryStore, cfg config.Webhooks) *Dispatcher {
+	return &Dispatcher{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		cfg:        cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport(cfg.AllowPrivateURLs),
+			// a redirect is a failed attempt, the subscriber registers the final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+func (d *Dispatcher) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "webhook_dispatcher"))
+}
+
+// Run runs the workers until ctx is done and returns once their attempts in flight are recorded
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	d.log(ctx).Info("dispatching webhook deliveries", "workers", d.cfg.Workers)
+
+	for i := 0; i < d.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		if d.DispatchOne(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(d.cfg.PollInterval):
+		}
+	}
+}
+
+// DispatchOne sends the delivery due the longest. it reports whether there was one.
+func (d *Dispatcher) DispatchOne(ctx context.Context) bool {
+	// the lease outlives the attempt, so no other worker claims it meanwhile
+	delivery, err := d.deliveries.Claim(ctx, d.now().UTC(), 2*d.cfg.Timeout)
+
+	if err != nil {
+		if !errors.Is(err, store.ErrNoDelivery) && ctx.Err() == nil {
+			d.log(ctx).Error("error claiming delivery", "error", err.Error())
+		}
+		return false
+	}
+
+	// the attempt in flight is finished and recorded on shutdown
+	c := requestctx.WithTenant(context.WithoutCancel(ctx), delivery.TenantID)
+	c = logging.With(c, slog.String("tenant_id", delivery.TenantID), slog.String("delivery_id", delivery.ID), slog.String("webhook_id", delivery.WebhookID))
+
+	attempt := d.attempt(c, delivery)
+
+	if err := d.deliveries.Complete(c, delivery.ID, attempt); err != nil {
+		d.log(c).Error("error recording delivery attempt", "error", err.Error())
+	}
+	return true
+}
+
+// attempt sends the delivery once and returns its outcome
+func (d *Dispatcher) attempt(c context.Context, delivery *model.WebhookDelivery) model.DeliveryAttempt {
+	now := d.now().UTC()
+	result := model.DeliveryAttempt{At: now}
+
+	webhook, err := d.webhooks.Lookup(c, delivery.WebhookID)
+
+	if err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			// nothing to retry, the subscription is gone
+			result.Status = types.DeliveryFailed
+			result.Error = "webhook deleted"
+			return result
+		}
+		result.Error = fmt.Sprintf("error getting webhook. error=%s", err.Error())
+		return d.retry(c, delivery, result)
+	}
+
+	// webhooks registered before https was required
+	if !d.cfg.AllowPrivateURLs && !strings.HasPrefix(webhook.URL, "https://") {
+		result.Status = types.DeliveryFailed
+		result.Error = "webhook url is not https"
+		return result
+	}
+
+	body := []byte(delivery.Body)
+	req, err := http.NewRequestWithContext(c, http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		result.Status = types.DeliveryFailed
+		result.Error = fmt.Sprintf("invalid webhook url. error=%s", err.Error())
+		return result
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "action-scheduler-webhooks")
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(webhook.Secret, now.Unix(), body))
+
+	res, err := d.client.Do(req)
+
+	if err != nil {
+		result.Error = err.Error()
+		return d.retry(c, delivery, result)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseBytes))
+	res.Body.Close()
+
+	result.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		result.Error = fmt.Sprintf("unexpected status %d", res.StatusCode)
+		return d.retry(c, delivery, result)
+	}
+
+	d.log(c).Info("delivered event", "event", delivery.Event, "attempt", delivery.Attempts+1)
+	result.Status = types.DeliverySucceeded
+	return result
+}
+
+// retry schedules the next attempt of a failed one, or fails the delivery after MaxAttempts
+func (d *Dispatcher) retry(c context.Context, delivery *model.WebhookDelivery, result model.DeliveryAttempt) model.DeliveryAttempt {
+	attempts := delivery.Attempts + 1
+
+	if attempts >= d.cfg.MaxAttempts {
+		d.log(c).Error("giving up on delivery", "event", delivery.Event, "attempts", attempts, "error", result.Error)
+		result.Status = types.DeliveryFailed
+		return result
+	}
+
+	result.Status = types.DeliveryPending
+	result.NextAttemptAt = result.At.Add(d.backoff(attempts))
+	d.log(c).Warn("delivery attempt failed", "event", delivery.Event, "attempt", attempts, "next_attempt_at", result.NextAttemptAt, "error", result.Error)
+	return result
+}
+
+// backoff is the delay after the given number of failed attempts: BackoffBase doubled after every attempt, up to BackoffMax
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BackoffBase
+	for i := 1; i < attempts && delay < d.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, d.cfg.BackoffMax)
+}
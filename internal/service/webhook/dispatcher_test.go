@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// fakeStores keeps one webhook and its deliveries in memory, without tenants
+type fakeStores struct {
+	webhook    *model.Webhook
+	deliveries []*model.WebhookDelivery
+}
+
+func (f *fakeStores) Create(ctx context.Context, webhook *model.Webhook) (string, error) {
+	webhook.ID = "webhook-1"
+	f.webhook = webhook
+	return webhook.ID, nil
+}
+
+func (f *fakeStores) Get(ctx context.Context) ([]model.Webhook, error) {
+	if f.webhook == nil {
+		return nil, nil
+	}
+	return []model.Webhook{*f.webhook}, nil
+}
+
+func (f *fakeStores) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	return f.Lookup(ctx, id)
+}
+
+func (f *fakeStores) Subscribed(ctx context.Context, event string) ([]model.Webhook, error) {
+	if f.webhook == nil {
+		return nil, nil
+	}
+	for _, e := range f.webhook.Events {
+		if e == event {
+			return []model.Webhook{*f.webhook}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStores) Lookup(ctx context.Context, id string) (*model.Webhook, error) {
+	if f.webhook == nil || f.webhook.ID != id {
+		return nil, store.ErrWebhookNotFound
+	}
+	return f.webhook, nil
+}
+
+func (f *fakeStores) Delete(ctx context.Context, id string) error {
+	if _, err := f.Lookup(ctx, id); err != nil {
+		return err
+	}
+	f.webhook = nil
+	return nil
+}
+
+type fakeDeliveries struct {
+	fakeStores *fakeStores
+}
+
+func (f fakeDeliveries) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	for i := range deliveries {
+		deliveries[i].ID = "delivery-" + strconv.Itoa(len(f.fakeStores.deliveries)+1)
+		f.fakeStores.deliveries = append(f.fakeStores.deliveries, &deliveries[i])
+	}
+	return nil
+}
+
+func (f fakeDeliveries) Get(ctx context.Context, filter model.DeliveryFilter, pagination *types.PaginationOps) (int64, []model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	for _, d := range f.fakeStores.deliveries {
+		deliveries = append(deliveries, *d)
+	}
+	return int64(len(deliveries)), deliveries, nil
+}
+
+func (f fakeDeliveries) GetByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	for _, d := range f.fakeStores.deliveries {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, store.ErrDeliveryNotFound
+}
+
+func (f fakeDeliveries) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	for _, d := range f.fakeStores.deliveries {
+		if d.Status == types.DeliveryPending && !d.NextAttemptAt.After(now) && !d.LockedUntil.After(now) {
+			d.LockedUntil = now.Add(lease)
+			claimed := *d
+			return &claimed, nil
+		}
+	}
+	return nil, store.ErrNoDelivery
+}
+
+func (f fakeDeliveries) Complete(ctx context.Context, id string, attempt model.DeliveryAttempt) error {
+	for _, d := range f.fakeStores.deliveries {
+		if d.ID == id {
+			d.Status = attempt.Status
+			d.Attempts++
+			d.LastStatusCode = attempt.StatusCode
+			d.LastError = attempt.Error
+			d.LockedUntil = time.Time{}
+			if attempt.Status == types.DeliveryPending {
+				d.NextAttemptAt = attempt.NextAttemptAt
+			}
+			if attempt.Status == types.DeliverySucceeded {
+				d.DeliveredAt = attempt.At
+			}
+			return nil
+		}
+	}
+	return store.ErrDeliveryNotFound
+}
+
+func TestDispatch(t *testing.T) {
+	statuses := []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusNoContent}
+	var requests []*http.Request
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		by, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(by))
+		w.WriteHeader(statuses[len(requests)-1])
+	}))
+	defer server.Close()
+
+	stores := &fakeStores{}
+	// the test server listens on loopback
+	cfg := config.Webhooks{Timeout: time.Second, MaxAttempts: 5, BackoffBase: 10 * time.Second, BackoffMax: time.Minute, AllowPrivateURLs: true}
+	svc := New(stores, fakeDeliveries{stores}, cfg)
+	created, err := svc.Create(context.Background(), types.CreateWebhookInput{URL: server.URL, Events: []string{types.EventScheduleCreated}})
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+
+	if err := svc.Emit(context.Background(), types.WebhookEvent{Type: types.EventScheduleDeleted}); err != nil {
+		t.Fatalf("error emitting: %v", err)
+	}
+	if err := svc.Emit(context.Background(), types.WebhookEvent{Type: types.EventScheduleCreated, Schedule: &types.Schedule{ID: "1"}}); err != nil {
+		t.Fatalf("error emitting: %v", err)
+	}
+	if len(stores.deliveries) != 1 {
+		t.Fatalf("expected 1 delivery for the subscribed event. got=%d", len(stores.deliveries))
+	}
+
+	now := time.Now().UTC()
+	d := NewDispatcher(stores, fakeDeliveries{stores}, cfg)
+	d.now = func() time.Time { return now }
+
+	delivery := stores.deliveries[0]
+	for i, wantBackoff := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		if !d.DispatchOne(context.Background()) {
+			t.Fatalf("attempt %d: expected a delivery", i+1)
+		}
+		if delivery.Status != types.DeliveryPending || delivery.LastStatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: expected a pending delivery after a 500. got=%+v", i+1, delivery)
+		}
+		if !delivery.NextAttemptAt.Equal(now.Add(wantBackoff)) {
+			t.Errorf("attempt %d: expected a retry in %v. got=%v", i+1, wantBackoff, delivery.NextAttemptAt.Sub(now))
+		}
+		if d.DispatchOne(context.Background()) {
+			t.Fatalf("attempt %d: expected no delivery before the backoff", i+1)
+		}
+		now = delivery.NextAttemptAt
+	}
+
+	if !d.DispatchOne(context.Background()) {
+		t.Fatal("expected a delivery")
+	}
+	if delivery.Status != types.DeliverySucceeded || delivery.Attempts != 3 {
+		t.Fatalf("expected a delivery succeeded after 3 attempts. got=%+v", delivery)
+	}
+
+	r := requests[2]
+	ts, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if want := "sha256=" + Sign(created.Secret, ts, []byte(bodies[2])); r.Header.Get(SignatureHeader) != want {
+		t.Errorf("expected signature %s. got=%s", want, r.Header.Get(SignatureHeader))
+	}
+	if r.Header.Get(EventHeader) != types.EventScheduleCreated || r.Header.Get(DeliveryHeader) != delivery.ID {
+		t.Errorf("unexpected headers %v", r.Header)
+	}
+	if bodies[0] != bodies[2] {
+		t.Errorf("expected every attempt to send the same body. got=%s and %s", bodies[0], bodies[2])
+	}
+
+	replay, err := svc.Replay(context.Background(), created.ID, delivery.ID)
+	if err != nil {
+		t.Fatalf("error replaying: %v", err)
+	}
+	if err := svc.Delete(context.Background(), created.ID); err != nil {
+		t.Fatalf("error deleting webhook: %v", err)
+	}
+
+	if !d.DispatchOne(context.Background()) {
+		t.Fatal("expected the replay to be dispatched")
+	}
+	if got := stores.deliveries[1]; got.ID != replay.ID || got.Status != types.DeliveryFailed || got.Attempts != 1 {
+		t.Errorf("expected the replay of a deleted webhook to fail at once. got=%+v", got)
+	}
+	if len(requests) != 3 {
+		t.Errorf("expected no request for a deleted webhook. got=%d", len(requests))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: config.Webhooks{MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: 5 * time.Second}}
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 30: 5 * time.Second} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d): expected %v. got=%v", attempts, want, got)
+		}
+	}
+
+	delivery := &model.WebhookDelivery{Attempts: 2}
+	if got := d.retry(context.Background(), delivery, model.DeliveryAttempt{}); got.Status != types.DeliveryFailed {
+		t.Errorf("expected the delivery to fail after MaxAttempts. got=%+v", got)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{url: "https://93.184.216.34/hook"},
+		{url: "https://[2606:2800:220:1:248:1893:25c8:1946]/hook"},
+		{url: "http://93.184.216.34/hook", wantErr: true},
+		{url: "ftp://93.184.216.34/hook", wantErr: true},
+		{url: "https://127.0.0.1/hook", wantErr: true},
+		{url: "https://[::1]/hook", wantErr: true},
+		{url: "https://10.0.0.1/hook", wantErr: true},
+		{url: "https://192.168.1.1/hook", wantErr: true},
+		{url: "https://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "https://[fe80::1]/hook", wantErr: true},
+		{url: "https://0.0.0.0/hook", wantErr: true},
+		{url: "https:///hook", wantErr: true},
+		{url: "http://127.0.0.1/hook", allowPrivate: true},
+		{url: "ftp://127.0.0.1/hook", allowPrivate: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := checkURL(context.Background(), tt.url, tt.allowPrivate)
+
+		if tt.wantErr && !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("%s: expected %v. got=%v", tt.url, ErrInvalidURL, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.url, err)
+		}
+	}
+}
+
+// a webhook registered on a public address whose host is rebound to an internal one is rejected when dialing
+func TestDispatchRejectsInternalAddresses(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	stores := &fakeStores{webhook: &model.Webhook{ID: "webhook-1", URL: server.URL, Events: []string{types.EventScheduleCreated}}}
+	stores.deliveries = []*model.WebhookDelivery{{ID: "delivery-1", WebhookID: stores.webhook.ID, Status: types.DeliveryPending}}
+
+	d := NewDispatcher(stores, fakeDeliveries{stores}, config.Webhooks{Timeout: time.Second, MaxAttempts: 5, BackoffBase: time.Second, BackoffMax: time.Minute})
+
+	if !d.DispatchOne(context.Background()) {
+		t.Fatal("expected a delivery")
+	}
+	if got := stores.deliveries[0]; got.Status != types.DeliveryPending || !strings.Contains(got.LastError, errBlockedAddress.Error()) {
+		t.Errorf("expected the attempt to fail on the address. got=%+v", got)
+	}
+	if requests != 0 {
+		t.Errorf("expected no request to reach the server. got=%d", requests)
+	}
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/apperr"
+)
+
+// ErrInvalidURL is returned for webhook URLs which are not https or resolve to an internal address.
+// the deliveries are sent from inside the deployment, a webhook must not reach what is only reachable from there.
+var ErrInvalidURL = apperr.Validation("invalid_webhook_url", "webhook urls must be https and resolve to a public address", nil)
+
+// errBlockedAddress fails the attempts dialing an internal address, e.g. after the host of the webhook was rebound
+var errBlockedAddress = errors.New("webhook address is not public")
+
+// blocked reports whether ip is loopback, private, link-local (e.g. the cloud metadata endpoints), multicast or unspecified
+func blocked(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// checkURL validates the URL of a webhook being registered and every address its host resolves to.
+// allowPrivate lets http and internal addresses through, see config.Webhooks.AllowPrivateURLs.
+func checkURL(c context.Context, raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w. got=%s", ErrInvalidURL, raw)
+	}
+
+	if allowPrivate {
+		if u.Scheme != "https" && u.Scheme != "http" {
+			return fmt.Errorf("%w. got=%s", ErrInvalidURL, raw)
+		}
+		return nil
+	}
+
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w. got=%s", ErrInvalidURL, raw)
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if blocked(ip) {
+			return fmt.Errorf("%w. got=%s", ErrInvalidURL, raw)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(c, u.Hostname())
+
+	if err != nil {
+		return fmt.Errorf("%w. reason: %s can't be resolved", ErrInvalidURL, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if blocked(addr.IP) {
+			return fmt.Errorf("%w. got=%s", ErrInvalidURL, raw)
+		}
+	}
+	return nil
+}
+
+// dialControl rejects the connections to internal addresses. it runs on the resolved address of every dial,
+// so a host resolving to a public address at registration and rebound to an internal one later is still rejected.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+		return fmt.Errorf("%w. got=%s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// transport dials the subscribers directly, through dialControl unless allowPrivate.
+// the proxy of the environment is ignored, dialControl would check its address instead of the subscriber's.
+func transport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = dialControl
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/japb1998/action-scheduler/internal/apperr"
+	"github.com/japb1998/action-scheduler/internal/config"
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/mapper"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+var (
+	ErrWebhookNotFound  = apperr.NotFound("webhook_not_found", "webhook not found")
+	ErrDeliveryNotFound = apperr.NotFound("delivery_not_found", "delivery not found")
+)
+
+const secretPrefix = "whsec_"
+
+type WebhookStore interface {
+	Create(ctx context.Context, webhook *model.Webhook) (string, error)
+	Get(ctx context.Context) ([]model.Webhook, error)
+	GetByID(ctx context.Context, id string) (*model.Webhook, error)
+	Subscribed(ctx context.Context, event string) ([]model.Webhook, error)
+	Lookup(ctx context.Context, id string) (*model.Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type DeliveryStore interface {
+	// Enqueue sets the IDs of the deliveries
+	Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error
+	Get(ctx context.Context, filter model.DeliveryFilter, pagination *types.PaginationOps) (int64, []model.WebhookDelivery, error)
+	GetByID(ctx context.Context, id string) (*model.WebhookDelivery, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error)
+	Complete(ctx context.Context, id string, attempt model.DeliveryAttempt) error
+}
+
+// WebhookService manages the webhooks of a tenant and queues their deliveries. the Dispatcher sends them.
+type WebhookService struct {
+	webhooks   WebhookStore
+	deliveries DeliveryStore
+	cfg        config.Webhooks
+}
+
+func New(webhooks WebhookStore, deliveries DeliveryStore, cfg config.Webhooks) *WebhookService {
+	return &WebhookService{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		cfg:        cfg,
+	}
+}
+
+func (s *WebhookService) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("service", "webhook"))
+}
+
+// Create registers a webhook for the tenant in the context. the secret is only returned here.
+// the URL must be https and resolve to public addresses, the Dispatcher checks them again on every dial.
+func (s *WebhookService) Create(c context.Context, input types.CreateWebhookInput) (*types.CreatedWebhook, error) {
+	if err := checkURL(c, input.URL, s.cfg.AllowPrivateURLs); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret")
+	}
+
+	m := &model.Webhook{
+		URL:       input.URL,
+		Events:    input.Events,
+		Secret:    secretPrefix + base64.RawURLEncoding.EncodeToString(secret),
+		CreatedBy: requestctx.Actor(c),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	id, err := s.webhooks.Create(c, m)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook")
+	}
+	m.ID = id
+
+	s.log(c).Info("created webhook", "id", id, "events", m.Events)
+
+	return &types.CreatedWebhook{
+		Webhook: *mapper.MapWebhookModelToType(m),
+		Secret:  m.Secret,
+	}, nil
+}
+
+func (s *WebhookService) GetAll(c context.Context) ([]types.Webhook, error) {
+	models, err := s.webhooks.Get(c)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks")
+	}
+
+	webhooks := make([]types.Webhook, 0, len(models))
+	for _, m := range models {
+		webhooks = append(webhooks, *mapper.MapWebhookModelToType(&m))
+	}
+	return webhooks, nil
+}
+
+// Delete removes the webhook. its pending deliveries fail when they are dispatched.
+func (s *WebhookService) Delete(c context.Context, id string) error {
+	if err := s.webhooks.Delete(c, id); err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to delete webhook")
+	}
+
+	s.log(c).Info("deleted webhook", "id", id)
+	return nil
+}
+
+// Deliveries returns the delivery log of the webhook
+func (s *WebhookService) Deliveries(c context.Context, webhookID string, filter *types.DeliveryFilter) (*types.PaginatedResult[types.WebhookDelivery], error) {
+	if _, err := s.getWebhook(c, webhookID); err != nil {
+		return nil, err
+	}
+
+	count, models, err := s.deliveries.Get(c, model.DeliveryFilter{WebhookID: webhookID, Status: filter.Status}, &filter.PaginationOps)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting deliveries")
+	}
+
+	deliveries := make([]types.WebhookDelivery, 0, len(models))
+	for _, m := range models {
+		deliveries = append(deliveries, *mapper.MapDeliveryModelToType(&m))
+	}
+
+	return &types.PaginatedResult[types.WebhookDelivery]{
+		Total: int(count),
+		Items: deliveries,
+		Limit: filter.Limit,
+		Page:  filter.Page,
+	}, nil
+}
+
+// Replay queues the event of a delivery again, whatever its status. the new delivery references the replayed one.
+func (s *WebhookService) Replay(c context.Context, webhookID, deliveryID string) (*types.WebhookDelivery, error) {
+	if _, err := s.getWebhook(c, webhookID); err != nil {
+		return nil, err
+	}
+
+	original, err := s.deliveries.GetByID(c, deliveryID)
+
+	if err != nil {
+		if errors.Is(err, store.ErrDeliveryNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get delivery")
+	}
+
+	if original.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	replays := []model.WebhookDelivery{newDelivery(webhookID, original.EventID, original.Event, original.Body)}
+	replays[0].ReplayOf = deliveryID
+
+	if err := s.deliveries.Enqueue(c, replays); err != nil {
+		return nil, fmt.Errorf("failed to queue delivery")
+	}
+	replay := &replays[0]
+
+	s.log(c).Info("replaying delivery", "webhook_id", webhookID, "delivery_id", deliveryID, "replay_id", replay.ID)
+	return mapper.MapDeliveryModelToType(replay), nil
+}
+
+// Emit queues a delivery of the event for every webhook of the tenant subscribed to it.
+// the ID and time of the event are set here.
+func (s *WebhookService) Emit(c context.Context, event types.WebhookEvent) error {
+	webhooks, err := s.webhooks.Subscribed(c, event.Type)
+
+	if err != nil {
+		return fmt.Errorf("failed to get the webhooks of event %s. error=%w", event.Type, err)
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	event.ID = uuid.NewString()
+	event.OccurredAt = time.Now().UTC()
+
+	body, err := json.Marshal(event)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s. error=%w", event.Type, err)
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, newDelivery(webhook.ID, event.ID, event.Type, string(body)))
+	}
+
+	if err := s.deliveries.Enqueue(c, deliveries); err != nil {
+		return fmt.Errorf("failed to queue event %s. error=%w", event.Type, err)
+	}
+
+	s.log(c).Info("queued event", "event", event.Type, "event_id", event.ID, "webhooks", len(webhooks))
+	return nil
+}
+
+func (s *WebhookService) getWebhook(c context.Context, id string) (*model.Webhook, error) {
+	webhook, err := s.webhooks.GetByID(c, id)
+
+	if err != nil {
+		if errors.Is(err, store.ErrWebhookNotFound) || errors.Is(err, store.ErrInvalidID) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook")
+	}
+	return webhook, nil
+}
+
+// newDelivery returns a delivery due now
+func newDelivery(webhookID, eventID, event, body string) model.WebhookDelivery {
+	now := time.Now().UTC()
+
+	return model.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		Event:         event,
+		Body:          body,
+		Status:        types.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+const apiKeyColumns = "id, tenant_id, name, prefix, hash, actions, read_only, created_by, created_at, last_used_at, revoked_at"
+
+type SQLiteAPIKeyStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteAPIKeyStore(db *sql.DB) *SQLiteAPIKeyStore {
+	return &SQLiteAPIKeyStore{db: db}
+}
+
+func (s *SQLiteAPIKeyStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "api_key"))
+}
+
+// Create inserts the key for the tenant of the context
+func (s *SQLiteAPIKeyStore) Create(ctx context.Context, key *model.APIKey) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	key.TenantID = tenantID
+
+	id, err := newID()
+
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO api_key ("+apiKeyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, tenantID, key.Name, key.Prefix, key.Hash, formatList(key.Actions), key.ReadOnly, key.CreatedBy,
+		formatTime(key.CreatedAt), formatTime(key.LastUsedAt), formatTime(key.RevokedAt))
+
+	if err != nil {
+		s.log(ctx).Error("error creating api key", slog.String("error", err.Error()))
+		return "", err
+	}
+	return id, nil
+}
+
+// GetByHash is used to authenticate callers so it is not scoped by tenant. revoked keys are not returned.
+func (s *SQLiteAPIKeyStore) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE hash = ? AND revoked_at = ?", hash, formatTime(time.Time{}))
+	key, err := scanAPIKey(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		s.log(ctx).Error("error getting api key", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return key, nil
+}
+
+// Get returns every key of the tenant, newest first
+func (s *SQLiteAPIKeyStore) Get(ctx context.Context) ([]model.APIKey, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE tenant_id = ? ORDER BY created_at DESC", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error getting api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting api keys", slog.String("error", err.Error()))
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke marks the key as revoked. revoked keys are kept so they still show up in listings and audits.
+func (s *SQLiteAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	if !validID(id) {
+		return ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "UPDATE api_key SET revoked_at = ? WHERE tenant_id = ? AND id = ? AND revoked_at = ?",
+		formatTime(at), tenantID, id, formatTime(time.Time{}))
+
+	if err != nil {
+		s.log(ctx).Error("error revoking api key", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RevokeAll revokes every key of the tenant not revoked yet and returns how many were revoked
+func (s *SQLiteAPIKeyStore) RevokeAll(ctx context.Context, at time.Time) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "UPDATE api_key SET revoked_at = ? WHERE tenant_id = ? AND revoked_at = ?", formatTime(at), tenantID, formatTime(time.Time{}))
+
+	if err != nil {
+		s.log(ctx).Error("error revoking tenant api keys", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func (s *SQLiteAPIKeyStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_key SET last_used_at = ? WHERE id = ?", formatTime(at), id)
+
+	if err != nil {
+		s.log(ctx).Error("error updating api key last used", slog.String("id", id), slog.String("error", err.Error()))
+	}
+	return err
+}
+
+func scanAPIKey(row scanner) (*model.APIKey, error) {
+	var (
+		key                                     model.APIKey
+		id, actions, created, lastUsed, revoked string
+	)
+
+	err := row.Scan(&id, &key.TenantID, &key.Name, &key.Prefix, &key.Hash, &actions, &key.ReadOnly, &key.CreatedBy, &created, &lastUsed, &revoked)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key.ID = id
+	if key.Actions, err = parseList(actions); err != nil {
+		return nil, fmt.Errorf("invalid actions of api key %s. error=%w", id, err)
+	}
+	for _, t := range []struct {
+		dst *time.Time
+		src string
+	}{{&key.CreatedAt, created}, {&key.LastUsedAt, lastUsed}, {&key.RevokedAt, revoked}} {
+		if *t.dst, err = parseTime(t.src); err != nil {
+			return nil, fmt.Errorf("invalid time of api key %s. error=%w", id, err)
+		}
+	}
+	return &key, nil
+}
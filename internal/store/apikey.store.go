@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type MongoAPIKeyStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoAPIKeyStore(c *mongo.Client, database string) *MongoAPIKeyStore {
+
+	return &MongoAPIKeyStore{
+		coll: c.Database(database).Collection("api_key"),
+	}
+}
+
+func (s *MongoAPIKeyStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "api_key"))
+}
+
+// Create inserts the key for the tenant of the context
+func (s *MongoAPIKeyStore) Create(ctx context.Context, key *model.APIKey) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	key.TenantID = tenantID
+
+	r, err := s.coll.InsertOne(ctx, key)
+
+	if err != nil {
+		s.log(ctx).Error("error creating api key", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	id, ok := r.InsertedID.(primitive.ObjectID)
+
+	if !ok {
+		return "", ErrInvalidID
+	}
+	return id.Hex(), nil
+}
+
+// GetByHash is used to authenticate callers so it is not scoped by tenant. revoked keys are not returned.
+func (s *MongoAPIKeyStore) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	filter := bson.D{
+		{Key: "hash", Value: hash},
+		{Key: "revoked_at", Value: time.Time{}},
+	}
+
+	var key model.APIKey
+
+	if err := s.coll.FindOne(ctx, filter).Decode(&key); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAPIKeyNotFound
+		}
+		s.log(ctx).Error("error getting api key", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Get returns every key of the tenant, newest first
+func (s *MongoAPIKeyStore) Get(ctx context.Context) ([]model.APIKey, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+
+	if err != nil {
+		s.log(ctx).Error("error getting api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	keys := make([]model.APIKey, 0)
+	if err := cursor.All(ctx, &keys); err != nil {
+		s.log(ctx).Error("error getting api keys", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks the key as revoked. revoked keys are kept so they still show up in listings and audits.
+func (s *MongoAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return ErrInvalidID
+	}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+	filter = append(filter, bson.E{Key: "_id", Value: bsonId}, bson.E{Key: "revoked_at", Value: time.Time{}})
+
+	r, err := s.coll.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+
+	if err != nil {
+		s.log(ctx).Error("error revoking api key", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if r.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RevokeAll revokes every key of the tenant not revoked yet and returns how many were revoked
+func (s *MongoAPIKeyStore) RevokeAll(ctx context.Context, at time.Time) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+	filter = append(filter, bson.E{Key: "revoked_at", Value: time.Time{}})
+
+	r, err := s.coll.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+
+	if err != nil {
+		s.log(ctx).Error("error revoking tenant api keys", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.ModifiedCount, nil
+}
+
+func (s *MongoAPIKeyStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	_, err = s.coll.UpdateByID(ctx, bsonId, bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: at}}}})
+
+	if err != nil {
+		s.log(ctx).Error("error updating api key last used", slog.String("id", id), slog.String("error", err.Error()))
+	}
+	return err
+}
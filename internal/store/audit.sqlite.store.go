@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+const auditColumns = "id, tenant_id, schedule_id, action, actor, request_id, timestamp, before, after"
+
+// SQLiteAuditStore is an append-only store. entries can be created and read but never updated or deleted.
+type SQLiteAuditStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteAuditStore(db *sql.DB) *SQLiteAuditStore {
+	return &SQLiteAuditStore{db: db}
+}
+
+func (s *SQLiteAuditStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "audit"))
+}
+
+// Create appends a new entry to the audit table
+func (s *SQLiteAuditStore) Create(ctx context.Context, entry *model.AuditEntry) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+	entry.TenantID = tenantID
+
+	before, err := formatJSON(entry.Before)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry. error=%w", err)
+	}
+	after, err := formatJSON(entry.After)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry. error=%w", err)
+	}
+
+	id, err := newID()
+
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO audit ("+auditColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, tenantID, entry.ScheduleID, string(entry.Action), entry.Actor, entry.RequestID, formatTime(entry.Timestamp), before, after)
+
+	if err != nil {
+		s.log(ctx).Error("error creating audit entry", slog.String("schedule_id", entry.ScheduleID), slog.String("error", err.Error()))
+		return err
+	}
+	entry.ID = id
+
+	return nil
+}
+
+// Get returns the audit entries matching the filter, newest first. Pagination is Zero based
+func (s *SQLiteAuditStore) Get(ctx context.Context, filter model.AuditFilter, pagination *types.PaginationOps) (count int64, entries []model.AuditEntry, err error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, nil, ErrMissingTenant
+	}
+
+	where := []string{"tenant_id = ?"}
+	args := []any{tenantID}
+
+	if filter.ScheduleID != "" {
+		where, args = append(where, "schedule_id = ?"), append(args, filter.ScheduleID)
+	}
+	if filter.Actor != "" {
+		where, args = append(where, "actor = ?"), append(args, filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		where, args = append(where, "timestamp >= ?"), append(args, formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where, args = append(where, "timestamp <= ?"), append(args, formatTime(filter.To))
+	}
+	conditions := strings.Join(where, " AND ")
+
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit WHERE "+conditions, args...).Scan(&count); err != nil {
+		s.log(ctx).Error("error counting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	limit, offset := limitOffset(pagination)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+auditColumns+" FROM audit WHERE "+conditions+" ORDER BY timestamp DESC, rowid DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+
+	if err != nil {
+		s.log(ctx).Error("error getting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting audit entries", slog.String("error", err.Error()))
+			return 0, nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.log(ctx).Error("error getting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	return count, entries, nil
+}
+
+func scanAuditEntry(row scanner) (*model.AuditEntry, error) {
+	var (
+		entry         model.AuditEntry
+		id, timestamp string
+		before, after sql.NullString
+	)
+
+	if err := row.Scan(&id, &entry.TenantID, &entry.ScheduleID, &entry.Action, &entry.Actor, &entry.RequestID, &timestamp, &before, &after); err != nil {
+		return nil, err
+	}
+
+	var err error
+	entry.ID = id
+	if entry.Timestamp, err = parseTime(timestamp); err != nil {
+		return nil, fmt.Errorf("invalid timestamp of audit entry %s. error=%w", id, err)
+	}
+	if entry.Before, err = parseJSON[model.Schedule](before); err != nil {
+		return nil, fmt.Errorf("invalid before of audit entry %s. error=%w", id, err)
+	}
+	if entry.After, err = parseJSON[model.Schedule](after); err != nil {
+		return nil, fmt.Errorf("invalid after of audit entry %s. error=%w", id, err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuditStore is an append-only store. entries can be created and read but never updated or deleted.
+type MongoAuditStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoAuditStore(c *mongo.Client, database string) *MongoAuditStore {
+
+	return &MongoAuditStore{
+		coll: c.Database(database).Collection("audit"),
+	}
+}
+
+func (s *MongoAuditStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "audit"))
+}
+
+// Create appends a new entry to the audit collection
+func (s *MongoAuditStore) Create(ctx context.Context, entry *model.AuditEntry) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+	entry.TenantID = tenantID
+
+	r, err := s.coll.InsertOne(ctx, entry)
+
+	if err != nil {
+		s.log(ctx).Error("error creating audit entry", slog.String("schedule_id", entry.ScheduleID), slog.String("error", err.Error()))
+		return err
+	}
+
+	if id, ok := r.InsertedID.(primitive.ObjectID); ok {
+		entry.ID = id.Hex()
+	}
+	return nil
+}
+
+// Get returns the audit entries matching the filter, newest first. Pagination is Zero based
+func (s *MongoAuditStore) Get(ctx context.Context, filter model.AuditFilter, pagination *types.PaginationOps) (count int64, entries []model.AuditEntry, err error) {
+	f, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, nil, err
+	}
+	f = append(f, auditFilterToBson(filter)...)
+	ops := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64(pagination.Limit * pagination.Page)).
+		SetLimit(int64(pagination.Limit))
+
+	cursor, err := s.coll.Find(ctx, f, ops)
+
+	if err != nil {
+		s.log(ctx).Error("error getting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	if err = cursor.All(ctx, &entries); err != nil {
+		s.log(ctx).Error("error getting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	if count, err = s.coll.CountDocuments(ctx, f); err != nil {
+		s.log(ctx).Error("error counting audit entries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	return count, entries, nil
+}
+
+func auditFilterToBson(filter model.AuditFilter) bson.D {
+	f := bson.D{}
+
+	if filter.ScheduleID != "" {
+		f = append(f, bson.E{Key: "schedule_id", Value: filter.ScheduleID})
+	}
+
+	if filter.Actor != "" {
+		f = append(f, bson.E{Key: "actor", Value: filter.Actor})
+	}
+
+	timestamp := bson.D{}
+	if !filter.From.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$lte", Value: filter.To})
+	}
+	if len(timestamp) > 0 {
+		f = append(f, bson.E{Key: "timestamp", Value: timestamp})
+	}
+
+	return f
+}
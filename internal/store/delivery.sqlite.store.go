@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+const deliveryColumns = "id, tenant_id, webhook_id, event_id, event, body, status, attempts, next_attempt_at, locked_until, last_status_code, last_error, created_at, delivered_at, replay_of"
+
+// SQLiteDeliveryStore is the queue of the webhook deliveries. deliveries are kept once done, as the delivery log.
+type SQLiteDeliveryStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteDeliveryStore(db *sql.DB) *SQLiteDeliveryStore {
+	return &SQLiteDeliveryStore{db: db}
+}
+
+func (s *SQLiteDeliveryStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "webhook_delivery"))
+}
+
+// Enqueue inserts the deliveries for the tenant of the context, all or none, and sets their IDs
+func (s *SQLiteDeliveryStore) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		s.log(ctx).Error("error enqueuing deliveries", slog.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.TenantID = tenantID
+		if d.ID, err = newID(); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "INSERT INTO webhook_delivery ("+deliveryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			d.ID, tenantID, d.WebhookID, d.EventID, d.Event, d.Body, d.Status, d.Attempts, formatTime(d.NextAttemptAt), formatTime(d.LockedUntil),
+			d.LastStatusCode, d.LastError, formatTime(d.CreatedAt), formatTime(d.DeliveredAt), d.ReplayOf)
+
+		if err != nil {
+			s.log(ctx).Error("error enqueuing deliveries", slog.String("error", err.Error()))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.log(ctx).Error("error enqueuing deliveries", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Get returns the deliveries of the tenant matching the filter, newest first. Pagination is Zero based
+func (s *SQLiteDeliveryStore) Get(ctx context.Context, filter model.DeliveryFilter, pagination *types.PaginationOps) (count int64, deliveries []model.WebhookDelivery, err error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, nil, ErrMissingTenant
+	}
+
+	where := []string{"tenant_id = ?"}
+	args := []any{tenantID}
+
+	if filter.WebhookID != "" {
+		where, args = append(where, "webhook_id = ?"), append(args, filter.WebhookID)
+	}
+	if filter.Status != "" {
+		where, args = append(where, "status = ?"), append(args, filter.Status)
+	}
+	conditions := strings.Join(where, " AND ")
+
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhook_delivery WHERE "+conditions, args...).Scan(&count); err != nil {
+		s.log(ctx).Error("error counting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	limit, offset := limitOffset(pagination)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_delivery WHERE "+conditions+" ORDER BY created_at DESC, rowid DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+
+	if err != nil {
+		s.log(ctx).Error("error getting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting deliveries", slog.String("error", err.Error()))
+			return 0, nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.log(ctx).Error("error getting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	return count, deliveries, nil
+}
+
+// GetByID returns the delivery of the tenant of the context
+func (s *SQLiteDeliveryStore) GetByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	delivery, err := scanDelivery(s.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_delivery WHERE tenant_id = ? AND id = ?", tenantID, id))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		s.log(ctx).Error("error getting delivery", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Claim leases the pending delivery due the longest, of any tenant, until now+lease.
+// a worker that dies while delivering loses the lease and the delivery is claimed again.
+func (s *SQLiteDeliveryStore) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	row := s.db.QueryRowContext(ctx, `UPDATE webhook_delivery SET locked_until = ? WHERE id = (
+			SELECT id FROM webhook_delivery WHERE status = ? AND next_attempt_at <= ? AND locked_until <= ? ORDER BY next_attempt_at LIMIT 1
+		) RETURNING `+deliveryColumns,
+		formatTime(now.Add(lease)), types.DeliveryPending, formatTime(now), formatTime(now))
+	delivery, err := scanDelivery(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoDelivery
+		}
+		s.log(ctx).Error("error claiming delivery", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Complete records an attempt of a claimed delivery and releases its lease
+func (s *SQLiteDeliveryStore) Complete(ctx context.Context, id string, attempt model.DeliveryAttempt) error {
+	var deliveredAt sql.NullString
+	if attempt.Status == types.DeliverySucceeded {
+		deliveredAt = sql.NullString{String: formatTime(attempt.At), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE webhook_delivery SET status = ?, last_status_code = ?, last_error = ?, next_attempt_at = ?, locked_until = ?,
+		attempts = attempts + 1, delivered_at = COALESCE(?, delivered_at) WHERE id = ?`,
+		attempt.Status, attempt.StatusCode, attempt.Error, formatTime(attempt.NextAttemptAt), formatTime(time.Time{}), deliveredAt, id)
+
+	if err != nil {
+		s.log(ctx).Error("error completing delivery", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes every delivery of the tenant, pending ones included, and returns how many were deleted
+func (s *SQLiteDeliveryStore) DeleteAll(ctx context.Context) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM webhook_delivery WHERE tenant_id = ?", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant deliveries", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func scanDelivery(row scanner) (*model.WebhookDelivery, error) {
+	var (
+		d                                                model.WebhookDelivery
+		id, nextAttempt, lockedUntil, created, delivered string
+	)
+
+	err := row.Scan(&id, &d.TenantID, &d.WebhookID, &d.EventID, &d.Event, &d.Body, &d.Status, &d.Attempts, &nextAttempt, &lockedUntil,
+		&d.LastStatusCode, &d.LastError, &created, &delivered, &d.ReplayOf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.ID = id
+	for _, t := range []struct {
+		dst *time.Time
+		src string
+	}{{&d.NextAttemptAt, nextAttempt}, {&d.LockedUntil, lockedUntil}, {&d.CreatedAt, created}, {&d.DeliveredAt, delivered}} {
+		if *t.dst, err = parseTime(t.src); err != nil {
+			return nil, fmt.Errorf("invalid time of delivery %s. error=%w", id, err)
+		}
+	}
+	return &d, nil
+}
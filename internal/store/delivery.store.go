@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrNoDelivery is returned by Claim when no delivery is due
+	ErrNoDelivery = errors.New("no delivery due")
+)
+
+// MongoDeliveryStore is the queue of the webhook deliveries. deliveries are kept once done, as the delivery log.
+type MongoDeliveryStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoDeliveryStore(c *mongo.Client, database string) *MongoDeliveryStore {
+	return &MongoDeliveryStore{
+		coll: c.Database(database).Collection("webhook_delivery"),
+	}
+}
+
+// EnsureIndexes creates the index Claim uses to find the due pending deliveries
+func (s *MongoDeliveryStore) EnsureIndexes(ctx context.Context) error {
+	due := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+	}
+	if _, err := s.coll.Indexes().CreateOne(ctx, due); err != nil {
+		s.log(ctx).Error("error creating queue index", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to create the webhook_delivery queue index. error=%w", err)
+	}
+	return nil
+}
+
+func (s *MongoDeliveryStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "webhook_delivery"))
+}
+
+// Enqueue inserts the deliveries for the tenant of the context and sets their IDs
+func (s *MongoDeliveryStore) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	docs := make([]any, 0, len(deliveries))
+	for i := range deliveries {
+		// mongo generates the ObjectIDs of the documents without _id
+		deliveries[i].ID = ""
+		deliveries[i].TenantID = tenantID
+		docs = append(docs, deliveries[i])
+	}
+
+	r, err := s.coll.InsertMany(ctx, docs)
+
+	if err != nil {
+		s.log(ctx).Error("error enqueuing deliveries", slog.String("error", err.Error()))
+		return err
+	}
+
+	for i, insertedID := range r.InsertedIDs {
+		id, ok := insertedID.(primitive.ObjectID)
+
+		if !ok {
+			return ErrInvalidID
+		}
+		deliveries[i].ID = id.Hex()
+	}
+	return nil
+}
+
+// Get returns the deliveries of the tenant matching the filter, newest first. Pagination is Zero based
+func (s *MongoDeliveryStore) Get(ctx context.Context, filter model.DeliveryFilter, pagination *types.PaginationOps) (count int64, deliveries []model.WebhookDelivery, err error) {
+	f, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, nil, err
+	}
+	if filter.WebhookID != "" {
+		f = append(f, bson.E{Key: "webhook_id", Value: filter.WebhookID})
+	}
+	if filter.Status != "" {
+		f = append(f, bson.E{Key: "status", Value: filter.Status})
+	}
+
+	ops := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(pagination.Limit * pagination.Page)).
+		SetLimit(int64(pagination.Limit))
+
+	cursor, err := s.coll.Find(ctx, f, ops)
+
+	if err != nil {
+		s.log(ctx).Error("error getting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		s.log(ctx).Error("error getting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	if count, err = s.coll.CountDocuments(ctx, f); err != nil {
+		s.log(ctx).Error("error counting deliveries", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	return count, deliveries, nil
+}
+
+// GetByID returns the delivery of the tenant of the context
+func (s *MongoDeliveryStore) GetByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery model.WebhookDelivery
+	if err := s.coll.FindOne(ctx, append(filter, bson.E{Key: "_id", Value: bsonId})).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		s.log(ctx).Error("error getting delivery", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Claim leases the pending delivery due the longest, of any tenant, until now+lease.
+// a worker that dies while delivering loses the lease and the delivery is claimed again.
+func (s *MongoDeliveryStore) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.WebhookDelivery, error) {
+	filter := bson.D{
+		{Key: "status", Value: types.DeliveryPending},
+		{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		{Key: "locked_until", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "locked_until", Value: now.Add(lease)}}}}
+	ops := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery model.WebhookDelivery
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, ops).Decode(&delivery); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoDelivery
+		}
+		s.log(ctx).Error("error claiming delivery", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Complete records an attempt of a claimed delivery and releases its lease
+func (s *MongoDeliveryStore) Complete(ctx context.Context, id string, attempt model.DeliveryAttempt) error {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	set := bson.D{
+		{Key: "status", Value: attempt.Status},
+		{Key: "last_status_code", Value: attempt.StatusCode},
+		{Key: "last_error", Value: attempt.Error},
+		{Key: "next_attempt_at", Value: attempt.NextAttemptAt},
+		{Key: "locked_until", Value: time.Time{}},
+	}
+	if attempt.Status == types.DeliverySucceeded {
+		set = append(set, bson.E{Key: "delivered_at", Value: attempt.At})
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+
+	if _, err := s.coll.UpdateByID(ctx, bsonId, update); err != nil {
+		s.log(ctx).Error("error completing delivery", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes every delivery of the tenant, pending ones included, and returns how many were deleted
+func (s *MongoDeliveryStore) DeleteAll(ctx context.Context) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := s.coll.DeleteMany(ctx, filter)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant deliveries", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.DeletedCount, nil
+}
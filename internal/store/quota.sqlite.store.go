@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+// SQLiteQuotaStore keeps the quota counters. expired rate window counters are ignored and replaced on their next increment,
+// there is no TTL index to remove them.
+type SQLiteQuotaStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewSQLiteQuotaStore(db *sql.DB) *SQLiteQuotaStore {
+	return &SQLiteQuotaStore{db: db, now: time.Now}
+}
+
+func (s *SQLiteQuotaStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "quota_counter"))
+}
+
+// Increment atomically increments the counter only while it is below limit. a limit <= 0 is unlimited.
+// ok is false, and the counter untouched, when the limit was already reached.
+func (s *SQLiteQuotaStore) Increment(ctx context.Context, scope model.QuotaScope, key string, limit int64, expiresAt *time.Time) (count int64, ok bool, err error) {
+	id, tenantID, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var expires sql.NullString
+	if expiresAt != nil {
+		expires = sql.NullString{String: formatTime(*expiresAt), Valid: true}
+	}
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM quota_counter WHERE id = ? AND expires_at <= ?", id, formatTime(s.now())); err != nil {
+		s.log(ctx).Error("error incrementing quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return 0, false, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO quota_counter (id, tenant_id, scope, key, count, expires_at) VALUES (?, ?, ?, ?, 1, ?)
+		ON CONFLICT (id) DO UPDATE SET count = count + 1 WHERE ? <= 0 OR count < ? RETURNING count`,
+		id, tenantID, string(scope), key, expires, limit, limit).Scan(&count)
+
+	// the counter exists but didn't match the limit condition
+	if errors.Is(err, sql.ErrNoRows) {
+		count, err = s.Get(ctx, scope, key)
+		return count, false, err
+	}
+
+	if err != nil {
+		s.log(ctx).Error("error incrementing quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return 0, false, err
+	}
+
+	return count, true, nil
+}
+
+// Decrement releases one unit of the counter. counters never go below zero.
+func (s *SQLiteQuotaStore) Decrement(ctx context.Context, scope model.QuotaScope, key string) error {
+	id, _, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(ctx, "UPDATE quota_counter SET count = count - 1 WHERE id = ? AND count > 0", id); err != nil {
+		s.log(ctx).Error("error decrementing quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Get returns the current value of the counter. missing and expired counters are zero.
+func (s *SQLiteQuotaStore) Get(ctx context.Context, scope model.QuotaScope, key string) (int64, error) {
+	id, _, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.db.QueryRowContext(ctx, "SELECT count FROM quota_counter WHERE id = ? AND (expires_at IS NULL OR expires_at > ?)", id, formatTime(s.now())).Scan(&count)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		s.log(ctx).Error("error getting quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return 0, err
+	}
+	return count, nil
+}
+
+// Set overwrites the counter with count
+func (s *SQLiteQuotaStore) Set(ctx context.Context, scope model.QuotaScope, key string, count int64) error {
+	id, tenantID, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO quota_counter (id, tenant_id, scope, key, count) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET count = excluded.count`, id, tenantID, string(scope), key, count)
+
+	if err != nil {
+		s.log(ctx).Error("error setting quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes the counters of the tenant in the scopes, or in every scope when none is given
+func (s *SQLiteQuotaStore) DeleteAll(ctx context.Context, scopes ...model.QuotaScope) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	query, args := "DELETE FROM quota_counter WHERE tenant_id = ?", []any{tenantID}
+	if len(scopes) > 0 {
+		query += " AND scope IN (?" + strings.Repeat(", ?", len(scopes)-1) + ")"
+		for _, scope := range scopes {
+			args = append(args, string(scope))
+		}
+	}
+
+	r, err := s.db.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant quota counters", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.RowsAffected()
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoQuotaStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoQuotaStore(c *mongo.Client, database string) *MongoQuotaStore {
+	return &MongoQuotaStore{
+		coll: c.Database(database).Collection("quota_counter"),
+	}
+}
+
+// EnsureIndexes creates the ttl index that removes the rate window counters once they expire
+func (s *MongoQuotaStore) EnsureIndexes(ctx context.Context) error {
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	if _, err := s.coll.Indexes().CreateOne(ctx, ttl); err != nil {
+		s.log(ctx).Error("error creating ttl index", slog.String("error", err.Error()))
+		return fmt.Errorf("failed to create the quota_counter ttl index. error=%w", err)
+	}
+	return nil
+}
+
+func (s *MongoQuotaStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "quota_counter"))
+}
+
+func counterID(ctx context.Context, scope model.QuotaScope, key string) (string, string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", "", ErrMissingTenant
+	}
+	return fmt.Sprintf("%s:%s:%s", tenantID, scope, key), tenantID, nil
+}
+
+// Increment atomically increments the counter only while it is below limit. a limit <= 0 is unlimited.
+// ok is false, and the counter untouched, when the limit was already reached.
+func (s *MongoQuotaStore) Increment(ctx context.Context, scope model.QuotaScope, key string, limit int64, expiresAt *time.Time) (count int64, ok bool, err error) {
+	id, tenantID, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: id}}
+	if limit > 0 {
+		filter = append(filter, bson.E{Key: "count", Value: bson.D{{Key: "$lt", Value: limit}}})
+	}
+
+	onInsert := bson.D{
+		{Key: "tenant_id", Value: tenantID},
+		{Key: "scope", Value: scope},
+		{Key: "key", Value: key},
+	}
+	if expiresAt != nil {
+		onInsert = append(onInsert, bson.E{Key: "expires_at", Value: *expiresAt})
+	}
+
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}},
+		{Key: "$setOnInsert", Value: onInsert},
+	}
+
+	var counter model.QuotaCounter
+	err = s.coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+
+	// the counter exists but didn't match the limit condition, so the upsert collided with it.
+	if mongo.IsDuplicateKeyError(err) {
+		count, err = s.Get(ctx, scope, key)
+		return count, false, err
+	}
+
+	if err != nil {
+		s.log(ctx).Error("error incrementing quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return 0, false, err
+	}
+
+	return counter.Count, true, nil
+}
+
+// Decrement releases one unit of the counter. counters never go below zero.
+func (s *MongoQuotaStore) Decrement(ctx context.Context, scope model.QuotaScope, key string) error {
+	id, _, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "count", Value: bson.D{{Key: "$gt", Value: 0}}},
+	}
+
+	if _, err := s.coll.UpdateOne(ctx, filter, bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: -1}}}}); err != nil {
+		s.log(ctx).Error("error decrementing quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Get returns the current value of the counter. missing counters are zero.
+func (s *MongoQuotaStore) Get(ctx context.Context, scope model.QuotaScope, key string) (int64, error) {
+	id, _, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var counter model.QuotaCounter
+	if err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&counter); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		s.log(ctx).Error("error getting quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return 0, err
+	}
+	return counter.Count, nil
+}
+
+// Set overwrites the counter with count
+func (s *MongoQuotaStore) Set(ctx context.Context, scope model.QuotaScope, key string, count int64) error {
+	id, tenantID, err := counterID(ctx, scope, key)
+
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "tenant_id", Value: tenantID},
+		{Key: "scope", Value: scope},
+		{Key: "key", Value: key},
+		{Key: "count", Value: count},
+	}}}
+
+	if _, err := s.coll.UpdateByID(ctx, id, update, options.Update().SetUpsert(true)); err != nil {
+		s.log(ctx).Error("error setting quota counter", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes the counters of the tenant in the scopes, or in every scope when none is given
+func (s *MongoQuotaStore) DeleteAll(ctx context.Context, scopes ...model.QuotaScope) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+	if len(scopes) > 0 {
+		filter = append(filter, bson.E{Key: "scope", Value: bson.D{{Key: "$in", Value: scopes}}})
+	}
+
+	r, err := s.coll.DeleteMany(ctx, filter)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant quota counters", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.DeletedCount, nil
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+const roleBindingColumns = "id, tenant_id, subject, role, actions, updated_at"
+
+type SQLiteRoleStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteRoleStore(db *sql.DB) *SQLiteRoleStore {
+	return &SQLiteRoleStore{db: db}
+}
+
+func (s *SQLiteRoleStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "role_binding"))
+}
+
+// GetBySubject returns the role binding of the subject within the tenant of the context
+func (s *SQLiteRoleStore) GetBySubject(ctx context.Context, subject string) (*model.RoleBinding, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+roleBindingColumns+" FROM role_binding WHERE tenant_id = ? AND subject = ?", tenantID, subject)
+	binding, err := scanRoleBinding(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRoleBindingNotFound
+		}
+		s.log(ctx).Error("error getting role binding", slog.String("subject", subject), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return binding, nil
+}
+
+// Get returns every role binding of the tenant
+func (s *SQLiteRoleStore) Get(ctx context.Context) ([]model.RoleBinding, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+roleBindingColumns+" FROM role_binding WHERE tenant_id = ? ORDER BY subject", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error getting role bindings", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	bindings := make([]model.RoleBinding, 0)
+	for rows.Next() {
+		binding, err := scanRoleBinding(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting role bindings", slog.String("error", err.Error()))
+			return nil, err
+		}
+		bindings = append(bindings, *binding)
+	}
+	return bindings, rows.Err()
+}
+
+// Upsert creates or replaces the role binding of the subject
+func (s *SQLiteRoleStore) Upsert(ctx context.Context, binding *model.RoleBinding) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	id, err := newID()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT INTO role_binding ("+roleBindingColumns+") VALUES (?, ?, ?, ?, ?, ?) "+
+		"ON CONFLICT (tenant_id, subject) DO UPDATE SET role = excluded.role, actions = excluded.actions, updated_at = excluded.updated_at",
+		id, tenantID, binding.Subject, string(binding.Role), formatList(binding.Actions), formatTime(binding.UpdatedAt))
+
+	if err != nil {
+		s.log(ctx).Error("error upserting role binding", slog.String("subject", binding.Subject), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteRoleStore) Delete(ctx context.Context, subject string) error {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM role_binding WHERE tenant_id = ? AND subject = ?", tenantID, subject)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting role binding", slog.String("subject", subject), slog.String("error", err.Error()))
+		return err
+	}
+
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		return ErrRoleBindingNotFound
+	}
+	return nil
+}
+
+// DeleteAll deletes every role binding of the tenant and returns how many were deleted
+func (s *SQLiteRoleStore) DeleteAll(ctx context.Context) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM role_binding WHERE tenant_id = ?", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant role bindings", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func scanRoleBinding(row scanner) (*model.RoleBinding, error) {
+	var (
+		binding                model.RoleBinding
+		id, actions, updatedAt string
+	)
+
+	if err := row.Scan(&id, &binding.TenantID, &binding.Subject, &binding.Role, &actions, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	binding.ID = id
+	if binding.Actions, err = parseList(actions); err != nil {
+		return nil, fmt.Errorf("invalid actions of role binding %s. error=%w", id, err)
+	}
+	if binding.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, fmt.Errorf("invalid update time of role binding %s. error=%w", id, err)
+	}
+	return &binding, nil
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrRoleBindingNotFound = errors.New("role binding not found")
+
+type MongoRoleStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoRoleStore(c *mongo.Client, database string) *MongoRoleStore {
+
+	return &MongoRoleStore{
+		coll: c.Database(database).Collection("role_binding"),
+	}
+}
+
+func (s *MongoRoleStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "role_binding"))
+}
+
+// GetBySubject returns the role binding of the subject within the tenant of the context
+func (s *MongoRoleStore) GetBySubject(ctx context.Context, subject string) (*model.RoleBinding, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	filter = append(filter, bson.E{Key: "subject", Value: subject})
+
+	var binding model.RoleBinding
+
+	if err := s.coll.FindOne(ctx, filter).Decode(&binding); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRoleBindingNotFound
+		}
+		s.log(ctx).Error("error getting role binding", slog.String("subject", subject), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// Get returns every role binding of the tenant
+func (s *MongoRoleStore) Get(ctx context.Context) ([]model.RoleBinding, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "subject", Value: 1}}))
+
+	if err != nil {
+		s.log(ctx).Error("error getting role bindings", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	bindings := make([]model.RoleBinding, 0)
+	if err := cursor.All(ctx, &bindings); err != nil {
+		s.log(ctx).Error("error getting role bindings", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// Upsert creates or replaces the role binding of the subject
+func (s *MongoRoleStore) Upsert(ctx context.Context, binding *model.RoleBinding) error {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+	filter = append(filter, bson.E{Key: "subject", Value: binding.Subject})
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "role", Value: binding.Role},
+		{Key: "actions", Value: binding.Actions},
+		{Key: "updated_at", Value: binding.UpdatedAt},
+	}}}
+
+	if _, err := s.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		s.log(ctx).Error("error upserting role binding", slog.String("subject", binding.Subject), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *MongoRoleStore) Delete(ctx context.Context, subject string) error {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+	filter = append(filter, bson.E{Key: "subject", Value: subject})
+
+	r, err := s.coll.DeleteOne(ctx, filter)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting role binding", slog.String("subject", subject), slog.String("error", err.Error()))
+		return err
+	}
+
+	if r.DeletedCount == 0 {
+		return ErrRoleBindingNotFound
+	}
+	return nil
+}
+
+// DeleteAll deletes every role binding of the tenant and returns how many were deleted
+func (s *MongoRoleStore) DeleteAll(ctx context.Context) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := s.coll.DeleteMany(ctx, filter)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant role bindings", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.DeletedCount, nil
+}
@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+const (
+	// broadcastHistory is how many changes are kept to resume from
+	broadcastHistory = 256
+	// watcherBuffer is how many changes a watcher can fall behind before it is dropped
+	watcherBuffer = 64
+)
+
+// broadcastedStore is the store BroadcastScheduleStore wraps
+type broadcastedStore interface {
+	GetByID(ctx context.Context, id string) (*model.Schedule, error)
+	Get(ctx context.Context, filter model.ScheduleFilter, pagination *types.PaginationOps) (int64, []model.Schedule, error)
+	Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error)
+	Delete(ctx context.Context, id string, version int64) error
+	Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error)
+	Restore(ctx context.Context, schedule model.Schedule) error
+	DeleteAll(ctx context.Context) (int64, error)
+	CountActive(ctx context.Context) ([]model.ScheduleCount, error)
+}
+
+// BroadcastScheduleStore publishes the writes made through the store it wraps to the watchers of the process.
+// it stands in for the mongo change streams when mongo is not a replica set, and for the sqlite store.
+// the writes of other processes are not seen, so it only fits a single replica.
+type BroadcastScheduleStore struct {
+	broadcastedStore
+	// epoch tells the tokens of this process from the ones of a previous one
+	epoch string
+
+	mu sync.Mutex
+	// seq is the sequence of the last change
+	seq uint64
+	// history holds the last changes, oldest first
+	history  []model.ScheduleChange
+	watchers map[*broadcastWatcher]struct{}
+}
+
+func NewBroadcastScheduleStore(s broadcastedStore) *BroadcastScheduleStore {
+	epoch := make([]byte, 4)
+	_, _ = rand.Read(epoch)
+
+	return &BroadcastScheduleStore{
+		broadcastedStore: s,
+		epoch:            hex.EncodeToString(epoch),
+		watchers:         make(map[*broadcastWatcher]struct{}),
+	}
+}
+
+func (s *BroadcastScheduleStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("store", "broadcast"))
+}
+
+func (s *BroadcastScheduleStore) Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error) {
+	id, err := s.broadcastedStore.Create(ctx, schedule)
+
+	if err != nil {
+		return "", err
+	}
+
+	if created, err := s.broadcastedStore.GetByID(ctx, id); err != nil {
+		s.log(ctx).Error("error getting created schedule, the insert is not broadcast", slog.String("id", id), slog.String("error", err.Error()))
+	} else {
+		s.publish(model.ChangeInsert, *created)
+	}
+	return id, nil
+}
+
+func (s *BroadcastScheduleStore) Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error) {
+	updated, err := s.broadcastedStore.Update(ctx, id, version, schedule)
+
+	if err != nil {
+		return nil, err
+	}
+	s.publish(model.ChangeUpdate, *updated)
+	return updated, nil
+}
+
+// Restore publishes an insert, like the mongo change streams do
+func (s *BroadcastScheduleStore) Restore(ctx context.Context, schedule model.Schedule) error {
+	if err := s.broadcastedStore.Restore(ctx, schedule); err != nil {
+		return err
+	}
+
+	if restored, err := s.broadcastedStore.GetByID(ctx, schedule.ID); err != nil {
+		s.log(ctx).Error("error getting restored schedule, the insert is not broadcast", slog.String("id", schedule.ID), slog.String("error", err.Error()))
+	} else {
+		s.publish(model.ChangeInsert, *restored)
+	}
+	return nil
+}
+
+// Delete reads the schedule before deleting it, the watchers are filtered on it
+func (s *BroadcastScheduleStore) Delete(ctx context.Context, id string, version int64) error {
+	before, _ := s.broadcastedStore.GetByID(ctx, id)
+
+	if err := s.broadcastedStore.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	if before != nil {
+		s.publish(model.ChangeDelete, *before)
+	}
+	return nil
+}
+
+// DeleteAll publishes a delete per schedule, like the mongo change streams do
+func (s *BroadcastScheduleStore) DeleteAll(ctx context.Context) (int64, error) {
+	_, schedules, err := s.broadcastedStore.Get(ctx, model.ScheduleFilter{}, &types.PaginationOps{})
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := s.broadcastedStore.DeleteAll(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+	for _, schedule := range schedules {
+		s.publish(model.ChangeDelete, schedule)
+	}
+	return n, nil
+}
+
+// Watch streams the changes of the schedules of the tenant matching the filter, after the resume token. "" starts from now.
+// it returns ErrChangesLost when the token is older than the history or comes from another process.
+func (s *BroadcastScheduleStore) Watch(ctx context.Context, f model.ScheduleFilter, after string) (ChangeStream, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	w := &broadcastWatcher{
+		store:    s,
+		tenantID: tenantID,
+		filter:   f,
+		changes:  make(chan model.ScheduleChange, watcherBuffer),
+		lost:     make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if after != "" {
+		seq, err := s.parseToken(after)
+
+		// the history has every change after seq
+		if err != nil || seq > s.seq || s.seq-seq > uint64(len(s.history)) {
+			return nil, ErrChangesLost
+		}
+		for _, change := range s.history[uint64(len(s.history))-(s.seq-seq):] {
+			if w.matches(change) {
+				w.pending = append(w.pending, change)
+			}
+		}
+	}
+
+	s.watchers[w] = struct{}{}
+	return w, nil
+}
+
+// publish sends the change to the matching watchers. a watcher too far behind is dropped, its next Next is ErrChangesLost.
+func (s *BroadcastScheduleStore) publish(typ model.ChangeType, schedule model.Schedule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	change := model.ScheduleChange{Token: fmt.Sprintf("%s-%d", s.epoch, s.seq), Type: typ, Schedule: schedule}
+
+	s.history = append(s.history, change)
+	if len(s.history) > broadcastHistory {
+		s.history = s.history[len(s.history)-broadcastHistory:]
+	}
+
+	for w := range s.watchers {
+		if !w.matches(change) {
+			continue
+		}
+		select {
+		case w.changes <- change:
+		default:
+			delete(s.watchers, w)
+			close(w.lost)
+		}
+	}
+}
+
+func (s *BroadcastScheduleStore) parseToken(token string) (uint64, error) {
+	epoch, seq, ok := strings.Cut(token, "-")
+
+	if !ok || epoch != s.epoch {
+		return 0, ErrChangesLost
+	}
+	return strconv.ParseUint(seq, 10, 64)
+}
+
+type broadcastWatcher struct {
+	store    *BroadcastScheduleStore
+	tenantID string
+	filter   model.ScheduleFilter
+	// pending are the changes replayed from the history, sent before the live ones
+	pending []model.ScheduleChange
+	changes chan model.ScheduleChange
+	// lost is closed when the watcher is dropped
+	lost chan struct{}
+}
+
+func (w *broadcastWatcher) Next(ctx context.Context) (model.ScheduleChange, error) {
+	if len(w.pending) > 0 {
+		change := w.pending[0]
+		w.pending = w.pending[1:]
+		return change, nil
+	}
+
+	select {
+	case change := <-w.changes:
+		return change, nil
+	case <-w.lost:
+		return model.ScheduleChange{}, ErrChangesLost
+	case <-ctx.Done():
+		return model.ScheduleChange{}, ctx.Err()
+	}
+}
+
+func (w *broadcastWatcher) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	delete(w.store.watchers, w)
+	return nil
+}
+
+func (w *broadcastWatcher) matches(change model.ScheduleChange) bool {
+	s, f := change.Schedule, w.filter
+	return s.TenantID == w.tenantID &&
+		(f.CreatedBy == "" || s.CreatedBy == f.CreatedBy) &&
+		(f.ActionID == "" || s.ActionID == f.ActionID) &&
+		(f.Type == "" || s.Expression.Type == f.Type) &&
+		(f.Name == "" || s.Name == f.Name)
+}
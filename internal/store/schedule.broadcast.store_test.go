@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/store/storetest"
+)
+
+func TestBroadcastScheduleStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.ScheduleStore {
+		return store.NewBroadcastScheduleStore(storetest.NewMemoryScheduleStore())
+	})
+}
+
+func TestBroadcastWatch(t *testing.T) {
+	ctx := requestctx.WithTenant(context.Background(), "acme")
+	s := store.NewBroadcastScheduleStore(storetest.NewMemoryScheduleStore())
+
+	next := func(t *testing.T, w store.ChangeStream) model.ScheduleChange {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		change, err := w.Next(ctx)
+		if err != nil {
+			t.Fatalf("error getting the next change: %v", err)
+		}
+		return change
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		w, err := s.Watch(ctx, model.ScheduleFilter{CreatedBy: "alice"}, "")
+		if err != nil {
+			t.Fatalf("error watching: %v", err)
+		}
+		defer w.Close()
+
+		// bob's schedule and the other tenant's are filtered out
+		other := requestctx.WithTenant(context.Background(), "globex")
+		if _, err := s.Create(other, &model.CreateScheduleInput{Name: "digest", CreatedBy: "alice"}); err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+		if _, err := s.Create(ctx, &model.CreateScheduleInput{Name: "digest", CreatedBy: "bob"}); err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+		id, err := s.Create(ctx, &model.CreateScheduleInput{Name: "digest", CreatedBy: "alice"})
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+		created, _ := s.GetByID(ctx, id)
+		if _, err := s.Update(ctx, id, created.Version, *created); err != nil {
+			t.Fatalf("error updating: %v", err)
+		}
+		if err := s.Delete(ctx, id, created.Version+1); err != nil {
+			t.Fatalf("error deleting: %v", err)
+		}
+
+		for _, want := range []model.ChangeType{model.ChangeInsert, model.ChangeUpdate, model.ChangeDelete} {
+			if change := next(t, w); change.Type != want || change.Schedule.ID != id {
+				t.Errorf("expected %s of %s. got=%s of %s", want, id, change.Type, change.Schedule.ID)
+			}
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		w, err := s.Watch(ctx, model.ScheduleFilter{}, "")
+		if err != nil {
+			t.Fatalf("error watching: %v", err)
+		}
+		first, _ := s.Create(ctx, &model.CreateScheduleInput{Name: "first"})
+		second, _ := s.Create(ctx, &model.CreateScheduleInput{Name: "second"})
+		token := next(t, w).Token
+		w.Close()
+
+		w, err = s.Watch(ctx, model.ScheduleFilter{}, token)
+		if err != nil {
+			t.Fatalf("error resuming: %v", err)
+		}
+		defer w.Close()
+		if change := next(t, w); change.Schedule.ID != second {
+			t.Errorf("expected the change after %s. got=%s", first, change.Schedule.ID)
+		}
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		for _, token := range []string{"nope", "00000000-1", "ffffffff-ffff"} {
+			if _, err := s.Watch(ctx, model.ScheduleFilter{}, token); !errors.Is(err, store.ErrChangesLost) {
+				t.Errorf("expected ErrChangesLost for %q. got=%v", token, err)
+			}
+		}
+
+		// a watcher too far behind is dropped
+		w, err := s.Watch(ctx, model.ScheduleFilter{}, "")
+		if err != nil {
+			t.Fatalf("error watching: %v", err)
+		}
+		defer w.Close()
+		for i := 0; i < 100; i++ {
+			if _, err := s.Create(ctx, &model.CreateScheduleInput{Name: "spam"}); err != nil {
+				t.Fatalf("error creating: %v", err)
+			}
+		}
+		for {
+			if _, err := w.Next(ctx); err != nil {
+				if !errors.Is(err, store.ErrChangesLost) {
+					t.Errorf("expected ErrChangesLost. got=%v", err)
+				}
+				break
+			}
+		}
+	})
+
+	t.Run("no tenant", func(t *testing.T) {
+		if _, err := s.Watch(context.Background(), model.ScheduleFilter{}, ""); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected ErrMissingTenant. got=%v", err)
+		}
+	})
+}
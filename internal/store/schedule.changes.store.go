@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrChangesLost is returned when the changes after a resume token are no longer available
+var ErrChangesLost = errors.New("changes after the resume token are lost")
+
+// ChangeStream is a subscription to the schedule changes, see MongoScheduleStore.Watch
+type ChangeStream interface {
+	// Next blocks until the next change or until ctx is done
+	Next(ctx context.Context) (model.ScheduleChange, error)
+	Close() error
+}
+
+// server error codes of a resume token that can't be resumed
+const (
+	codeInvalidResumeToken      = 260
+	codeChangeStreamFatalError  = 280
+	codeChangeStreamHistoryLost = 286
+)
+
+// Watch streams the changes of the schedules of the tenant matching the filter, after the resume token. "" starts from now.
+// it needs a replica set. deletes are only seen when the collection records pre-images, see EnableChangePreImages.
+func (s *MongoScheduleStore) Watch(ctx context.Context, f model.ScheduleFilter, after string) (ChangeStream, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	// inserts and updates are matched on the document after the change, deletes on the document before it
+	match := func(document string) bson.D {
+		d := bson.D{{Key: document + ".tenant_id", Value: tenantID}}
+		if f.CreatedBy != "" {
+			d = append(d, bson.E{Key: document + ".created_by", Value: f.CreatedBy})
+		}
+		if f.ActionID != "" {
+			d = append(d, bson.E{Key: document + ".action", Value: f.ActionID})
+		}
+		if f.Type != "" {
+			d = append(d, bson.E{Key: document + ".expression.type", Value: f.Type})
+		}
+		if f.Name != "" {
+			d = append(d, bson.E{Key: document + ".name", Value: f.Name})
+		}
+		return d
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.D{
+		{Key: "operationType", Value: bson.D{{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete"}}}},
+		{Key: "$or", Value: bson.A{match("fullDocument"), match("fullDocumentBeforeChange")}},
+	}}}}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup).SetFullDocumentBeforeChange(options.WhenAvailable)
+
+	if after != "" {
+		token, err := base64.RawURLEncoding.DecodeString(after)
+
+		if err != nil {
+			return nil, ErrChangesLost
+		}
+		opts.SetResumeAfter(bson.Raw(token))
+	}
+
+	cs, err := s.coll.Watch(ctx, pipeline, opts)
+
+	if err != nil {
+		if changesLost(err) {
+			return nil, ErrChangesLost
+		}
+		s.log(ctx).Error("error watching schedules", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &mongoChangeStream{cs: cs}, nil
+}
+
+// EnableChangePreImages makes the collection record the documents before their changes, which Watch needs to see deletes.
+// pre-images need mongo 6.
+func (s *MongoScheduleStore) EnableChangePreImages(ctx context.Context) error {
+	preImages := bson.D{{Key: "enabled", Value: true}}
+	err := s.coll.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: s.coll.Name()},
+		{Key: "changeStreamPreAndPostImages", Value: preImages},
+	}).Err()
+
+	// the collection is created by the first insert otherwise
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(26) {
+		err = s.coll.Database().CreateCollection(ctx, s.coll.Name(), options.CreateCollection().SetChangeStreamPreAndPostImages(preImages))
+	}
+	return err
+}
+
+type mongoChangeStream struct {
+	cs *mongo.ChangeStream
+}
+
+func (c *mongoChangeStream) Next(ctx context.Context) (model.ScheduleChange, error) {
+	for c.cs.Next(ctx) {
+		var event struct {
+			OperationType string          `bson:"operationType"`
+			After         *model.Schedule `bson:"fullDocument"`
+			Before        *model.Schedule `bson:"fullDocumentBeforeChange"`
+		}
+
+		if err := c.cs.Decode(&event); err != nil {
+			return model.ScheduleChange{}, err
+		}
+
+		change := model.ScheduleChange{Token: base64.RawURLEncoding.EncodeToString(c.cs.ResumeToken()), Type: model.ChangeUpdate}
+		schedule := event.After
+
+		switch event.OperationType {
+		case "insert":
+			change.Type = model.ChangeInsert
+		case "delete":
+			change.Type = model.ChangeDelete
+			schedule = event.Before
+		}
+		// an update of a schedule deleted since has no document after the change
+		if schedule == nil {
+			schedule = event.Before
+		}
+		if schedule == nil {
+			continue
+		}
+		change.Schedule = *schedule
+		return change, nil
+	}
+
+	err := c.cs.Err()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if changesLost(err) {
+		return model.ScheduleChange{}, ErrChangesLost
+	}
+	return model.ScheduleChange{}, err
+}
+
+func (c *mongoChangeStream) Close() error {
+	return c.cs.Close(context.Background())
+}
+
+// changesLost reports whether the error is a resume token that can't be resumed
+func changesLost(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(codeInvalidResumeToken) || serverErr.HasErrorCode(codeChangeStreamFatalError) || serverErr.HasErrorCode(codeChangeStreamHistoryLost))
+}
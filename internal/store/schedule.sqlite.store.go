@@ -0,0 +1,324 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+const scheduleColumns = "id, tenant_id, name, action, created_by, client_token, expression_type, expression_start, expression_end, payload, version"
+
+// SQLiteScheduleStore keeps the schedules in the embedded database opened by the sqlite package.
+// ids are 24 hex characters like the ObjectIDs of the mongo store, so both fit the same scheduler names and URLs.
+type SQLiteScheduleStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteScheduleStore(db *sql.DB) *SQLiteScheduleStore {
+	return &SQLiteScheduleStore{db: db}
+}
+
+func (s *SQLiteScheduleStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "schedule"))
+}
+
+// GetByID returns the schedule with the given id
+func (s *SQLiteScheduleStore) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM schedule WHERE tenant_id = ? AND id = ?", tenantID, id)
+	schedule, err := scanSchedule(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		s.log(ctx).Error("error getting schedule by id", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Get returns the schedules of the tenant in insertion order with pagination. Pagination is Zero based
+func (s *SQLiteScheduleStore) Get(ctx context.Context, f model.ScheduleFilter, pagination *types.PaginationOps) (count int64, schedules []model.Schedule, err error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, nil, ErrMissingTenant
+	}
+
+	where := []string{"tenant_id = ?"}
+	args := []any{tenantID}
+
+	if f.CreatedBy != "" {
+		where, args = append(where, "created_by = ?"), append(args, f.CreatedBy)
+	}
+	if f.ActionID != "" {
+		where, args = append(where, "action = ?"), append(args, f.ActionID)
+	}
+	if f.Type != "" {
+		where, args = append(where, "expression_type = ?"), append(args, string(f.Type))
+	}
+	if f.Name != "" {
+		where, args = append(where, "name = ?"), append(args, f.Name)
+	}
+	conditions := strings.Join(where, " AND ")
+
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schedule WHERE "+conditions, args...).Scan(&count); err != nil {
+		s.log(ctx).Error("error counting schedules", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	limit, offset := limitOffset(pagination)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+scheduleColumns+" FROM schedule WHERE "+conditions+" ORDER BY rowid LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+
+	if err != nil {
+		s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
+			return 0, nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
+		return 0, nil, err
+	}
+
+	return count, schedules, nil
+}
+
+// Create inserts the schedule. the tenant is always taken from the context.
+func (s *SQLiteScheduleStore) Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	schedule.TenantID = tenantID
+	schedule.Version = 1
+
+	id, err := newID()
+
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(schedule.Payload)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload. error=%w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT INTO schedule ("+scheduleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, tenantID, schedule.Name, schedule.ActionID, schedule.CreatedBy, schedule.ClientToken,
+		string(schedule.Expression.Type), formatTime(schedule.Expression.Start), formatTime(schedule.Expression.End), string(payload), schedule.Version)
+
+	if err != nil {
+		s.log(ctx).Error("error creating schedule", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Delete deletes the schedule if it is still at the given version, types.AnyVersion deletes it whatever the version.
+// it returns ErrVersionMismatch when the schedule was written since that version.
+func (s *SQLiteScheduleStore) Delete(ctx context.Context, id string, version int64) error {
+	if !validID(id) {
+		return ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	query, args := "DELETE FROM schedule WHERE tenant_id = ? AND id = ?", []any{tenantID, id}
+	if version != types.AnyVersion {
+		query, args = query+" AND version = ?", append(args, version)
+	}
+
+	r, err := s.db.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting schedule", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		if version == types.AnyVersion {
+			return ErrScheduleNotFound
+		}
+		return s.notMatched(ctx, tenantID, id)
+	}
+
+	return nil
+}
+
+// Update replaces the name, action, expression and payload of the schedule if it is still at the given version, and increments the version.
+// it returns ErrVersionMismatch when the schedule was written since that version.
+func (s *SQLiteScheduleStore) Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+
+	payload, err := json.Marshal(schedule.Payload)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload. error=%w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, "UPDATE schedule SET name = ?, action = ?, expression_type = ?, expression_start = ?, expression_end = ?, payload = ?, version = version + 1 WHERE tenant_id = ? AND id = ? AND version = ? RETURNING "+scheduleColumns,
+		schedule.Name, schedule.ActionID, string(schedule.Expression.Type), formatTime(schedule.Expression.Start), formatTime(schedule.Expression.End), string(payload), tenantID, id, version)
+	updated, err := scanSchedule(row)
+
+	if err == nil {
+		return updated, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		s.log(ctx).Error("error updating schedule", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return nil, s.notMatched(ctx, tenantID, id)
+}
+
+// notMatched tells why a write at a version matched nothing: either the schedule is gone or it is at another version
+func (s *SQLiteScheduleStore) notMatched(ctx context.Context, tenantID, id string) error {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schedule WHERE tenant_id = ? AND id = ?)", tenantID, id).Scan(&exists); err != nil {
+		s.log(ctx).Error("error checking schedule version", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	if !exists {
+		return ErrScheduleNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// Restore inserts a deleted schedule again, with its id. the tenant is always taken from the context.
+func (s *SQLiteScheduleStore) Restore(ctx context.Context, schedule model.Schedule) error {
+	if !validID(schedule.ID) {
+		return ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	payload, err := json.Marshal(schedule.Payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to encode payload. error=%w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT INTO schedule ("+scheduleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		schedule.ID, tenantID, schedule.Name, schedule.ActionID, schedule.CreatedBy, schedule.ClientToken,
+		string(schedule.Expression.Type), formatTime(schedule.Expression.Start), formatTime(schedule.Expression.End), string(payload), schedule.Version)
+
+	if err != nil {
+		s.log(ctx).Error("error restoring schedule", slog.String("id", schedule.ID), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes every schedule of the tenant and returns how many were deleted
+func (s *SQLiteScheduleStore) DeleteAll(ctx context.Context) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM schedule WHERE tenant_id = ?", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant schedules", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return r.RowsAffected()
+}
+
+// CountActive counts the schedules of every tenant by expression type and action
+func (s *SQLiteScheduleStore) CountActive(ctx context.Context) ([]model.ScheduleCount, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT expression_type, action, COUNT(*) FROM schedule GROUP BY expression_type, action")
+
+	if err != nil {
+		s.log(ctx).Error("error counting schedules", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]model.ScheduleCount, 0)
+	for rows.Next() {
+		var count model.ScheduleCount
+
+		if err := rows.Scan(&count.Type, &count.ActionID, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func scanSchedule(row scanner) (*model.Schedule, error) {
+	var (
+		schedule   model.Schedule
+		start, end string
+		payload    string
+	)
+
+	err := row.Scan(&schedule.ID, &schedule.TenantID, &schedule.Name, &schedule.ActionID, &schedule.CreatedBy, &schedule.ClientToken,
+		&schedule.Expression.Type, &start, &end, &payload, &schedule.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.Expression.Start, err = time.Parse(time.RFC3339Nano, start); err != nil {
+		return nil, fmt.Errorf("invalid start of schedule %s. error=%w", schedule.ID, err)
+	}
+	if schedule.Expression.End, err = time.Parse(time.RFC3339Nano, end); err != nil {
+		return nil, fmt.Errorf("invalid end of schedule %s. error=%w", schedule.ID, err)
+	}
+	if err := json.Unmarshal([]byte(payload), &schedule.Payload); err != nil {
+		return nil, fmt.Errorf("invalid payload of schedule %s. error=%w", schedule.ID, err)
+	}
+	return &schedule, nil
+}
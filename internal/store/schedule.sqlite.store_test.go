@@ -0,0 +1,192 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/database/sqlite"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/store/storetest"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestSQLiteScheduleStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.ScheduleStore {
+		db, err := sqlite.Open(context.Background(), ":memory:")
+		if err != nil {
+			t.Fatalf("error opening database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return store.NewSQLiteScheduleStore(db)
+	})
+}
+
+// the behaviour specific to sqlite: a database file that outlives the process, ids shaped like ObjectIDs and times kept to the nanosecond
+func TestSQLiteScheduleStoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.db")
+	db, err := sqlite.Open(context.Background(), path)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	s := store.NewSQLiteScheduleStore(db)
+
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	other := requestctx.WithTenant(context.Background(), "other")
+	start := time.Date(2030, 1, 2, 9, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	var ids []string
+	for _, input := range []model.CreateScheduleInput{
+		{Name: "digest", ActionID: "email", CreatedBy: "u1", Expression: model.Expression{Type: model.DailyExpression, Start: start}, Payload: map[string]any{"to": "a@b.c"}},
+		{Name: "report", ActionID: "email", CreatedBy: "u2", Expression: model.Expression{Type: model.MonthlyExpression}},
+		{Name: "ping", ActionID: "sms", CreatedBy: "u1", Expression: model.Expression{Type: model.DailyExpression}},
+	} {
+		// the tenant of the input is ignored
+		input.TenantID = "other"
+		id, err := s.Create(acme, &input)
+		if err != nil {
+			t.Fatalf("error creating %s: %v", input.Name, err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := s.Create(other, &model.CreateScheduleInput{Name: "elsewhere", ActionID: "email", Expression: model.Expression{Type: model.OneTimeExpression}}); err != nil {
+		t.Fatalf("error creating: %v", err)
+	}
+	if _, err := s.Create(context.Background(), &model.CreateScheduleInput{}); !errors.Is(err, store.ErrMissingTenant) {
+		t.Errorf("expected %v without tenant. got=%v", store.ErrMissingTenant, err)
+	}
+
+	got, err := s.GetByID(acme, ids[0])
+	if err != nil {
+		t.Fatalf("error getting schedule: %v", err)
+	}
+	if got.ID != ids[0] || len(got.ID) != 24 || got.TenantID != "acme" || got.Name != "digest" || got.Payload["to"] != "a@b.c" || !got.Expression.Start.Equal(start) || !got.Expression.End.IsZero() {
+		t.Errorf("unexpected schedule %+v", got)
+	}
+	if _, err := s.GetByID(other, ids[0]); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("expected %v from another tenant. got=%v", store.ErrScheduleNotFound, err)
+	}
+
+	tests := []struct {
+		name      string
+		filter    model.ScheduleFilter
+		page      types.PaginationOps
+		wantCount int64
+		wantNames []string
+	}{
+		{name: "all", wantCount: 3, wantNames: []string{"digest", "report", "ping"}},
+		{name: "paginated", page: types.PaginationOps{Limit: 2, Page: 1}, wantCount: 3, wantNames: []string{"ping"}},
+		{name: "filtered", filter: model.ScheduleFilter{CreatedBy: "u1", Type: model.DailyExpression}, wantCount: 2, wantNames: []string{"digest", "ping"}},
+		{name: "by name", filter: model.ScheduleFilter{ActionID: "email", Name: "report"}, wantCount: 1, wantNames: []string{"report"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, schedules, err := s.Get(acme, tt.filter, &tt.page)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, sch := range schedules {
+				names = append(names, sch.Name)
+			}
+			if count != tt.wantCount || len(names) != len(tt.wantNames) {
+				t.Fatalf("expected %d schedules %v. got=%d %v", tt.wantCount, tt.wantNames, count, names)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("expected %v. got=%v", tt.wantNames, names)
+				}
+			}
+		})
+	}
+
+	got.Name = "weekly digest"
+	got.Expression.Type = model.WeeklyExpression
+	updated, err := s.Update(acme, ids[0], got.Version, *got)
+	if err != nil {
+		t.Fatalf("error updating: %v", err)
+	}
+	if updated.Name != "weekly digest" || updated.Expression.Type != model.WeeklyExpression || updated.CreatedBy != "u1" {
+		t.Errorf("unexpected update %+v", updated)
+	}
+	if _, err := s.Update(other, ids[0], updated.Version, *got); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("expected %v updating from another tenant. got=%v", store.ErrScheduleNotFound, err)
+	}
+
+	counts, err := s.CountActive(context.Background())
+	if err != nil {
+		t.Fatalf("error counting: %v", err)
+	}
+	if len(counts) != 4 {
+		t.Errorf("expected 4 groups across tenants. got=%+v", counts)
+	}
+
+	if err := s.Delete(acme, ids[1], 1); err != nil {
+		t.Fatalf("error deleting: %v", err)
+	}
+	if err := s.Delete(acme, ids[1], types.AnyVersion); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("expected %v deleting twice. got=%v", store.ErrScheduleNotFound, err)
+	}
+	if n, err := s.DeleteAll(acme); err != nil || n != 2 {
+		t.Errorf("expected the 2 schedules left deleted. got=%d %v", n, err)
+	}
+	db.Close()
+
+	// reopening applies no migration twice and keeps the rows
+	db, err = sqlite.Open(context.Background(), path)
+	if err != nil {
+		t.Fatalf("error reopening database: %v", err)
+	}
+	defer db.Close()
+	if count, _, err := store.NewSQLiteScheduleStore(db).Get(other, model.ScheduleFilter{}, &types.PaginationOps{}); err != nil || count != 1 {
+		t.Errorf("expected the schedule of the other tenant after reopening. got=%d %v", count, err)
+	}
+}
+
+// rows stored before the version column was added are at version 0 and can still be updated and deleted
+func TestSQLiteUnversionedSchedule(t *testing.T) {
+	db, err := sqlite.Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+	s := store.NewSQLiteScheduleStore(db)
+	acme := requestctx.WithTenant(context.Background(), "acme")
+
+	id, zero := "65a1b2c3d4e5f60718293a4b", "0001-01-01T00:00:00.000000000Z"
+	_, err = db.Exec(`INSERT INTO schedule (id, tenant_id, name, action, created_by, client_token, expression_type, expression_start, expression_end, payload)
+		VALUES (?, 'acme', 'digest', 'email', 'u1', '', 'daily', ?, ?, '{}')`, id, zero, zero)
+	if err != nil {
+		t.Fatalf("error inserting: %v", err)
+	}
+
+	got, err := s.GetByID(acme, id)
+	if err != nil {
+		t.Fatalf("error getting: %v", err)
+	}
+	if got.Version != 0 || !got.Expression.Start.IsZero() {
+		t.Errorf("expected the row at version 0. got=%+v", got)
+	}
+
+	if _, err := s.Update(acme, id, 1, *got); !errors.Is(err, store.ErrVersionMismatch) {
+		t.Errorf("expected %v updating at version 1. got=%v", store.ErrVersionMismatch, err)
+	}
+	got.Name = "weekly digest"
+	updated, err := s.Update(acme, id, 0, *got)
+	if err != nil {
+		t.Fatalf("error updating: %v", err)
+	}
+	if updated.Version != 1 || updated.Name != "weekly digest" {
+		t.Errorf("expected the row at version 1. got=%+v", updated)
+	}
+	if err := s.Delete(acme, id, 0); !errors.Is(err, store.ErrVersionMismatch) {
+		t.Errorf("expected %v deleting at version 0. got=%v", store.ErrVersionMismatch, err)
+	}
+	if err := s.Delete(acme, id, 1); err != nil {
+		t.Errorf("error deleting: %v", err)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"os"
+	"time"
 
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/metrics"
 	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
 	"github.com/japb1998/action-scheduler/internal/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,21 +21,35 @@ import (
 var (
 	ErrScheduleNotFound = errors.New("schedule not found")
 	ErrInvalidID        = errors.New("invalid id")
+	ErrMissingTenant    = errors.New("no tenant in context")
+	ErrVersionMismatch  = errors.New("schedule version mismatch")
 )
 
+// tenantFilter returns a filter scoped to the tenant of the context. every query must start from it.
+func tenantFilter(ctx context.Context) (bson.D, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+	return bson.D{bson.E{Key: "tenant_id", Value: tenantID}}, nil
+}
+
 type MongoScheduleStore struct {
-	coll   *mongo.Collection
-	logger *slog.Logger
+	coll *mongo.Collection
 }
 
-func NewMongoScheduleStore(c *mongo.Client) *MongoScheduleStore {
+func NewMongoScheduleStore(c *mongo.Client, database string) *MongoScheduleStore {
 
 	return &MongoScheduleStore{
-		coll:   c.Database("notification-scheduler").Collection("schedule"),
-		logger: slog.New(slog.NewTextHandler(os.Stdout, nil).WithAttrs([]slog.Attr{slog.String("package", "store"), slog.String("collection", "schedule")})),
+		coll: c.Database(database).Collection("schedule"),
 	}
 }
 
+func (s *MongoScheduleStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "schedule"))
+}
+
 // Get by Id returns the schedule with the given id
 func (s *MongoScheduleStore) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
 	bsonId, err := primitive.ObjectIDFromHex(id)
@@ -40,18 +57,24 @@ func (s *MongoScheduleStore) GetByID(ctx context.Context, id string) (*model.Sch
 	if err != nil {
 		return nil, ErrInvalidID
 	}
-	filter := bson.D{bson.E{
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	filter = append(filter, bson.E{
 		Key:   "_id",
 		Value: bsonId,
-	},
-	}
+	})
 
 	var schedule model.Schedule
 
+	start := time.Now()
 	err = s.coll.FindOne(ctx, filter).Decode(&schedule)
+	s.observe("find_one", start, err)
 
 	if err != nil {
-		s.logger.Error("error getting schedule by id", slog.String("id", id), slog.String("error", err.Error()))
+		s.log(ctx).Error("error getting schedule by id", slog.String("id", id), slog.String("error", err.Error()))
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrScheduleNotFound
 		}
@@ -60,56 +83,111 @@ func (s *MongoScheduleStore) GetByID(ctx context.Context, id string) (*model.Sch
 	return &schedule, nil
 }
 
-// GetAll returns all the schedules with pagination. Pagination is Zero based
-func (s *MongoScheduleStore) Get(ctx context.Context, pagination *types.PaginationOps) (count int64, schedules []model.Schedule, err error) {
+// GetAll returns all the schedules of the tenant with pagination. Pagination is Zero based
+func (s *MongoScheduleStore) Get(ctx context.Context, f model.ScheduleFilter, pagination *types.PaginationOps) (count int64, schedules []model.Schedule, err error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if f.CreatedBy != "" {
+		filter = append(filter, bson.E{Key: "created_by", Value: f.CreatedBy})
+	}
+	if f.ActionID != "" {
+		filter = append(filter, bson.E{Key: "action", Value: f.ActionID})
+	}
+	if f.Type != "" {
+		filter = append(filter, bson.E{Key: "expression.type", Value: f.Type})
+	}
+	if f.Name != "" {
+		filter = append(filter, bson.E{Key: "name", Value: f.Name})
+	}
 	ops := options.Find().SetSkip(int64(pagination.Limit * pagination.Page)).SetLimit(int64(pagination.Limit))
 
-	cursor, err := s.coll.Find(ctx, bson.D{}, ops)
+	start := time.Now()
+	cursor, err := s.coll.Find(ctx, filter, ops)
 
 	if err != nil {
-		s.logger.Error("error getting all schedules", slog.String("error", err.Error()))
+		s.observe("find", start, err)
+		s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
 		return 0, nil, err
 	}
 
-	if err = cursor.All(ctx, &schedules); err != nil {
-		s.logger.Error("error getting all schedules", slog.String("error", err.Error()))
+	err = cursor.All(ctx, &schedules)
+	s.observe("find", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
 		return 0, nil, err
 	}
 
-	if count, err = s.coll.CountDocuments(ctx, bson.D{}); err != nil {
-		s.logger.Error("error getting all schedules", slog.String("error", err.Error()))
+	start = time.Now()
+	count, err = s.coll.CountDocuments(ctx, filter)
+	s.observe("count", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error getting all schedules", slog.String("error", err.Error()))
 		return 0, nil, err
 	}
 
 	return count, schedules, nil
 }
 
-func (s *MongoScheduleStore) Delete(ctx context.Context, id string) error {
+// Delete deletes the schedule if it is still at the given version, types.AnyVersion deletes it whatever the version.
+// it returns ErrVersionMismatch when the schedule was written since that version.
+func (s *MongoScheduleStore) Delete(ctx context.Context, id string, version int64) error {
 	bsonId, err := primitive.ObjectIDFromHex(id)
 
 	if err != nil {
 		return ErrInvalidID
 	}
-	filter := bson.D{bson.E{"_id", bsonId}}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+	filter = append(filter, bson.E{Key: "_id", Value: bsonId})
+
+	deleteFilter := filter
+	if version != types.AnyVersion {
+		deleteFilter = append(filter, bson.E{Key: "version", Value: versionFilter(version)})
+	}
 
-	r, err := s.coll.DeleteOne(ctx, filter)
+	start := time.Now()
+	r, err := s.coll.DeleteOne(ctx, deleteFilter)
+	s.observe("delete_one", start, err)
 
 	if err != nil {
-		s.logger.Error("error deleting schedule", slog.String("id", id), slog.String("error", err.Error()))
+		s.log(ctx).Error("error deleting schedule", slog.String("id", id), slog.String("error", err.Error()))
 		return err
 	}
 
 	if r.DeletedCount == 0 {
-		return ErrScheduleNotFound
+		if version == types.AnyVersion {
+			return ErrScheduleNotFound
+		}
+		return s.notMatched(ctx, id, filter)
 	}
 
 	return nil
 }
 
+// Create inserts the schedule. the tenant is always taken from the context.
 func (s *MongoScheduleStore) Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	schedule.TenantID = tenantID
+	schedule.Version = 1
+
+	start := time.Now()
 	r, err := s.coll.InsertOne(ctx, schedule)
+	s.observe("insert_one", start, err)
 	if err != nil {
-		s.logger.Error("error creating schedule", slog.String("error", err.Error()))
+		s.log(ctx).Error("error creating schedule", slog.String("error", err.Error()))
 		return "", err
 	}
 
@@ -122,6 +200,164 @@ func (s *MongoScheduleStore) Create(ctx context.Context, schedule *model.CreateS
 	return id.Hex(), nil
 }
 
-func (s *MongoScheduleStore) Update(c context.Context, id string, notification model.Schedule) (*model.Schedule, error) {
-	return nil, nil
+// Restore inserts a deleted schedule again, with its id. the tenant is always taken from the context.
+func (s *MongoScheduleStore) Restore(ctx context.Context, schedule model.Schedule) error {
+	bsonId, err := primitive.ObjectIDFromHex(schedule.ID)
+
+	if err != nil {
+		return ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	doc := bson.D{
+		{Key: "_id", Value: bsonId},
+		{Key: "tenant_id", Value: tenantID},
+		{Key: "expression", Value: schedule.Expression},
+		{Key: "payload", Value: schedule.Payload},
+		{Key: "created_by", Value: schedule.CreatedBy},
+		{Key: "action", Value: schedule.ActionID},
+		{Key: "client_token", Value: schedule.ClientToken},
+		{Key: "name", Value: schedule.Name},
+		{Key: "version", Value: schedule.Version},
+	}
+
+	start := time.Now()
+	_, err = s.coll.InsertOne(ctx, doc)
+	s.observe("insert_one", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error restoring schedule", slog.String("id", schedule.ID), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// DeleteAll deletes every schedule of the tenant and returns how many were deleted
+func (s *MongoScheduleStore) DeleteAll(ctx context.Context) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	r, err := s.coll.DeleteMany(ctx, filter)
+	s.observe("delete_many", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant schedules", slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return r.DeletedCount, nil
+}
+
+// CountActive counts the schedules of every tenant by expression type and action
+func (s *MongoScheduleStore) CountActive(ctx context.Context) ([]model.ScheduleCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "type", Value: "$expression.type"}, {Key: "action", Value: "$action"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "type", Value: "$_id.type"},
+			{Key: "action", Value: "$_id.action"},
+			{Key: "count", Value: 1},
+		}}},
+	}
+
+	start := time.Now()
+	counts := make([]model.ScheduleCount, 0)
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+
+	if err == nil {
+		err = cursor.All(ctx, &counts)
+	}
+	s.observe("aggregate", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error counting schedules", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// observe records the operation metrics. a missing document is not a failed operation.
+func (s *MongoScheduleStore) observe(operation string, start time.Time, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = nil
+	}
+	metrics.ObserveMongo("schedule", operation, start, err)
+}
+
+// Update replaces the name, action, expression and payload of the schedule if it is still at the given version, and increments the version.
+// it returns ErrVersionMismatch when the schedule was written since that version.
+func (s *MongoScheduleStore) Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error) {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	filter = append(filter, bson.E{Key: "_id", Value: bsonId})
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "name", Value: schedule.Name},
+			{Key: "action", Value: schedule.ActionID},
+			{Key: "expression", Value: schedule.Expression},
+			{Key: "payload", Value: schedule.Payload},
+			{Key: "version", Value: version + 1},
+		}},
+	}
+
+	var updated model.Schedule
+
+	start := time.Now()
+	err = s.coll.FindOneAndUpdate(ctx, append(filter, bson.E{Key: "version", Value: versionFilter(version)}), update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	s.observe("find_one_and_update", start, err)
+
+	if err == nil {
+		return &updated, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		s.log(ctx).Error("error updating schedule", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return nil, s.notMatched(ctx, id, filter)
+}
+
+// notMatched tells why a write at a version matched nothing: either the schedule is gone or it is at another version
+func (s *MongoScheduleStore) notMatched(ctx context.Context, id string, filter bson.D) error {
+	start := time.Now()
+	count, err := s.coll.CountDocuments(ctx, filter)
+	s.observe("count", start, err)
+
+	if err != nil {
+		s.log(ctx).Error("error checking schedule version", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+	if count == 0 {
+		return ErrScheduleNotFound
+	}
+	return ErrVersionMismatch
+}
+
+// versionFilter matches the version. schedules stored before versioning have no version field, they are at version 0.
+func versionFilter(version int64) any {
+	if version == 0 {
+		return bson.D{{Key: "$in", Value: bson.A{0, nil}}}
+	}
+	return version
 }
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// timeLayout is RFC 3339 with nanoseconds, fixed width so the times of a column sort and compare as text.
+// rows written with time.RFC3339Nano before still parse.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// scanner is a *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// parseTime parses a time of formatTime. the zero time comes back as the zero time.Time, without location.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+
+	if err != nil || t.IsZero() {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// newID returns 12 random bytes in hex
+func newID() (string, error) {
+	id := make([]byte, 12)
+
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate id. error=%w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// validID reports whether id is an id of newID. the ids of rows copied from mongo are ObjectIDs, also 12 bytes in hex.
+func validID(id string) bool {
+	if len(id) != 24 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// formatList encodes a list column as a JSON array
+func formatList(list []string) string {
+	by, _ := json.Marshal(list)
+	return string(by)
+}
+
+func parseList(s string) ([]string, error) {
+	var list []string
+	return list, json.Unmarshal([]byte(s), &list)
+}
+
+// formatJSON encodes a nullable JSON column, nil is NULL
+func formatJSON[T any](v *T) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	by, err := json.Marshal(v)
+	return sql.NullString{String: string(by), Valid: err == nil}, err
+}
+
+func parseJSON[T any](s sql.NullString) (*T, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var v T
+	return &v, json.Unmarshal([]byte(s.String), &v)
+}
+
+// limitOffset returns the LIMIT and OFFSET of the zero based page. a limit of 0 returns every row, as with mongo.
+func limitOffset(pagination *types.PaginationOps) (limit, offset int64) {
+	limit = int64(pagination.Limit)
+	if limit <= 0 {
+		limit = -1
+	}
+	return limit, int64(pagination.Limit * pagination.Page)
+}
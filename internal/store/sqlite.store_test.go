@@ -0,0 +1,222 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/database/sqlite"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// the stores of the collections kept next to the schedules in sqlite
+func TestSQLiteTenantData(t *testing.T) {
+	db, err := sqlite.Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer db.Close()
+
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	other := requestctx.WithTenant(context.Background(), "other")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("tenant", func(t *testing.T) {
+		tenants := store.NewSQLiteTenantStore(db)
+
+		if err := tenants.Upsert(acme, &model.Tenant{ID: "acme", Name: "Acme", TimeZone: "UTC", AllowedActions: []string{"1"}, UpdatedAt: now}); err != nil {
+			t.Fatalf("error upserting: %v", err)
+		}
+		if err := tenants.Upsert(acme, &model.Tenant{ID: "acme", Name: "Acme Inc", TimeZone: "UTC", UpdatedAt: now.Add(time.Hour)}); err != nil {
+			t.Fatalf("error upserting: %v", err)
+		}
+
+		got, err := tenants.GetByID(acme, "acme")
+		if err != nil {
+			t.Fatalf("error getting: %v", err)
+		}
+		if got.Name != "Acme Inc" || !got.CreatedAt.Equal(now) || !got.UpdatedAt.Equal(now.Add(time.Hour)) {
+			t.Errorf("expected the update to keep the creation time. got=%+v", got)
+		}
+
+		if err := tenants.Delete(acme, "acme"); err != nil {
+			t.Fatalf("error deleting: %v", err)
+		}
+		if _, err := tenants.GetByID(acme, "acme"); !errors.Is(err, store.ErrTenantNotFound) {
+			t.Errorf("expected %v. got=%v", store.ErrTenantNotFound, err)
+		}
+	})
+
+	t.Run("role", func(t *testing.T) {
+		roles := store.NewSQLiteRoleStore(db)
+
+		for _, role := range []model.Role{model.RoleScheduler, model.RoleAdmin} {
+			if err := roles.Upsert(acme, &model.RoleBinding{Subject: "u1", Role: role, Actions: []string{"1"}, UpdatedAt: now}); err != nil {
+				t.Fatalf("error upserting: %v", err)
+			}
+		}
+
+		if got, err := roles.GetBySubject(acme, "u1"); err != nil || got.Role != model.RoleAdmin || got.TenantID != "acme" {
+			t.Errorf("expected the binding replaced. got=%+v %v", got, err)
+		}
+		if _, err := roles.GetBySubject(other, "u1"); !errors.Is(err, store.ErrRoleBindingNotFound) {
+			t.Errorf("expected the binding of another tenant not to be found. got=%v", err)
+		}
+		if n, err := roles.DeleteAll(acme); err != nil || n != 1 {
+			t.Errorf("expected 1 binding deleted. got=%d %v", n, err)
+		}
+	})
+
+	t.Run("audit", func(t *testing.T) {
+		audit := store.NewSQLiteAuditStore(db)
+
+		for i, action := range []model.AuditAction{model.AuditCreate, model.AuditUpdate} {
+			entry := &model.AuditEntry{ScheduleID: "s1", Action: action, Actor: "u1", Timestamp: now.Add(time.Duration(i) * time.Millisecond), After: &model.Schedule{ID: "s1", Name: "digest"}}
+			if err := audit.Create(acme, entry); err != nil {
+				t.Fatalf("error creating: %v", err)
+			}
+		}
+		if err := audit.Create(other, &model.AuditEntry{ScheduleID: "s1", Action: model.AuditCreate, Timestamp: now}); err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+
+		count, entries, err := audit.Get(acme, model.AuditFilter{ScheduleID: "s1", From: now}, &types.PaginationOps{Limit: 1})
+		if err != nil {
+			t.Fatalf("error getting: %v", err)
+		}
+		if count != 2 || len(entries) != 1 || entries[0].Action != model.AuditUpdate || entries[0].After == nil || entries[0].After.Name != "digest" || entries[0].Before != nil {
+			t.Errorf("expected the newest of the 2 entries of the tenant. got=%d %+v", count, entries)
+		}
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		keys := store.NewSQLiteAPIKeyStore(db)
+
+		id, err := keys.Create(acme, &model.APIKey{Name: "ci", Hash: "h1", Actions: []string{"1"}, ReadOnly: true, CreatedAt: now})
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+
+		key, err := keys.GetByHash(context.Background(), "h1")
+		if err != nil {
+			t.Fatalf("error getting by hash: %v", err)
+		}
+		if key.ID != id || key.TenantID != "acme" || !key.ReadOnly || len(key.Actions) != 1 || !key.RevokedAt.IsZero() {
+			t.Errorf("unexpected key %+v", key)
+		}
+
+		if err := keys.Revoke(other, id, now); !errors.Is(err, store.ErrAPIKeyNotFound) {
+			t.Errorf("expected the key of another tenant not to be found. got=%v", err)
+		}
+		if err := keys.Revoke(acme, "not-an-id", now); !errors.Is(err, store.ErrInvalidID) {
+			t.Errorf("expected %v. got=%v", store.ErrInvalidID, err)
+		}
+		if n, err := keys.RevokeAll(acme, now); err != nil || n != 1 {
+			t.Fatalf("expected 1 key revoked. got=%d %v", n, err)
+		}
+		if _, err := keys.GetByHash(context.Background(), "h1"); !errors.Is(err, store.ErrAPIKeyNotFound) {
+			t.Errorf("expected revoked keys not to authenticate. got=%v", err)
+		}
+	})
+
+	t.Run("webhook and deliveries", func(t *testing.T) {
+		webhooks, deliveries := store.NewSQLiteWebhookStore(db), store.NewSQLiteDeliveryStore(db)
+
+		id, err := webhooks.Create(acme, &model.Webhook{URL: "https://example.com", Events: []string{types.EventScheduleCreated}, CreatedAt: now})
+		if err != nil {
+			t.Fatalf("error creating: %v", err)
+		}
+		if got, err := webhooks.Subscribed(acme, types.EventScheduleCreated); err != nil || len(got) != 1 || got[0].ID != id {
+			t.Errorf("expected the subscribed webhook. got=%+v %v", got, err)
+		}
+		if got, err := webhooks.Subscribed(acme, types.EventScheduleDeleted); err != nil || len(got) != 0 {
+			t.Errorf("expected no webhook subscribed to %s. got=%+v %v", types.EventScheduleDeleted, got, err)
+		}
+
+		err = deliveries.Enqueue(acme, []model.WebhookDelivery{
+			{WebhookID: id, Event: types.EventScheduleCreated, Status: types.DeliveryPending, NextAttemptAt: now, CreatedAt: now},
+			{WebhookID: id, Event: types.EventScheduleCreated, Status: types.DeliveryPending, NextAttemptAt: now.Add(time.Hour), CreatedAt: now},
+		})
+		if err != nil {
+			t.Fatalf("error enqueuing: %v", err)
+		}
+
+		claimed, err := deliveries.Claim(context.Background(), now, time.Minute)
+		if err != nil {
+			t.Fatalf("error claiming: %v", err)
+		}
+		if !claimed.NextAttemptAt.Equal(now) || !claimed.LockedUntil.Equal(now.Add(time.Minute)) {
+			t.Errorf("expected the due delivery, leased. got=%+v", claimed)
+		}
+		if _, err := deliveries.Claim(context.Background(), now, time.Minute); !errors.Is(err, store.ErrNoDelivery) {
+			t.Errorf("expected the leased delivery not to be claimed again. got=%v", err)
+		}
+
+		if err := deliveries.Complete(context.Background(), claimed.ID, model.DeliveryAttempt{Status: types.DeliverySucceeded, StatusCode: 204, At: now}); err != nil {
+			t.Fatalf("error completing: %v", err)
+		}
+		got, err := deliveries.GetByID(acme, claimed.ID)
+		if err != nil {
+			t.Fatalf("error getting: %v", err)
+		}
+		if got.Status != types.DeliverySucceeded || got.Attempts != 1 || got.LastStatusCode != 204 || !got.DeliveredAt.Equal(now) || !got.LockedUntil.IsZero() {
+			t.Errorf("expected the attempt to be recorded. got=%+v", got)
+		}
+
+		if count, _, err := deliveries.Get(acme, model.DeliveryFilter{WebhookID: id, Status: types.DeliveryPending}, &types.PaginationOps{}); err != nil || count != 1 {
+			t.Errorf("expected 1 pending delivery. got=%d %v", count, err)
+		}
+		if n, err := deliveries.DeleteAll(acme); err != nil || n != 2 {
+			t.Errorf("expected 2 deliveries deleted. got=%d %v", n, err)
+		}
+		if n, err := webhooks.DeleteAll(acme); err != nil || n != 1 {
+			t.Errorf("expected 1 webhook deleted. got=%d %v", n, err)
+		}
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		quota := store.NewSQLiteQuotaStore(db)
+
+		for want := int64(1); want <= 2; want++ {
+			if count, ok, err := quota.Increment(acme, model.QuotaScopeUser, "u1", 2, nil); err != nil || !ok || count != want {
+				t.Fatalf("expected the increment to %d. got=%d %t %v", want, count, ok, err)
+			}
+		}
+		if count, ok, err := quota.Increment(acme, model.QuotaScopeUser, "u1", 2, nil); err != nil || ok || count != 2 {
+			t.Errorf("expected the limit to be reached. got=%d %t %v", count, ok, err)
+		}
+		if err := quota.Decrement(acme, model.QuotaScopeUser, "u1"); err != nil {
+			t.Fatalf("error decrementing: %v", err)
+		}
+		if count, err := quota.Get(acme, model.QuotaScopeUser, "u1"); err != nil || count != 1 {
+			t.Errorf("expected 1 after the decrement. got=%d %v", count, err)
+		}
+
+		// an expired rate window starts over
+		expired := now.Add(-time.Minute)
+		if _, _, err := quota.Increment(acme, model.QuotaScopeRate, "u1", 1, &expired); err != nil {
+			t.Fatalf("error incrementing: %v", err)
+		}
+		if count, err := quota.Get(acme, model.QuotaScopeRate, "u1"); err != nil || count != 0 {
+			t.Errorf("expected expired counters to be zero. got=%d %v", count, err)
+		}
+		next := now.Add(time.Minute)
+		if count, ok, err := quota.Increment(acme, model.QuotaScopeRate, "u1", 1, &next); err != nil || !ok || count != 1 {
+			t.Errorf("expected the expired counter to be replaced. got=%d %t %v", count, ok, err)
+		}
+
+		if err := quota.Set(acme, model.QuotaScopeTenant, "", 5); err != nil {
+			t.Fatalf("error setting: %v", err)
+		}
+		if n, err := quota.DeleteAll(acme, model.QuotaScopeUser, model.QuotaScopeTenant); err != nil || n != 2 {
+			t.Errorf("expected the 2 counters of the scopes deleted. got=%d %v", n, err)
+		}
+		if count, err := quota.Get(acme, model.QuotaScopeRate, "u1"); err != nil || count != 1 {
+			t.Errorf("expected the counters of the other scopes to be kept. got=%d %v", count, err)
+		}
+	})
+}
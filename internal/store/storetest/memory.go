@@ -0,0 +1,276 @@
+// storetest package provides an in-memory schedule store for the tests of the services
+package storetest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// MemoryScheduleStore keeps the schedules in memory, in insertion order. it is safe for concurrent use.
+// it answers like the mongo store: ids are 24 hex characters, any other id is store.ErrInvalidID,
+// and every operation but CountActive is scoped to the tenant of the context.
+type MemoryScheduleStore struct {
+	mu        sync.Mutex
+	schedules []model.Schedule
+}
+
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{}
+}
+
+func (s *MemoryScheduleStore) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	if !validID(id) {
+		return nil, store.ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(tenantID, id)
+	if i < 0 {
+		return nil, store.ErrScheduleNotFound
+	}
+	schedule := s.schedules[i]
+	return &schedule, nil
+}
+
+// Get returns a page of the schedules of the tenant matching the filter. a limit of 0 returns them all.
+func (s *MemoryScheduleStore) Get(ctx context.Context, f model.ScheduleFilter, pagination *types.PaginationOps) (int64, []model.Schedule, error) {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matches []model.Schedule
+	for _, schedule := range s.schedules {
+		if schedule.TenantID == tenantID && matchesFilter(schedule, f) {
+			matches = append(matches, schedule)
+		}
+	}
+
+	count := int64(len(matches))
+	if pagination.Limit <= 0 {
+		return count, matches, nil
+	}
+
+	start := min(pagination.Limit*pagination.Page, len(matches))
+	end := min(start+pagination.Limit, len(matches))
+	return count, matches[start:end], nil
+}
+
+// Create inserts the schedule. the tenant is always taken from the context.
+func (s *MemoryScheduleStore) Create(ctx context.Context, input *model.CreateScheduleInput) (string, error) {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return "", err
+	}
+	input.TenantID = tenantID
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule := model.Schedule{
+		ID:          hex.EncodeToString(id),
+		TenantID:    tenantID,
+		Expression:  input.Expression,
+		Payload:     input.Payload,
+		CreatedBy:   input.CreatedBy,
+		ActionID:    input.ActionID,
+		ClientToken: input.ClientToken,
+		Name:        input.Name,
+		Version:     1,
+	}
+	input.Version = 1
+	s.schedules = append(s.schedules, schedule)
+	return schedule.ID, nil
+}
+
+// Delete deletes the schedule if it is still at the given version, types.AnyVersion deletes it whatever the version
+func (s *MemoryScheduleStore) Delete(ctx context.Context, id string, version int64) error {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return err
+	}
+	if !validID(id) {
+		return store.ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(tenantID, id)
+	if i < 0 {
+		return store.ErrScheduleNotFound
+	}
+	if version != types.AnyVersion && s.schedules[i].Version != version {
+		return store.ErrVersionMismatch
+	}
+	s.schedules = append(s.schedules[:i], s.schedules[i+1:]...)
+	return nil
+}
+
+// Update replaces the name, action, expression and payload of the schedule if it is still at the given version, and increments the version
+func (s *MemoryScheduleStore) Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error) {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	if !validID(id) {
+		return nil, store.ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(tenantID, id)
+	if i < 0 {
+		return nil, store.ErrScheduleNotFound
+	}
+	if s.schedules[i].Version != version {
+		return nil, store.ErrVersionMismatch
+	}
+
+	updated := &s.schedules[i]
+	updated.Name = schedule.Name
+	updated.ActionID = schedule.ActionID
+	updated.Expression = schedule.Expression
+	updated.Payload = schedule.Payload
+	updated.Version++
+
+	result := *updated
+	return &result, nil
+}
+
+// Restore appends a deleted schedule again, with its id
+func (s *MemoryScheduleStore) Restore(ctx context.Context, schedule model.Schedule) error {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return err
+	}
+	if !validID(schedule.ID) {
+		return store.ErrInvalidID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index(tenantID, schedule.ID) >= 0 {
+		return fmt.Errorf("schedule %s already exists", schedule.ID)
+	}
+	schedule.TenantID = tenantID
+	s.schedules = append(s.schedules, schedule)
+	return nil
+}
+
+// DeleteAll deletes every schedule of the tenant and returns how many were deleted
+func (s *MemoryScheduleStore) DeleteAll(ctx context.Context) (int64, error) {
+	tenantID, err := tenant(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.schedules[:0]
+	for _, schedule := range s.schedules {
+		if schedule.TenantID != tenantID {
+			kept = append(kept, schedule)
+		}
+	}
+	deleted := int64(len(s.schedules) - len(kept))
+	s.schedules = kept
+	return deleted, nil
+}
+
+// CountActive counts the schedules of every tenant by expression type and action
+func (s *MemoryScheduleStore) CountActive(ctx context.Context) ([]model.ScheduleCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make([]model.ScheduleCount, 0)
+	for _, schedule := range s.schedules {
+		found := false
+		for i := range counts {
+			if counts[i].Type == schedule.Expression.Type && counts[i].ActionID == schedule.ActionID {
+				counts[i].Count++
+				found = true
+				break
+			}
+		}
+		if !found {
+			counts = append(counts, model.ScheduleCount{Type: schedule.Expression.Type, ActionID: schedule.ActionID, Count: 1})
+		}
+	}
+	return counts, nil
+}
+
+// Len is the number of schedules of every tenant
+func (s *MemoryScheduleStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.schedules)
+}
+
+// index returns the position of the schedule, -1 when the tenant has none with the id
+func (s *MemoryScheduleStore) index(tenantID, id string) int {
+	for i, schedule := range s.schedules {
+		if schedule.TenantID == tenantID && schedule.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func tenant(ctx context.Context) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", store.ErrMissingTenant
+	}
+	return tenantID, nil
+}
+
+func matchesFilter(schedule model.Schedule, f model.ScheduleFilter) bool {
+	return (f.CreatedBy == "" || schedule.CreatedBy == f.CreatedBy) &&
+		(f.ActionID == "" || schedule.ActionID == f.ActionID) &&
+		(f.Type == "" || schedule.Expression.Type == f.Type) &&
+		(f.Name == "" || schedule.Name == f.Name)
+}
+
+// validID reports whether the id has the shape of an ObjectID
+func validID(id string) bool {
+	if len(id) != 24 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
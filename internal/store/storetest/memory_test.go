@@ -0,0 +1,9 @@
+package storetest
+
+import "testing"
+
+func TestMemoryScheduleStore(t *testing.T) {
+	Run(t, func(t *testing.T) ScheduleStore {
+		return NewMemoryScheduleStore()
+	})
+}
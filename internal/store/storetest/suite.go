@@ -0,0 +1,342 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"github.com/japb1998/action-scheduler/internal/store"
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+// ScheduleStore is what Run checks: the schedule store of the services plus what the tenant service and the metrics use
+type ScheduleStore interface {
+	GetByID(ctx context.Context, id string) (*model.Schedule, error)
+	Get(ctx context.Context, filter model.ScheduleFilter, pagination *types.PaginationOps) (int64, []model.Schedule, error)
+	Create(ctx context.Context, schedule *model.CreateScheduleInput) (string, error)
+	Delete(ctx context.Context, id string, version int64) error
+	Update(ctx context.Context, id string, version int64, schedule model.Schedule) (*model.Schedule, error)
+	Restore(ctx context.Context, schedule model.Schedule) error
+	DeleteAll(ctx context.Context) (int64, error)
+	CountActive(ctx context.Context) ([]model.ScheduleCount, error)
+}
+
+var _ ScheduleStore = (*MemoryScheduleStore)(nil)
+
+// Run checks that the stores returned by newStore behave the way the services rely on.
+// every subtest gets its own store, with no schedule.
+func Run(t *testing.T, newStore func(t *testing.T) ScheduleStore) {
+	acme := requestctx.WithTenant(context.Background(), "acme")
+	other := requestctx.WithTenant(context.Background(), "other")
+	// milliseconds, the precision of mongo dates
+	start := time.Now().Truncate(time.Millisecond).Add(time.Hour).In(time.FixedZone("EST", -5*3600))
+
+	create := func(t *testing.T, s ScheduleStore, ctx context.Context, input model.CreateScheduleInput) string {
+		t.Helper()
+
+		id, err := s.Create(ctx, &input)
+		if err != nil {
+			t.Fatalf("error creating %s: %v", input.Name, err)
+		}
+		if id == "" {
+			t.Fatalf("expected the id of %s", input.Name)
+		}
+		return id
+	}
+
+	t.Run("create get", func(t *testing.T) {
+		s := newStore(t)
+		input := model.CreateScheduleInput{
+			// the tenant is taken from the context
+			TenantID:    "other",
+			Name:        "digest",
+			ActionID:    "email",
+			CreatedBy:   "u1",
+			ClientToken: "token-1",
+			Expression:  model.Expression{Type: model.MonthlyExpression, Start: start, End: start.AddDate(1, 0, 0)},
+			Payload:     map[string]any{"to": "a@b.c"},
+		}
+		id := create(t, s, acme, input)
+
+		got, err := s.GetByID(acme, id)
+		if err != nil {
+			t.Fatalf("error getting schedule: %v", err)
+		}
+		if got.ID != id || got.TenantID != "acme" || got.Name != input.Name || got.ActionID != input.ActionID || got.CreatedBy != input.CreatedBy || got.ClientToken != input.ClientToken {
+			t.Errorf("expected %+v with id %s in acme. got=%+v", input, id, got)
+		}
+		if got.Expression.Type != input.Expression.Type || !got.Expression.Start.Equal(input.Expression.Start) || !got.Expression.End.Equal(input.Expression.End) {
+			t.Errorf("expected expression %+v. got=%+v", input.Expression, got.Expression)
+		}
+		if got.Payload["to"] != "a@b.c" {
+			t.Errorf("expected payload %v. got=%v", input.Payload, got.Payload)
+		}
+		if got.Version != 1 {
+			t.Errorf("expected version 1. got=%d", got.Version)
+		}
+
+		// unset dates stay unset
+		id = create(t, s, acme, model.CreateScheduleInput{Name: "ping", ActionID: "sms", Expression: model.Expression{Type: model.DailyExpression}})
+		if got, err := s.GetByID(acme, id); err != nil || !got.Expression.Start.IsZero() || !got.Expression.End.IsZero() {
+			t.Errorf("expected a schedule without dates. got=%+v %v", got, err)
+		}
+	})
+
+	t.Run("tenants", func(t *testing.T) {
+		s := newStore(t)
+		id := create(t, s, acme, model.CreateScheduleInput{Name: "digest", ActionID: "email", Expression: model.Expression{Type: model.DailyExpression}})
+
+		if _, err := s.GetByID(other, id); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v from another tenant. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if err := s.Delete(other, id, types.AnyVersion); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v deleting from another tenant. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if count, schedules, err := s.Get(other, model.ScheduleFilter{}, &types.PaginationOps{}); err != nil || count != 0 || len(schedules) != 0 {
+			t.Errorf("expected no schedule for another tenant. got=%d %v %v", count, schedules, err)
+		}
+		if n, err := s.DeleteAll(other); err != nil || n != 0 {
+			t.Errorf("expected nothing deleted for another tenant. got=%d %v", n, err)
+		}
+
+		ctx := context.Background()
+		if _, err := s.Create(ctx, &model.CreateScheduleInput{Name: "digest"}); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v creating without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+		if _, err := s.GetByID(ctx, id); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v getting without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+		if _, _, err := s.Get(ctx, model.ScheduleFilter{}, &types.PaginationOps{}); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v listing without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+		if err := s.Delete(ctx, id, types.AnyVersion); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v deleting without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+		if _, err := s.DeleteAll(ctx); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v deleting all without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+	})
+
+	t.Run("missing schedule", func(t *testing.T) {
+		s := newStore(t)
+		id := create(t, s, acme, model.CreateScheduleInput{Name: "digest", ActionID: "email", Expression: model.Expression{Type: model.DailyExpression}})
+
+		if err := s.Delete(acme, id, types.AnyVersion); err != nil {
+			t.Fatalf("error deleting: %v", err)
+		}
+		if _, err := s.GetByID(acme, id); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v after delete. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if err := s.Delete(acme, id, types.AnyVersion); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v deleting twice. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if err := s.Delete(acme, id, 1); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v deleting twice at a version. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if _, err := s.Update(acme, id, 1, model.Schedule{Name: "digest"}); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v updating after delete. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if _, err := s.GetByID(acme, "not-an-id"); !errors.Is(err, store.ErrInvalidID) {
+			t.Errorf("expected %v getting an invalid id. got=%v", store.ErrInvalidID, err)
+		}
+		if err := s.Delete(acme, "not-an-id", types.AnyVersion); !errors.Is(err, store.ErrInvalidID) {
+			t.Errorf("expected %v deleting an invalid id. got=%v", store.ErrInvalidID, err)
+		}
+		if _, err := s.Update(acme, "not-an-id", 1, model.Schedule{Name: "digest"}); !errors.Is(err, store.ErrInvalidID) {
+			t.Errorf("expected %v updating an invalid id. got=%v", store.ErrInvalidID, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		s := newStore(t)
+		id := create(t, s, acme, model.CreateScheduleInput{Name: "digest", ActionID: "email", CreatedBy: "u1", ClientToken: "token-1", Expression: model.Expression{Type: model.DailyExpression}})
+		next := model.Schedule{
+			Name:       "weekly digest",
+			ActionID:   "sms",
+			Expression: model.Expression{Type: model.WeeklyExpression, Start: start},
+			Payload:    map[string]any{"to": "c@d.e"},
+		}
+
+		updated, err := s.Update(acme, id, 1, next)
+		if err != nil {
+			t.Fatalf("error updating: %v", err)
+		}
+		if updated.ID != id || updated.Version != 2 || updated.Name != next.Name || updated.ActionID != next.ActionID || updated.Payload["to"] != "c@d.e" ||
+			updated.Expression.Type != next.Expression.Type || !updated.Expression.Start.Equal(start) {
+			t.Errorf("expected %+v at version 2. got=%+v", next, updated)
+		}
+		// the rest is kept
+		if got, err := s.GetByID(acme, id); err != nil || got.Version != 2 || got.CreatedBy != "u1" || got.ClientToken != "token-1" || got.TenantID != "acme" {
+			t.Errorf("expected the update stored with the rest of the schedule. got=%+v %v", got, err)
+		}
+
+		// compare and swap: the version read before the update is stale
+		if _, err := s.Update(acme, id, 1, next); !errors.Is(err, store.ErrVersionMismatch) {
+			t.Errorf("expected %v for a stale version. got=%v", store.ErrVersionMismatch, err)
+		}
+		if got, err := s.GetByID(acme, id); err != nil || got.Version != 2 {
+			t.Errorf("expected a stale update to write nothing. got=%+v %v", got, err)
+		}
+
+		if _, err := s.Update(other, id, 2, next); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v updating from another tenant. got=%v", store.ErrScheduleNotFound, err)
+		}
+		if _, err := s.Update(context.Background(), id, 2, next); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v updating without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+
+		// deletes are compare and swap too
+		if err := s.Delete(acme, id, 1); !errors.Is(err, store.ErrVersionMismatch) {
+			t.Errorf("expected %v deleting at a stale version. got=%v", store.ErrVersionMismatch, err)
+		}
+		if _, err := s.GetByID(acme, id); err != nil {
+			t.Errorf("expected a stale delete to delete nothing. got=%v", err)
+		}
+		if err := s.Delete(acme, id, 2); err != nil {
+			t.Fatalf("error deleting: %v", err)
+		}
+		if _, err := s.Update(acme, id, 2, next); !errors.Is(err, store.ErrScheduleNotFound) {
+			t.Errorf("expected %v updating a deleted schedule. got=%v", store.ErrScheduleNotFound, err)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		s := newStore(t)
+		id := create(t, s, acme, model.CreateScheduleInput{Name: "digest", ActionID: "email", CreatedBy: "u1", ClientToken: "token-1", Expression: model.Expression{Type: model.DailyExpression, Start: start}, Payload: map[string]any{"to": "a@b.c"}})
+		deleted, err := s.GetByID(acme, id)
+		if err != nil {
+			t.Fatalf("error getting: %v", err)
+		}
+		if err := s.Delete(acme, id, deleted.Version); err != nil {
+			t.Fatalf("error deleting: %v", err)
+		}
+
+		restored := *deleted
+		restored.Version++
+		// the tenant is the one of the context
+		restored.TenantID = "other"
+		if err := s.Restore(acme, restored); err != nil {
+			t.Fatalf("error restoring: %v", err)
+		}
+		got, err := s.GetByID(acme, id)
+		if err != nil {
+			t.Fatalf("error getting the restored schedule: %v", err)
+		}
+		if got.Version != 2 || got.TenantID != "acme" || got.ClientToken != "token-1" || got.Payload["to"] != "a@b.c" || !got.Expression.Start.Equal(start) {
+			t.Errorf("expected the schedule back at version 2. got=%+v", got)
+		}
+
+		if err := s.Restore(acme, restored); err == nil {
+			t.Errorf("expected an error restoring a schedule that exists")
+		}
+		if err := s.Restore(acme, model.Schedule{ID: "not-an-id"}); !errors.Is(err, store.ErrInvalidID) {
+			t.Errorf("expected %v restoring an invalid id. got=%v", store.ErrInvalidID, err)
+		}
+		if err := s.Restore(context.Background(), restored); !errors.Is(err, store.ErrMissingTenant) {
+			t.Errorf("expected %v restoring without tenant. got=%v", store.ErrMissingTenant, err)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		s := newStore(t)
+		names := []string{"a", "b", "c", "d", "e"}
+		for _, name := range names {
+			create(t, s, acme, model.CreateScheduleInput{Name: name, ActionID: "email", Expression: model.Expression{Type: model.DailyExpression}})
+		}
+
+		tests := []struct {
+			limit, page int
+			want        []string
+		}{
+			{limit: 2, page: 0, want: names[:2]},
+			{limit: 2, page: 1, want: names[2:4]},
+			{limit: 2, page: 2, want: names[4:]},
+			{limit: 2, page: 3},
+			{limit: 10, page: 0, want: names},
+			// 0 is no limit
+			{limit: 0, page: 0, want: names},
+		}
+
+		for _, tt := range tests {
+			count, schedules, err := s.Get(acme, model.ScheduleFilter{}, &types.PaginationOps{Limit: tt.limit, Page: tt.page})
+			if err != nil {
+				t.Fatalf("limit %d page %d: unexpected error: %v", tt.limit, tt.page, err)
+			}
+			if count != int64(len(names)) {
+				t.Errorf("limit %d page %d: expected a total of %d. got=%d", tt.limit, tt.page, len(names), count)
+			}
+			if got := scheduleNames(schedules); !slices.Equal(got, tt.want) {
+				t.Errorf("limit %d page %d: expected %v in insertion order. got=%v", tt.limit, tt.page, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		s := newStore(t)
+		for _, input := range []model.CreateScheduleInput{
+			{Name: "digest", ActionID: "email", CreatedBy: "u1", Expression: model.Expression{Type: model.DailyExpression}},
+			{Name: "report", ActionID: "email", CreatedBy: "u2", Expression: model.Expression{Type: model.MonthlyExpression}},
+			{Name: "ping", ActionID: "sms", CreatedBy: "u1", Expression: model.Expression{Type: model.DailyExpression}},
+		} {
+			create(t, s, acme, input)
+		}
+
+		tests := []struct {
+			filter model.ScheduleFilter
+			want   []string
+		}{
+			{filter: model.ScheduleFilter{CreatedBy: "u1"}, want: []string{"digest", "ping"}},
+			{filter: model.ScheduleFilter{ActionID: "email"}, want: []string{"digest", "report"}},
+			{filter: model.ScheduleFilter{Type: model.DailyExpression, ActionID: "sms"}, want: []string{"ping"}},
+			{filter: model.ScheduleFilter{Name: "report"}, want: []string{"report"}},
+			{filter: model.ScheduleFilter{Name: "repo"}},
+		}
+
+		for _, tt := range tests {
+			count, schedules, err := s.Get(acme, tt.filter, &types.PaginationOps{Limit: 10})
+			if err != nil {
+				t.Fatalf("%+v: unexpected error: %v", tt.filter, err)
+			}
+			if got := scheduleNames(schedules); count != int64(len(tt.want)) || !slices.Equal(got, tt.want) {
+				t.Errorf("%+v: expected %v. got=%d %v", tt.filter, tt.want, count, got)
+			}
+		}
+	})
+
+	t.Run("delete all and count", func(t *testing.T) {
+		s := newStore(t)
+		create(t, s, acme, model.CreateScheduleInput{Name: "digest", ActionID: "email", Expression: model.Expression{Type: model.DailyExpression}})
+		create(t, s, acme, model.CreateScheduleInput{Name: "report", ActionID: "email", Expression: model.Expression{Type: model.DailyExpression}})
+		create(t, s, other, model.CreateScheduleInput{Name: "ping", ActionID: "sms", Expression: model.Expression{Type: model.OneTimeExpression}})
+
+		counts, err := s.CountActive(context.Background())
+		if err != nil {
+			t.Fatalf("error counting: %v", err)
+		}
+		sort.Slice(counts, func(i, j int) bool { return counts[i].ActionID < counts[j].ActionID })
+		want := []model.ScheduleCount{{Type: model.DailyExpression, ActionID: "email", Count: 2}, {Type: model.OneTimeExpression, ActionID: "sms", Count: 1}}
+		if len(counts) != len(want) || counts[0] != want[0] || counts[1] != want[1] {
+			t.Errorf("expected counts %+v across tenants. got=%+v", want, counts)
+		}
+
+		if n, err := s.DeleteAll(acme); err != nil || n != 2 {
+			t.Errorf("expected the 2 schedules of the tenant deleted. got=%d %v", n, err)
+		}
+		if count, _, err := s.Get(other, model.ScheduleFilter{}, &types.PaginationOps{}); err != nil || count != 1 {
+			t.Errorf("expected the schedules of other tenants kept. got=%d %v", count, err)
+		}
+	})
+}
+
+func scheduleNames(schedules []model.Schedule) []string {
+	var names []string
+	for _, s := range schedules {
+		names = append(names, s.Name)
+	}
+	return names
+}
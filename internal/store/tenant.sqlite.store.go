@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+)
+
+type SQLiteTenantStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteTenantStore(db *sql.DB) *SQLiteTenantStore {
+	return &SQLiteTenantStore{db: db}
+}
+
+func (s *SQLiteTenantStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "tenant"))
+}
+
+func (s *SQLiteTenantStore) GetByID(ctx context.Context, id string) (*model.Tenant, error) {
+	var (
+		tenant                    model.Tenant
+		actions, created, updated string
+	)
+
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, time_zone, allowed_actions, created_at, updated_at FROM tenant WHERE id = ?", id).
+		Scan(&tenant.ID, &tenant.Name, &tenant.TimeZone, &actions, &created, &updated)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTenantNotFound
+		}
+		s.log(ctx).Error("error getting tenant by id", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if tenant.AllowedActions, err = parseList(actions); err != nil {
+		return nil, fmt.Errorf("invalid allowed actions of tenant %s. error=%w", id, err)
+	}
+	if tenant.CreatedAt, err = parseTime(created); err != nil {
+		return nil, fmt.Errorf("invalid creation time of tenant %s. error=%w", id, err)
+	}
+	if tenant.UpdatedAt, err = parseTime(updated); err != nil {
+		return nil, fmt.Errorf("invalid update time of tenant %s. error=%w", id, err)
+	}
+	return &tenant, nil
+}
+
+// Upsert creates or replaces the tenant configuration. created_at is only set on insert.
+func (s *SQLiteTenantStore) Upsert(ctx context.Context, tenant *model.Tenant) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO tenant (id, name, time_zone, allowed_actions, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, time_zone = excluded.time_zone, allowed_actions = excluded.allowed_actions, updated_at = excluded.updated_at`,
+		tenant.ID, tenant.Name, tenant.TimeZone, formatList(tenant.AllowedActions), formatTime(tenant.UpdatedAt), formatTime(tenant.UpdatedAt))
+
+	if err != nil {
+		s.log(ctx).Error("error upserting tenant", slog.String("id", tenant.ID), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteTenantStore) Delete(ctx context.Context, id string) error {
+	r, err := s.db.ExecContext(ctx, "DELETE FROM tenant WHERE id = ?", id)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
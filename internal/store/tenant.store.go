@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+type MongoTenantStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoTenantStore(c *mongo.Client, database string) *MongoTenantStore {
+
+	return &MongoTenantStore{
+		coll: c.Database(database).Collection("tenant"),
+	}
+}
+
+func (s *MongoTenantStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "tenant"))
+}
+
+func (s *MongoTenantStore) GetByID(ctx context.Context, id string) (*model.Tenant, error) {
+	var tenant model.Tenant
+
+	err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&tenant)
+
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTenantNotFound
+		}
+		s.log(ctx).Error("error getting tenant by id", slog.String("id", id), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// Upsert creates or replaces the tenant configuration. created_at is only set on insert.
+func (s *MongoTenantStore) Upsert(ctx context.Context, tenant *model.Tenant) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "name", Value: tenant.Name},
+			{Key: "time_zone", Value: tenant.TimeZone},
+			{Key: "allowed_actions", Value: tenant.AllowedActions},
+			{Key: "updated_at", Value: tenant.UpdatedAt},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: tenant.UpdatedAt}}},
+	}
+
+	_, err := s.coll.UpdateByID(ctx, tenant.ID, update, options.Update().SetUpsert(true))
+
+	if err != nil {
+		s.log(ctx).Error("error upserting tenant", slog.String("id", tenant.ID), slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *MongoTenantStore) Delete(ctx context.Context, id string) error {
+	r, err := s.coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if r.DeletedCount == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+)
+
+const webhookColumns = "id, tenant_id, url, events, secret, created_by, created_at"
+
+type SQLiteWebhookStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteWebhookStore(db *sql.DB) *SQLiteWebhookStore {
+	return &SQLiteWebhookStore{db: db}
+}
+
+func (s *SQLiteWebhookStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("table", "webhook"))
+}
+
+// Create inserts the webhook for the tenant of the context
+func (s *SQLiteWebhookStore) Create(ctx context.Context, webhook *model.Webhook) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	webhook.TenantID = tenantID
+
+	id, err := newID()
+
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO webhook ("+webhookColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		id, tenantID, webhook.URL, formatList(webhook.Events), webhook.Secret, webhook.CreatedBy, formatTime(webhook.CreatedAt))
+
+	if err != nil {
+		s.log(ctx).Error("error creating webhook", slog.String("error", err.Error()))
+		return "", err
+	}
+	return id, nil
+}
+
+// Get returns every webhook of the tenant, newest first
+func (s *SQLiteWebhookStore) Get(ctx context.Context) ([]model.Webhook, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+	return s.find(ctx, "tenant_id = ?", tenantID)
+}
+
+// Subscribed returns the webhooks of the tenant subscribed to the event
+func (s *SQLiteWebhookStore) Subscribed(ctx context.Context, event string) ([]model.Webhook, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+	return s.find(ctx, "tenant_id = ? AND EXISTS (SELECT 1 FROM json_each(events) WHERE value = ?)", tenantID, event)
+}
+
+func (s *SQLiteWebhookStore) find(ctx context.Context, where string, args ...any) ([]model.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE "+where+" ORDER BY created_at DESC", args...)
+
+	if err != nil {
+		s.log(ctx).Error("error getting webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+
+		if err != nil {
+			s.log(ctx).Error("error getting webhooks", slog.String("error", err.Error()))
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetByID returns the webhook of the tenant of the context
+func (s *SQLiteWebhookStore) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return nil, ErrMissingTenant
+	}
+	return s.findOne(ctx, s.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE tenant_id = ? AND id = ?", tenantID, id))
+}
+
+// Lookup returns a webhook of any tenant. it is used by the dispatcher, which delivers for every tenant.
+func (s *SQLiteWebhookStore) Lookup(ctx context.Context, id string) (*model.Webhook, error) {
+	if !validID(id) {
+		return nil, ErrInvalidID
+	}
+	return s.findOne(ctx, s.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE id = ?", id))
+}
+
+func (s *SQLiteWebhookStore) findOne(ctx context.Context, row *sql.Row) (*model.Webhook, error) {
+	webhook, err := scanWebhook(row)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		s.log(ctx).Error("error getting webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *SQLiteWebhookStore) Delete(ctx context.Context, id string) error {
+	if !validID(id) {
+		return ErrInvalidID
+	}
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM webhook WHERE tenant_id = ? AND id = ?", tenantID, id)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting webhook", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// DeleteAll deletes every webhook of the tenant and returns how many were deleted
+func (s *SQLiteWebhookStore) DeleteAll(ctx context.Context) (int64, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return 0, ErrMissingTenant
+	}
+
+	r, err := s.db.ExecContext(ctx, "DELETE FROM webhook WHERE tenant_id = ?", tenantID)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant webhooks", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+func scanWebhook(row scanner) (*model.Webhook, error) {
+	var (
+		webhook             model.Webhook
+		id, events, created string
+	)
+
+	err := row.Scan(&id, &webhook.TenantID, &webhook.URL, &events, &webhook.Secret, &webhook.CreatedBy, &created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.ID = id
+	if webhook.Events, err = parseList(events); err != nil {
+		return nil, fmt.Errorf("invalid events of webhook %s. error=%w", id, err)
+	}
+	if webhook.CreatedAt, err = parseTime(created); err != nil {
+		return nil, fmt.Errorf("invalid creation time of webhook %s. error=%w", id, err)
+	}
+	return &webhook, nil
+}
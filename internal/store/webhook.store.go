@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/japb1998/action-scheduler/internal/logging"
+	"github.com/japb1998/action-scheduler/internal/model"
+	"github.com/japb1998/action-scheduler/internal/requestctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type MongoWebhookStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoWebhookStore(c *mongo.Client, database string) *MongoWebhookStore {
+
+	return &MongoWebhookStore{
+		coll: c.Database(database).Collection("webhook"),
+	}
+}
+
+func (s *MongoWebhookStore) log(c context.Context) *slog.Logger {
+	return logging.FromContext(c).With(slog.String("package", "store"), slog.String("collection", "webhook"))
+}
+
+// Create inserts the webhook for the tenant of the context
+func (s *MongoWebhookStore) Create(ctx context.Context, webhook *model.Webhook) (string, error) {
+	tenantID, ok := requestctx.Tenant(ctx)
+
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	webhook.TenantID = tenantID
+
+	r, err := s.coll.InsertOne(ctx, webhook)
+
+	if err != nil {
+		s.log(ctx).Error("error creating webhook", slog.String("error", err.Error()))
+		return "", err
+	}
+
+	id, ok := r.InsertedID.(primitive.ObjectID)
+
+	if !ok {
+		return "", ErrInvalidID
+	}
+	return id.Hex(), nil
+}
+
+// Get returns every webhook of the tenant, newest first
+func (s *MongoWebhookStore) Get(ctx context.Context) ([]model.Webhook, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	return s.find(ctx, filter)
+}
+
+// Subscribed returns the webhooks of the tenant subscribed to the event
+func (s *MongoWebhookStore) Subscribed(ctx context.Context, event string) ([]model.Webhook, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	return s.find(ctx, append(filter, bson.E{Key: "events", Value: event}))
+}
+
+func (s *MongoWebhookStore) find(ctx context.Context, filter bson.D) ([]model.Webhook, error) {
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+
+	if err != nil {
+		s.log(ctx).Error("error getting webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	webhooks := make([]model.Webhook, 0)
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		s.log(ctx).Error("error getting webhooks", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetByID returns the webhook of the tenant of the context
+func (s *MongoWebhookStore) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+	return s.findOne(ctx, append(filter, bson.E{Key: "_id", Value: bsonId}))
+}
+
+// Lookup returns a webhook of any tenant. it is used by the dispatcher, which delivers for every tenant.
+func (s *MongoWebhookStore) Lookup(ctx context.Context, id string) (*model.Webhook, error) {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.findOne(ctx, bson.D{{Key: "_id", Value: bsonId}})
+}
+
+func (s *MongoWebhookStore) findOne(ctx context.Context, filter bson.D) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	if err := s.coll.FindOne(ctx, filter).Decode(&webhook); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		s.log(ctx).Error("error getting webhook", slog.String("error", err.Error()))
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (s *MongoWebhookStore) Delete(ctx context.Context, id string) error {
+	bsonId, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return ErrInvalidID
+	}
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	r, err := s.coll.DeleteOne(ctx, append(filter, bson.E{Key: "_id", Value: bsonId}))
+
+	if err != nil {
+		s.log(ctx).Error("error deleting webhook", slog.String("id", id), slog.String("error", err.Error()))
+		return err
+	}
+
+	if r.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// DeleteAll deletes every webhook of the tenant and returns how many were deleted
+func (s *MongoWebhookStore) DeleteAll(ctx context.Context) (int64, error) {
+	filter, err := tenantFilter(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := s.coll.DeleteMany(ctx, filter)
+
+	if err != nil {
+		s.log(ctx).Error("error deleting tenant webhooks", slog.String("error", err.Error()))
+		return 0, err
+	}
+	return r.DeletedCount, nil
+}
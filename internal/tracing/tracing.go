@@ -0,0 +1,116 @@
+// tracing package sets up the OpenTelemetry tracer provider and the helpers every layer uses to create spans
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/japb1998/action-scheduler/internal/config"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+const instrumentationName = "github.com/japb1998/action-scheduler"
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+// the returned function flushes pending spans and must be called on shutdown.
+func Setup(cfg config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, file, err := newExporter(cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName))
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter builds the span exporter for cfg.Exporter. file is the trace file the caller must close, if any
+func newExporter(cfg config.Tracing) (sdktrace.SpanExporter, *os.File, error) {
+	if cfg.Exporter == ExporterOTLP {
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		// the exporter connects lazily, so a collector that is down doesn't block startup
+		exporter, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp trace exporter. error=%w", err)
+		}
+		return exporter, nil, nil
+	}
+
+	var w io.Writer = os.Stdout
+	var file *os.File
+
+	if cfg.Exporter == ExporterFile {
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file %s. error=%w", cfg.File, err)
+		}
+		w, file = f, f
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+
+	if err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return nil, nil, fmt.Errorf("failed to create trace exporter. error=%w", err)
+	}
+	return exporter, file, nil
+}
+
+// Start starts a span that is a child of the span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End marks the span as failed when err is not nil and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,325 @@
+// transfer package writes and reads the schedule files of the export and import endpoints, as JSON, YAML or CSV
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// formats
+const (
+	JSON = "json"
+	YAML = "yaml"
+	CSV  = "csv"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// csvHeader are the CSV columns. payload is a JSON object and dates are RFC3339.
+var csvHeader = []string{"id", "name", "action", "type", "start_date", "end_date", "payload", "created_by"}
+
+// ContentType returns the media type of the format
+func ContentType(format string) string {
+	switch format {
+	case YAML:
+		return "application/yaml"
+	case CSV:
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+// FormatOf returns the format of a media type
+func FormatOf(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return "", fmt.Errorf("%w. content_type=%s", ErrUnknownFormat, contentType)
+	}
+
+	switch mediaType {
+	case "application/json":
+		return JSON, nil
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return YAML, nil
+	case "text/csv":
+		return CSV, nil
+	}
+	return "", fmt.Errorf("%w. content_type=%s", ErrUnknownFormat, contentType)
+}
+
+// Record returns the exported shape of a schedule
+func Record(sch *types.Schedule) *types.ScheduleRecord {
+	return &types.ScheduleRecord{
+		ID:         sch.ID,
+		Name:       sch.Name,
+		ActionID:   sch.Action.Id,
+		Expression: sch.Expression,
+		Payload:    sch.Payload,
+		CreatedBy:  sch.CreatedBy,
+	}
+}
+
+// Writer streams records. nothing is written before the first Write or Close, so the caller can still send an error instead.
+type Writer struct {
+	w       io.Writer
+	format  string
+	started bool
+	csv     *csv.Writer
+}
+
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case JSON, YAML:
+		return &Writer{w: w, format: format}, nil
+	case CSV:
+		return &Writer{w: w, format: format, csv: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("%w. format=%s", ErrUnknownFormat, format)
+}
+
+// start writes what precedes the first record
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	switch w.format {
+	case JSON:
+		_, err := io.WriteString(w.w, "[")
+		return err
+	case CSV:
+		return w.csv.Write(csvHeader)
+	}
+	return nil
+}
+
+func (w *Writer) Write(r *types.ScheduleRecord) error {
+	first := !w.started
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	switch w.format {
+	case JSON:
+		by, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if !first {
+			by = append([]byte(","), by...)
+		}
+		_, err = w.w.Write(append([]byte("\n  "), by...))
+		return err
+	case YAML:
+		doc, err := jsonDocument(r)
+		if err != nil {
+			return err
+		}
+		// a list of one item per record concatenates into a single list
+		by, err := yaml.Marshal([]any{doc})
+		if err != nil {
+			return err
+		}
+		_, err = w.w.Write(by)
+		return err
+	}
+
+	payload := ""
+	if len(r.Payload) > 0 {
+		by, err := json.Marshal(r.Payload)
+		if err != nil {
+			return err
+		}
+		payload = string(by)
+	}
+
+	return w.csv.Write([]string{
+		r.ID, r.Name, r.ActionID, r.Expression.Type,
+		formatDate(r.Expression.Start), formatDate(r.Expression.End),
+		payload, r.CreatedBy,
+	})
+}
+
+// Close ends the document and flushes it
+func (w *Writer) Close() error {
+	empty := !w.started
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	switch w.format {
+	case JSON:
+		end := "\n]\n"
+		if empty {
+			end = "]\n"
+		}
+		_, err := io.WriteString(w.w, end)
+		return err
+	case YAML:
+		if empty {
+			_, err := io.WriteString(w.w, "[]\n")
+			return err
+		}
+		return nil
+	}
+
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func formatDate(d types.ScheduleDate) string {
+	t := time.Time(d)
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// jsonDocument converts v to the generic value of its JSON, so YAML uses the JSON field names
+func jsonDocument(v any) (any, error) {
+	by, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	err = json.Unmarshal(by, &doc)
+	return doc, err
+}
+
+// Read reads the rows of an import file. a row that can't be read gets its error, the file only fails when it can't be parsed at all.
+func Read(r io.Reader, format string) ([]types.ImportRow, error) {
+	switch format {
+	case JSON:
+		var docs []json.RawMessage
+		if err := json.NewDecoder(r).Decode(&docs); err != nil {
+			return nil, fmt.Errorf("expected a JSON list of schedules. error=%w", err)
+		}
+
+		rows := make([]types.ImportRow, 0, len(docs))
+		for i, doc := range docs {
+			rows = append(rows, decodeRow(i+1, doc))
+		}
+		return rows, nil
+	case YAML:
+		var docs []any
+		if err := yaml.NewDecoder(r).Decode(&docs); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("expected a YAML list of schedules. error=%w", err)
+		}
+
+		rows := make([]types.ImportRow, 0, len(docs))
+		for i, doc := range docs {
+			by, err := json.Marshal(doc)
+			if err != nil {
+				rows = append(rows, types.ImportRow{Row: i + 1, Err: err})
+				continue
+			}
+			rows = append(rows, decodeRow(i+1, by))
+		}
+		return rows, nil
+	case CSV:
+		return readCSV(r)
+	}
+	return nil, fmt.Errorf("%w. format=%s", ErrUnknownFormat, format)
+}
+
+func decodeRow(row int, by []byte) types.ImportRow {
+	var input types.CreateScheduleInput
+
+	if err := json.Unmarshal(by, &input); err != nil {
+		return types.ImportRow{Row: row, Input: input, Err: err}
+	}
+	return types.ImportRow{Row: row, Input: input}
+}
+
+func readCSV(r io.Reader) ([]types.ImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("expected a CSV header. error=%w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "action", "type"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q. got=%v", required, header)
+		}
+	}
+
+	var rows []types.ImportRow
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			// a malformed line is reported on its row, csv.Reader goes on with the next one
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, types.ImportRow{Row: n, Err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		rows = append(rows, csvRow(n, columns, record))
+	}
+}
+
+func csvRow(n int, columns map[string]int, record []string) types.ImportRow {
+	get := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := types.ImportRow{Row: n}
+	row.Input.Name = get("name")
+	row.Input.ActionID = get("action")
+	row.Input.Expression.Type = get("type")
+
+	for _, date := range []struct {
+		column string
+		dst    *types.ScheduleDate
+	}{{"start_date", &row.Input.Expression.Start}, {"end_date", &row.Input.Expression.End}} {
+		v := get(date.column)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			row.Err = fmt.Errorf("invalid %s. wanted=%s. got=%s", date.column, time.RFC3339, v)
+			return row
+		}
+		*date.dst = types.ScheduleDate(t)
+	}
+
+	if payload := get("payload"); payload != "" {
+		if err := json.Unmarshal([]byte(payload), &row.Input.Payload); err != nil {
+			row.Err = fmt.Errorf("payload must be a JSON object. error=%w", err)
+			return row
+		}
+	}
+	return row
+}
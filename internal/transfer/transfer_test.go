@@ -0,0 +1,95 @@
+package transfer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/internal/types"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Date(2030, 2, 1, 10, 0, 0, 0, time.UTC)
+	schedules := []types.Schedule{
+		{ID: "1", Name: "digest", CreatedBy: "user-1", Action: types.Action{Id: "1"}, Expression: types.Expression{Type: types.DAILY, Start: types.ScheduleDate(start)}, Payload: map[string]any{"to": "a@b.c"}},
+		{ID: "2", Name: "report, monthly", CreatedBy: "user-2", Action: types.Action{Id: "2"}, Expression: types.Expression{Type: types.MONTHLY}},
+	}
+
+	for _, format := range []string{JSON, YAML, CSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			if err != nil {
+				t.Fatalf("error creating writer: %v", err)
+			}
+			for i := range schedules {
+				if err := w.Write(Record(&schedules[i])); err != nil {
+					t.Fatalf("error writing: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("error closing: %v", err)
+			}
+
+			rows, err := Read(&buf, format)
+			if err != nil {
+				t.Fatalf("error reading:\n%s\n%v", buf.String(), err)
+			}
+			if len(rows) != len(schedules) {
+				t.Fatalf("expected %d rows. got=%d", len(schedules), len(rows))
+			}
+
+			for i, row := range rows {
+				want := schedules[i]
+				if row.Err != nil {
+					t.Fatalf("row %d: unexpected error %v", row.Row, row.Err)
+				}
+				if row.Row != i+1 || row.Input.Name != want.Name || row.Input.ActionID != want.Action.Id || row.Input.Expression.Type != want.Expression.Type {
+					t.Errorf("row %d: expected %+v. got=%+v", i+1, want, row.Input)
+				}
+				if !time.Time(row.Input.Expression.Start).Equal(time.Time(want.Expression.Start)) {
+					t.Errorf("row %d: expected start %v. got=%v", i+1, time.Time(want.Expression.Start), time.Time(row.Input.Expression.Start))
+				}
+				if len(row.Input.Payload) != len(want.Payload) {
+					t.Errorf("row %d: expected payload %v. got=%v", i+1, want.Payload, row.Input.Payload)
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyExport(t *testing.T) {
+	for format, want := range map[string]string{JSON: "[]\n", YAML: "[]\n", CSV: strings.Join(csvHeader, ",") + "\n"} {
+		var buf bytes.Buffer
+		w, _ := NewWriter(&buf, format)
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: error closing: %v", format, err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: expected %q. got=%q", format, want, buf.String())
+		}
+	}
+}
+
+func TestReadCSVRowErrors(t *testing.T) {
+	file := "name,action,type,start_date,payload\n" +
+		"ok,1,daily,,\n" +
+		"bad date,1,daily,tomorrow,\n" +
+		"bad payload,1,daily,,[1]\n"
+
+	rows, err := Read(strings.NewReader(file), CSV)
+	if err != nil {
+		t.Fatalf("error reading: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows. got=%d", len(rows))
+	}
+	if rows[0].Err != nil || rows[1].Err == nil || rows[2].Err == nil {
+		t.Errorf("expected only the first row to be valid. got=%v, %v, %v", rows[0].Err, rows[1].Err, rows[2].Err)
+	}
+
+	if _, err := Read(strings.NewReader("name,type\nx,daily\n"), CSV); err == nil {
+		t.Errorf("expected an error for a header without action")
+	}
+}
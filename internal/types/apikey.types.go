@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Actions    []string   `json:"actions"`
+	ReadOnly   bool       `json:"read_only"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// MintedAPIKey is only returned once, when the key is created. the plain key can't be recovered afterwards.
+type MintedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyInput struct {
+	Name string `json:"name" binding:"required,min=2"`
+	// Actions are the action IDs the key may schedule. "*" allows every action. read-only keys schedule nothing.
+	Actions []string `json:"actions" binding:"required_unless=ReadOnly true,dive,required"`
+	// ReadOnly keys can only read schedules. calendar subscriptions (?key=) require one.
+	ReadOnly bool `json:"read_only"`
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// AuditEntry represents a single create/update/delete/run performed on a schedule.
+type AuditEntry struct {
+	ID         string    `json:"id"`
+	ScheduleID string    `json:"schedule_id"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"request_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Before     *Schedule `json:"before,omitempty"`
+	After      *Schedule `json:"after,omitempty"`
+}
+
+type AuditFilter struct {
+	ScheduleID string    `form:"schedule_id"`
+	Actor      string    `form:"actor"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PaginationOps
+}
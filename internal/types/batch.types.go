@@ -0,0 +1,43 @@
+package types
+
+// batch item statuses
+const (
+	BatchCreated = "created"
+	BatchDeleted = "deleted"
+	BatchFailed  = "error"
+)
+
+// BatchCreateInput is the body of POST /schedule:batch
+type BatchCreateInput struct {
+	Items []CreateScheduleInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// BatchDeleteInput is the body of POST /schedule:batchDelete. either IDs or a filter is set.
+type BatchDeleteInput struct {
+	IDs    []string             `json:"ids,omitempty" binding:"omitempty,dive,required"`
+	Filter *ScheduleFilterInput `json:"filter,omitempty"`
+}
+
+// ScheduleFilterInput selects schedules. empty fields match every schedule.
+type ScheduleFilterInput struct {
+	ActionID  string `json:"action,omitempty" form:"action"`
+	Type      string `json:"type,omitempty" form:"type" binding:"omitempty,oneof=monthly weekly daily one_time"`
+	CreatedBy string `json:"created_by,omitempty" form:"created_by"`
+}
+
+// BatchItemResult is the outcome of one item. Index is its position in the request, or in the filter matches.
+type BatchItemResult struct {
+	Index    int            `json:"index"`
+	ID       string         `json:"id,omitempty"`
+	Status   string         `json:"status"`
+	Schedule *Schedule      `json:"schedule,omitempty"`
+	Error    *ErrorResponse `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Items     []BatchItemResult `json:"items"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	// Remaining is the number of schedules matching the filter left over the batch limit
+	Remaining int `json:"remaining,omitempty"`
+}
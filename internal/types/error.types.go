@@ -0,0 +1,15 @@
+package types
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Details depend on the code. validation errors list a FieldError per invalid field.
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,19 @@
+package types
+
+const (
+	HealthStatusOK    = "ok"
+	HealthStatusError = "error"
+)
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Readiness is ok only when every dependency check is ok
+type Readiness struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
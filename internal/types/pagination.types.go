@@ -13,7 +13,7 @@ func PaginationDefaults() *PaginationOps {
 }
 
 type PaginationItem interface {
-	Schedule
+	Schedule | AuditEntry | WebhookDelivery
 }
 
 type PaginatedResult[T PaginationItem] struct {
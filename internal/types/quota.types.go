@@ -0,0 +1,9 @@
+package types
+
+type QuotaUsage struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	Used  int64  `json:"used"`
+	// Limit is 0 when the quota is unlimited
+	Limit int64 `json:"limit"`
+}
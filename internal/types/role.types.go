@@ -0,0 +1,13 @@
+package types
+
+type RoleBinding struct {
+	Subject string   `json:"subject"`
+	Role    string   `json:"role"`
+	Actions []string `json:"actions"`
+}
+
+type UpsertRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=viewer scheduler admin"`
+	// Actions are the action IDs the subject may schedule. "*" allows every action.
+	Actions []string `json:"actions" binding:"omitempty,dive,required"`
+}
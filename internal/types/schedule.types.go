@@ -1,55 +1,98 @@
 package types
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
 // schedule types the idea is that this will have its own collection at some point.
 const (
 	MONTHLY = "monthly"
+	WEEKLY  = "weekly"
 	DAILY   = "daily"
 	ONE     = "one_time"
 )
 
+// AnyVersion matches every version of a schedule, it is what If-Match: * asks for
+const AnyVersion int64 = -1
+
 /*
 Schedule is a struct that represents a schedule in the database
 this schedule will also be stored/triggered by AWS Event Bridge Scheduler.
 */
 type Schedule struct {
 	ID          string                             `json:"id" binding:"required"`
+	TenantID    string                             `json:"tenant_id"`
 	CreatedBy   string                             `json:"created_by" binding:"required"`
 	Name        string                             `json:"name"`
 	Payload     map[string]any                     `json:"payload,omitempty" binding:"omitempty"`
 	Action      `json:"action" binding:"required"` // arn to the lambda function to be triggered
 	Expression  `json:"expression" binding:"required"`
 	ClientToken string `json:"-"`
+	// Version is incremented by every update, it is the ETag of the schedule
+	Version int64 `json:"version"`
 }
 
 type CreateScheduleInput struct {
-	CreatedBy  string `json:"created_by" binding:"required"`
+	CreatedBy  string `json:"-"` // stamped from the authenticated principal
 	ActionID   string `json:"action" binding:"required"`
 	Name       string `json:"name" binding:"required,min=2"`
 	Expression `json:"expression" binding:"required"`
 	Payload    map[string]any `json:"payload,omitempty" binding:"omitempty"`
 }
 
+// LogValue keeps the payload out of the logs. only its size is logged.
+func (s Schedule) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", s.ID),
+		slog.String("tenant_id", s.TenantID),
+		slog.String("created_by", s.CreatedBy),
+		slog.String("name", s.Name),
+		slog.String("action", s.Action.Id),
+		slog.String("expression", s.Expression.Type),
+		slog.Int("payload_keys", len(s.Payload)),
+		slog.Int64("version", s.Version),
+	)
+}
+
+// LogValue logs the input without its payload
+func (s CreateScheduleInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("created_by", s.CreatedBy),
+		slog.String("name", s.Name),
+		slog.String("action", s.ActionID),
+		slog.String("expression", s.Expression.Type),
+		slog.Int("payload_keys", len(s.Payload)),
+	)
+}
+
+// UpdateScheduleInput replaces the expression and payload of a schedule.
+// the name and action can't change, the name is part of the name of the schedule in the scheduler.
 type UpdateScheduleInput struct {
-	ActionID string         `json:"action"`
-	Name     string         `json:"name" binding:"omitempty,min=2"`
-	Payload  map[string]any `json:"payload,omitempty"`
+	Expression `json:"expression" binding:"required"`
+	Payload    map[string]any `json:"payload,omitempty" binding:"omitempty"`
+}
+
+// LogValue logs the input without its payload
+func (s UpdateScheduleInput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("expression", s.Expression.Type),
+		slog.Int("payload_keys", len(s.Payload)),
+	)
 }
 
 // Schedule expression. expression is used in order to build the schedule and know when it will be triggered
 type Expression struct {
-	Type  string       `json:"type" binding:"required,oneof=monthly daily one_time"`
+	Type  string       `json:"type" binding:"required,oneof=monthly weekly daily one_time"`
 	Start ScheduleDate `json:"start_date" binding:"omitempty"`
 	End   ScheduleDate `json:"end_date" binding:"omitempty"`
 }
 
 type UpdateExpressionInput struct {
-	Type  string       `json:"type" binding:"omitempty,oneof=monthly daily one_time"`
+	Type  string       `json:"type" binding:"omitempty,oneof=monthly weekly daily one_time"`
 	Start ScheduleDate `json:"start_date" binding:"omitempty"`
 	End   ScheduleDate `json:"end_date" binding:"omitempty"`
 }
@@ -78,3 +121,27 @@ func (s *ScheduleDate) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(t.Format(time.RFC3339))
 }
+
+// event types of GET /schedule/stream. insert, update and delete are writes to a schedule.
+const (
+	ScheduleEventInsert = "insert"
+	ScheduleEventUpdate = "update"
+	ScheduleEventDelete = "delete"
+	// ScheduleEventReset is sent when events were lost, e.g. the resume token is too old. the client reloads its schedules.
+	ScheduleEventReset = "reset"
+)
+
+// ScheduleEvent is an event of GET /schedule/stream
+type ScheduleEvent struct {
+	// ID resumes the stream after the event. it is sent as the SSE id, reset events have none.
+	ID       string    `json:"-"`
+	Type     string    `json:"type"`
+	Schedule *Schedule `json:"schedule,omitempty"`
+}
+
+// ScheduleEvents is a subscription to the schedule events
+type ScheduleEvents interface {
+	// Next blocks until the next event or until ctx is done
+	Next(ctx context.Context) (*ScheduleEvent, error)
+	Close() error
+}
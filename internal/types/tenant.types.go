@@ -0,0 +1,17 @@
+package types
+
+type Tenant struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	TimeZone       string   `json:"time_zone"`
+	AllowedActions []string `json:"allowed_actions"`
+	// ScheduleGroup is the EventBridge schedule group holding every schedule of the tenant.
+	ScheduleGroup string `json:"schedule_group"`
+}
+
+type UpsertTenantInput struct {
+	Name     string `json:"name" binding:"required,min=2"`
+	TimeZone string `json:"time_zone" binding:"required"`
+	// AllowedActions restricts the action IDs the tenant can schedule. empty allows every action.
+	AllowedActions []string `json:"allowed_actions" binding:"omitempty,dive,required"`
+}
@@ -0,0 +1,61 @@
+package types
+
+// import row statuses. the batch statuses are used for the created and failed rows.
+const (
+	ImportWouldCreate = "would_create"
+	ImportSkipped     = "skipped"
+	ImportInvalid     = "invalid"
+)
+
+// ScheduleRecord is a schedule as exported. import reads the same shape and ignores id and created_by.
+type ScheduleRecord struct {
+	ID         string         `json:"id,omitempty"`
+	Name       string         `json:"name"`
+	ActionID   string         `json:"action"`
+	Expression Expression     `json:"expression"`
+	Payload    map[string]any `json:"payload,omitempty"`
+	CreatedBy  string         `json:"created_by,omitempty"`
+}
+
+// ExportQuery is the query of GET /schedule/export. ics exports the calendar with the payloads, to be imported back.
+type ExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json yaml csv ics"`
+	ScheduleFilterInput
+}
+
+// ImportQuery is the query of POST /schedule/import. the format defaults to the one of the Content-Type.
+type ImportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json yaml csv"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// ImportRow is a row read from an import file. Err is set when the row could not be read or is invalid.
+type ImportRow struct {
+	// Row is the 1-based position of the schedule in the file, not counting the CSV header.
+	Row   int
+	Input CreateScheduleInput
+	Err   error
+}
+
+type ImportRowResult struct {
+	Row    int            `json:"row"`
+	Name   string         `json:"name,omitempty"`
+	Status string         `json:"status"`
+	ID     string         `json:"id,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+type ImportResult struct {
+	DryRun bool              `json:"dry_run"`
+	Rows   []ImportRowResult `json:"rows"`
+	// Created counts the rows created, or that would be created in a dry run
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// CalendarImportQuery is the query of POST /schedule/import/ics. ActionID is used for the events that don't carry their action.
+type CalendarImportQuery struct {
+	ActionID string `form:"action"`
+	DryRun   bool   `form:"dry_run"`
+}
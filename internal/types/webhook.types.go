@@ -0,0 +1,84 @@
+package types
+
+import "time"
+
+// webhook events
+const (
+	EventScheduleCreated = "schedule.created"
+	EventScheduleUpdated = "schedule.updated"
+	EventScheduleDeleted = "schedule.deleted"
+	EventScheduleFired   = "schedule.fired"
+	EventScheduleFailed  = "schedule.failed"
+)
+
+// delivery statuses. a delivery is failed once every attempt failed.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// schedule run statuses reported by the actions
+const (
+	RunFired  = "fired"
+	RunFailed = "failed"
+)
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreatedWebhook is only returned once, when the webhook is created. the secret signs every delivery.
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type CreateWebhookInput struct {
+	// URL must be https and resolve to public addresses, see webhook.ErrInvalidURL
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=schedule.created schedule.updated schedule.deleted schedule.fired schedule.failed"`
+}
+
+// WebhookEvent is the body of a delivery
+type WebhookEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Schedule   *Schedule `json:"schedule"`
+	// Run is set on schedule.fired and schedule.failed
+	Run *ScheduleRunInput `json:"run,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Event     string `json:"event"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	// NextAttemptAt is set while the delivery is pending
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	// ReplayOf is the delivery this one replays
+	ReplayOf string `json:"replay_of,omitempty"`
+}
+
+type DeliveryFilter struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+	PaginationOps
+}
+
+// ScheduleRunInput is how an action reports a run of its schedule, as the scheduler doesn't report them
+type ScheduleRunInput struct {
+	Status string `json:"status" binding:"required,oneof=fired failed"`
+	// Error describes the failure of a failed run
+	Error string `json:"error,omitempty" binding:"max=1024"`
+}
@@ -2,34 +2,32 @@ package awssess
 
 import (
 	"log/slog"
-	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
-var sess *session.Session
-
-func MustGetSession() *session.Session {
-
-	if sess != nil {
-		return sess
-	}
+type Options struct {
+	// Stage "local" uses the shared config Profile and Region. any other stage uses the default credential chain.
+	Stage   string
+	Profile string
+	Region  string
+}
 
-	switch os.Getenv("STAGE") {
+func NewSession(ops Options) (*session.Session, error) {
+	switch ops.Stage {
 	case "local":
 		slog.Info("initialize aws session", "stage", "local", "package", "awssess")
-		sess = session.Must(session.NewSessionWithOptions(session.Options{
+		return session.NewSessionWithOptions(session.Options{
 			SharedConfigState: session.SharedConfigEnable,
-			Profile:           "personal",
+			Profile:           ops.Profile,
 			Config: aws.Config{
-				Region: aws.String("us-east-1"),
+				Region: aws.String(ops.Region),
 			},
-		}))
+		})
 	default:
-		sess = session.Must(session.NewSessionWithOptions(session.Options{
+		return session.NewSessionWithOptions(session.Options{
 			SharedConfigState: session.SharedConfigEnable,
-		}))
+		})
 	}
-	return sess
 }
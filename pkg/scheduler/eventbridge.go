@@ -2,15 +2,26 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsScheduler "github.com/aws/aws-sdk-go/service/scheduler"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
+var tracer = otel.Tracer("github.com/japb1998/action-scheduler/pkg/scheduler")
+
 // TimeZones our app will support
 var (
 	TimeZoneETD = "America/New_York"
@@ -22,22 +33,60 @@ var (
 	ErrInvalidTZ         = errors.New("Invalid time zone provided")
 	ErrNotFound          = errors.New("Schedule Not Found")
 	ErrInvalidExpression = errors.New("Invalid Expression Type")
+	ErrConflict          = errors.New("Schedule already exists")
+)
+
+type Outcome string
+
+const (
+	OutcomeOK        Outcome = "ok"
+	OutcomeError     Outcome = "error"
+	OutcomeThrottled Outcome = "throttled"
 )
 
+// MetricsRecorder is notified of every EventBridge API call
+type MetricsRecorder interface {
+	ObserveCall(operation string, outcome Outcome, duration time.Duration)
+}
+
 type SchedulerOps struct {
 	RetryAttempts int64
+	// Metrics is optional
+	Metrics MetricsRecorder
+	// Logger returns the logger of the call context. slog.Default is used when nil.
+	Logger func(context.Context) *slog.Logger
+	// RateLimit caps the API calls per second shared by every operation. 0 is unlimited.
+	RateLimit float64
 }
-type schedule struct {
+
+// Schedule is a schedule of the scheduler, built with NewSchedule. it is read only outside the package.
+type Schedule struct {
 	name       string
+	group      string
 	timeZone   string
 	payload    string
 	role       string
 	target     string
-	expression scheduleExpression
+	expression ScheduleExpression
+}
+
+func (s *Schedule) Name() string     { return s.name }
+func (s *Schedule) Group() string    { return s.group }
+func (s *Schedule) TimeZone() string { return s.timeZone }
+func (s *Schedule) Payload() string  { return s.payload }
+func (s *Schedule) Role() string     { return s.role }
+func (s *Schedule) Target() string   { return s.target }
+
+// Expression returns a copy of the expression of the schedule
+func (s *Schedule) Expression() *ScheduleExpression {
+	exp := s.expression
+	return &exp
 }
 
 type scheduler struct {
 	ebScheduler *awsScheduler.Scheduler
+	// limiter is nil when calls are not rate limited
+	limiter *rate.Limiter
 	SchedulerOps
 }
 
@@ -45,18 +94,28 @@ func NewScheduler(sess *session.Session, ops *SchedulerOps) *scheduler {
 	var schedulerOps SchedulerOps
 	s := awsScheduler.New(sess)
 
-	if ops == nil {
+	if ops != nil {
 		schedulerOps = *ops
 	}
+
+	var limiter *rate.Limiter
+	if schedulerOps.RateLimit > 0 {
+		// a burst of one second worth of calls
+		limiter = rate.NewLimiter(rate.Limit(schedulerOps.RateLimit), int(math.Ceil(schedulerOps.RateLimit)))
+	}
+
 	return &scheduler{
 		ebScheduler:  s,
+		limiter:      limiter,
 		SchedulerOps: schedulerOps,
 	}
 }
 
-func NewSchedule(name, targetID, role, tz, payload string, expression scheduleExpression) *schedule {
-	return &schedule{
+// NewSchedule creates a schedule. an empty group places the schedule in the default EventBridge schedule group.
+func NewSchedule(name, group, targetID, role, tz, payload string, expression ScheduleExpression) *Schedule {
+	return &Schedule{
 		name:       name,
+		group:      group,
 		timeZone:   tz,
 		payload:    payload,
 		role:       role,
@@ -67,12 +126,16 @@ func NewSchedule(name, targetID, role, tz, payload string, expression scheduleEx
 
 // CreateSchedule creates a schedule using aws eventBridge and returns the schedule name. important: schedule name must be unique.
 // token
-func (s *scheduler) CreateSchedule(sch *schedule, token string) (name string, err error) {
+func (s *scheduler) CreateSchedule(ctx context.Context, sch *Schedule, token string) (name string, err error) {
 
 	var expression string
 	var loc *time.Location
 	// 1. validate time zone
 	loc, err = time.LoadLocation(sch.timeZone)
+
+	if err != nil {
+		return "", fmt.Errorf("%w. tz=%s", ErrInvalidTZ, sch.timeZone)
+	}
 	// 2. get expression string based on expression type
 	expression, err = sch.expression.Expression(loc)
 
@@ -80,20 +143,11 @@ func (s *scheduler) CreateSchedule(sch *schedule, token string) (name string, er
 		return "", err
 	}
 
-	target := &awsScheduler.Target{
-		Arn:     &sch.target,
-		RoleArn: &sch.role,
-		Input:   &sch.payload,
-		RetryPolicy: &awsScheduler.RetryPolicy{
-			MaximumRetryAttempts: aws.Int64(s.SchedulerOps.RetryAttempts),
-		},
-	}
-
 	input := &awsScheduler.CreateScheduleInput{
 		Name:                       &sch.name,
 		ScheduleExpression:         &expression,
 		ActionAfterCompletion:      aws.String("DELETE"),
-		Target:                     target,
+		Target:                     s.target(sch),
 		ScheduleExpressionTimezone: &sch.timeZone,
 		FlexibleTimeWindow: &awsScheduler.FlexibleTimeWindow{
 			Mode: aws.String("OFF"),
@@ -101,6 +155,10 @@ func (s *scheduler) CreateSchedule(sch *schedule, token string) (name string, er
 		ClientToken: &token,
 	}
 
+	if sch.group != "" {
+		input.GroupName = &sch.group
+	}
+
 	if sch.expression.Type != OneTime && !sch.expression.Start.IsZero() {
 		input.StartDate = &sch.expression.Start
 	}
@@ -109,20 +167,33 @@ func (s *scheduler) CreateSchedule(sch *schedule, token string) (name string, er
 		input.EndDate = &sch.expression.End
 	}
 
-	_, err = s.ebScheduler.CreateSchedule(input)
+	err = s.call(ctx, "CreateSchedule", sch.group, func(ctx context.Context) error {
+		_, err := s.ebScheduler.CreateScheduleWithContext(ctx, input)
+		return err
+	})
 
+	var conflict *awsScheduler.ConflictException
+	if errors.As(err, &conflict) {
+		return "", fmt.Errorf("%w. name=%s", ErrConflict, sch.name)
+	}
 	if err != nil {
 		return "", fmt.Errorf("error while creating schedule error: %w", err)
 	}
 	return sch.name, nil
 }
 
-func (s *scheduler) DeleteSchedule(name, token string) error {
+func (s *scheduler) DeleteSchedule(ctx context.Context, group, name, token string) error {
 	input := &awsScheduler.DeleteScheduleInput{
 		Name:        &name,
 		ClientToken: &token,
 	}
-	_, err := s.ebScheduler.DeleteSchedule(input)
+	if group != "" {
+		input.GroupName = &group
+	}
+	err := s.call(ctx, "DeleteSchedule", group, func(ctx context.Context) error {
+		_, err := s.ebScheduler.DeleteScheduleWithContext(ctx, input)
+		return err
+	})
 	var notFound *awsScheduler.ResourceNotFoundException
 	if errors.As(err, &notFound) {
 		return ErrNotFound
@@ -130,12 +201,19 @@ func (s *scheduler) DeleteSchedule(name, token string) error {
 	return err
 }
 
-func (s *scheduler) GetSchedule(name string) (*schedule, error) {
+func (s *scheduler) GetSchedule(ctx context.Context, group, name string) (*Schedule, error) {
 	input := &awsScheduler.GetScheduleInput{
 		Name: aws.String(name),
 	}
+	if group != "" {
+		input.GroupName = &group
+	}
 
-	output, err := s.ebScheduler.GetSchedule(input)
+	var output *awsScheduler.GetScheduleOutput
+	err := s.call(ctx, "GetSchedule", group, func(ctx context.Context) (err error) {
+		output, err = s.ebScheduler.GetScheduleWithContext(ctx, input)
+		return err
+	})
 
 	if err != nil {
 		var notFound *awsScheduler.ResourceNotFoundException
@@ -150,11 +228,126 @@ func (s *scheduler) GetSchedule(name string) (*schedule, error) {
 		return nil, fmt.Errorf("error while parsing output expression error: %w", err)
 	}
 
-	sch := NewSchedule(*output.Name, *output.Target.Arn, *output.Target.RoleArn, *output.ScheduleExpressionTimezone, *output.Target.Input, *expression)
+	sch := NewSchedule(aws.StringValue(output.Name), aws.StringValue(output.GroupName), aws.StringValue(output.Target.Arn), aws.StringValue(output.Target.RoleArn),
+		aws.StringValue(output.ScheduleExpressionTimezone), aws.StringValue(output.Target.Input), *expression)
 
 	return sch, nil
 }
 
+// CreateScheduleGroup creates a schedule group. creating a group that already exists is not an error.
+func (s *scheduler) CreateScheduleGroup(ctx context.Context, name, token string) error {
+	input := &awsScheduler.CreateScheduleGroupInput{
+		Name:        &name,
+		ClientToken: &token,
+	}
+
+	err := s.call(ctx, "CreateScheduleGroup", name, func(ctx context.Context) error {
+		_, err := s.ebScheduler.CreateScheduleGroupWithContext(ctx, input)
+		return err
+	})
+
+	var conflict *awsScheduler.ConflictException
+	if errors.As(err, &conflict) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error while creating schedule group error: %w", err)
+	}
+	return nil
+}
+
+// DeleteScheduleGroup deletes a schedule group and every schedule in it.
+func (s *scheduler) DeleteScheduleGroup(ctx context.Context, name, token string) error {
+	input := &awsScheduler.DeleteScheduleGroupInput{
+		Name:        &name,
+		ClientToken: &token,
+	}
+	err := s.call(ctx, "DeleteScheduleGroup", name, func(ctx context.Context) error {
+		_, err := s.ebScheduler.DeleteScheduleGroupWithContext(ctx, input)
+		return err
+	})
+	var notFound *awsScheduler.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Ping checks that the scheduler API is reachable with the session credentials
+func (s *scheduler) Ping(ctx context.Context) error {
+	return s.call(ctx, "ListScheduleGroups", "", func(ctx context.Context) error {
+		_, err := s.ebScheduler.ListScheduleGroupsWithContext(ctx, &awsScheduler.ListScheduleGroupsInput{
+			MaxResults: aws.Int64(1),
+		})
+		return err
+	})
+}
+
+// call runs an EventBridge API call in its own client span and reports it to the metrics recorder
+func (s *scheduler) call(ctx context.Context, operation, group string, fn func(context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "Scheduler."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService("Scheduler"),
+			semconv.RPCMethod(operation),
+			attribute.String("scheduler.group", group),
+		),
+	)
+	defer span.End()
+
+	if s.limiter != nil {
+		waitStart := time.Now()
+		if err := s.limiter.Wait(ctx); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return fmt.Errorf("rate limited %s call not made. error=%w", operation, err)
+		}
+		span.SetAttributes(attribute.Int64("scheduler.rate_limit_wait_ms", time.Since(waitStart).Milliseconds()))
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	duration := time.Since(start)
+	s.observe(operation, duration, err)
+
+	logger := s.logger(ctx).With(slog.String("package", "scheduler"), slog.String("operation", operation), slog.String("group", group), slog.Duration("duration", duration))
+	if err != nil {
+		logger.Error("eventbridge call failed", slog.String("error", err.Error()))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		logger.Debug("eventbridge call succeeded")
+	}
+	return err
+}
+
+func (s *scheduler) logger(ctx context.Context) *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger(ctx)
+}
+
+// observe reports the call to the metrics recorder, if any
+func (s *scheduler) observe(operation string, duration time.Duration, err error) {
+	if s.Metrics == nil {
+		return
+	}
+
+	outcome := OutcomeOK
+	if err != nil {
+		outcome = OutcomeError
+
+		var throttled *awsScheduler.ThrottlingException
+		if errors.As(err, &throttled) {
+			outcome = OutcomeThrottled
+		}
+	}
+
+	s.Metrics.ObserveCall(operation, outcome, duration)
+}
+
 // loadTz - load time zone or return error if an invalid string is passed.
 func loadTz(tz string) (*time.Location, error) {
 
@@ -169,7 +362,68 @@ func loadTz(tz string) (*time.Location, error) {
 	return nil, ErrInvalidTZ
 }
 
-// UpdateSchedule - to be implemented
-func (s *scheduler) UpdateSchedule(sch *schedule) (name string, err error) {
-	return "", nil
+// UpdateSchedule replaces the expression, target and payload of an existing schedule and returns the schedule name.
+// the schedule is found by its name and group, which can't change.
+func (s *scheduler) UpdateSchedule(ctx context.Context, sch *Schedule) (name string, err error) {
+	loc, err := time.LoadLocation(sch.timeZone)
+
+	if err != nil {
+		return "", fmt.Errorf("%w. tz=%s", ErrInvalidTZ, sch.timeZone)
+	}
+
+	expression, err := sch.expression.Expression(loc)
+
+	if err != nil {
+		return "", err
+	}
+
+	// an update replaces the whole schedule, every field create sets is set again
+	input := &awsScheduler.UpdateScheduleInput{
+		Name:                       &sch.name,
+		ScheduleExpression:         &expression,
+		ActionAfterCompletion:      aws.String("DELETE"),
+		Target:                     s.target(sch),
+		ScheduleExpressionTimezone: &sch.timeZone,
+		FlexibleTimeWindow: &awsScheduler.FlexibleTimeWindow{
+			Mode: aws.String("OFF"),
+		},
+	}
+
+	if sch.group != "" {
+		input.GroupName = &sch.group
+	}
+
+	if sch.expression.Type != OneTime && !sch.expression.Start.IsZero() {
+		input.StartDate = &sch.expression.Start
+	}
+
+	if sch.expression.Type != OneTime && !sch.expression.End.IsZero() {
+		input.EndDate = &sch.expression.End
+	}
+
+	err = s.call(ctx, "UpdateSchedule", sch.group, func(ctx context.Context) error {
+		_, err := s.ebScheduler.UpdateScheduleWithContext(ctx, input)
+		return err
+	})
+
+	var notFound *awsScheduler.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", fmt.Errorf("%w. name=%s", ErrNotFound, sch.name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while updating schedule error: %w", err)
+	}
+	return sch.name, nil
+}
+
+// target is the lambda the schedule invokes with its payload
+func (s *scheduler) target(sch *Schedule) *awsScheduler.Target {
+	return &awsScheduler.Target{
+		Arn:     &sch.target,
+		RoleArn: &sch.role,
+		Input:   &sch.payload,
+		RetryPolicy: &awsScheduler.RetryPolicy{
+			MaximumRetryAttempts: aws.Int64(s.SchedulerOps.RetryAttempts),
+		},
+	}
 }
@@ -0,0 +1,163 @@
+package scheduler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+	"github.com/japb1998/action-scheduler/pkg/scheduler/schedulertest"
+)
+
+// TestEventBridge runs the conformance suite against the EventBridge client, talking to an emulation of the Scheduler REST API
+func TestEventBridge(t *testing.T) {
+	schedulertest.Run(t, func(t *testing.T) scheduler.Scheduler {
+		srv := httptest.NewServer(newEventBridge())
+		t.Cleanup(srv.Close)
+
+		sess, err := session.NewSession(&aws.Config{
+			Region:      aws.String("us-east-1"),
+			Endpoint:    aws.String(srv.URL),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+			MaxRetries:  aws.Int(0),
+		})
+		if err != nil {
+			t.Fatalf("error creating session: %v", err)
+		}
+		return scheduler.NewScheduler(sess, nil)
+	})
+}
+
+// eventBridge emulates the schedule and schedule group operations of EventBridge Scheduler.
+// schedules are kept as the JSON they were created with, GetSchedule returns it back.
+type eventBridge struct {
+	mu        sync.Mutex
+	groups    map[string]bool
+	schedules map[string]map[string]json.RawMessage
+	tokens    map[string]string
+}
+
+func newEventBridge() *eventBridge {
+	return &eventBridge{
+		groups:    map[string]bool{"default": true},
+		schedules: make(map[string]map[string]json.RawMessage),
+		tokens:    make(map[string]string),
+	}
+}
+
+func (e *eventBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/schedule-groups/"):
+		e.group(w, r, strings.TrimPrefix(r.URL.Path, "/schedule-groups/"))
+	case strings.HasPrefix(r.URL.Path, "/schedules/"):
+		e.schedule(w, r, strings.TrimPrefix(r.URL.Path, "/schedules/"))
+	default:
+		fail(w, http.StatusNotFound, "UnknownOperationException")
+	}
+}
+
+func (e *eventBridge) group(w http.ResponseWriter, r *http.Request, name string) {
+	switch r.Method {
+	case http.MethodPost:
+		if e.groups[name] {
+			fail(w, http.StatusConflict, "ConflictException")
+			return
+		}
+		e.groups[name] = true
+		reply(w, map[string]string{"ScheduleGroupArn": "arn:aws:scheduler:us-east-1:000000000000:schedule-group/" + name})
+	case http.MethodDelete:
+		if !e.groups[name] {
+			fail(w, http.StatusNotFound, "ResourceNotFoundException")
+			return
+		}
+		delete(e.groups, name)
+		for key := range e.schedules {
+			if strings.HasPrefix(key, name+"/") {
+				delete(e.schedules, key)
+			}
+		}
+		reply(w, map[string]string{})
+	default:
+		fail(w, http.StatusMethodNotAllowed, "ValidationException")
+	}
+}
+
+func (e *eventBridge) schedule(w http.ResponseWriter, r *http.Request, name string) {
+	// gets and deletes have the group in the query, creates and updates in the body
+	group := r.URL.Query().Get("groupName")
+
+	var body map[string]json.RawMessage
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			fail(w, http.StatusBadRequest, "ValidationException")
+			return
+		}
+		_ = json.Unmarshal(body["GroupName"], &group)
+	}
+	if group == "" {
+		group = "default"
+	}
+	key := group + "/" + name
+	arn := map[string]string{"ScheduleArn": "arn:aws:scheduler:us-east-1:000000000000:schedule/" + key}
+
+	if r.Method == http.MethodPost {
+		var token string
+		_ = json.Unmarshal(body["ClientToken"], &token)
+		if !e.groups[group] {
+			fail(w, http.StatusNotFound, "ResourceNotFoundException")
+			return
+		}
+		if _, ok := e.schedules[key]; ok && e.tokens[key] != token {
+			fail(w, http.StatusConflict, "ConflictException")
+			return
+		}
+		body["Name"], _ = json.Marshal(name)
+		body["GroupName"], _ = json.Marshal(group)
+		e.schedules[key], e.tokens[key] = body, token
+		reply(w, arn)
+		return
+	}
+
+	sch, ok := e.schedules[key]
+
+	if !ok {
+		fail(w, http.StatusNotFound, "ResourceNotFoundException")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		reply(w, sch)
+	case http.MethodPut:
+		// an update replaces the schedule
+		body["Name"], body["GroupName"] = sch["Name"], sch["GroupName"]
+		e.schedules[key] = body
+		reply(w, arn)
+	case http.MethodDelete:
+		delete(e.schedules, key)
+		reply(w, map[string]string{})
+	default:
+		fail(w, http.StatusMethodNotAllowed, "ValidationException")
+	}
+}
+
+func reply(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func fail(w http.ResponseWriter, status int, errorType string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Amzn-Errortype", errorType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"Message": errorType})
+}
@@ -2,16 +2,19 @@ package scheduler
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	awsScheduler "github.com/aws/aws-sdk-go/service/scheduler"
 )
 
 // schedule types
 const (
 	Monthly = "monthly"
+	Weekly  = "weekly"
 	Daily   = "daily"
 	OneTime = "one_time"
 )
@@ -21,11 +24,15 @@ var (
 	ErrInvalidDay  = fmt.Errorf("invalid day")
 )
 
+// cronWeekdays are the day-of-week names of EventBridge cron expressions
+var cronWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
 /*
-scheduleExpression
+ScheduleExpression
 start -
 
 	this is the start time of the schedule. if the schedule type is monthly, this is the day and time of the month.
+	if the schedule type is weekly, this is the day of the week and time.
 	if the schedule type is daily, this is the time of day.
 	if the schedule type is one_time, this is the date and time of the schedule
 
@@ -34,18 +41,18 @@ end - this is the end time of the schedule. if the schedule type is monthly, thi
 	if the schedule type is daily, this is the end date of the schedule
 	if the schedule type is one_time, this is disregarded
 
-type - this is the type of schedule. it can be monthly, daily, or one_time
+type - this is the type of schedule. it can be monthly, weekly, daily, or one_time
 */
-type scheduleExpression struct {
+type ScheduleExpression struct {
 	Start time.Time
 	End   time.Time
 	Type  string
 }
 
 // NewExpression creates a new schedule expression, and validates the expression type
-func NewExpression(start, end time.Time, t string) (*scheduleExpression, error) {
+func NewExpression(start, end time.Time, t string) (*ScheduleExpression, error) {
 
-	exp := &scheduleExpression{
+	exp := &ScheduleExpression{
 		Start: start,
 		End:   end,
 		Type:  t,
@@ -54,7 +61,7 @@ func NewExpression(start, end time.Time, t string) (*scheduleExpression, error)
 	return exp, exp.validate()
 }
 
-func (se *scheduleExpression) Expression(loc *time.Location) (string, error) {
+func (se *ScheduleExpression) Expression(loc *time.Location) (string, error) {
 	switch se.Type {
 	case Monthly:
 		if se.Start.IsZero() {
@@ -64,6 +71,12 @@ func (se *scheduleExpression) Expression(loc *time.Location) (string, error) {
 			return fmt.Sprintf("cron(%d %d %d * ? *)", time.Now().In(loc).Minute(), time.Now().In(loc).Hour(), time.Now().In(loc).Day()), nil
 		}
 		return fmt.Sprintf("cron(%d %d %d * ? *)", se.Start.In(loc).Minute(), se.Start.In(loc).Hour(), se.Start.In(loc).Day()), nil
+	case Weekly:
+		start := se.Start.In(loc)
+		if se.Start.IsZero() {
+			start = time.Now().In(loc)
+		}
+		return fmt.Sprintf("cron(%d %d ? * %s *)", start.Minute(), start.Hour(), cronWeekdays[start.Weekday()]), nil
 	case Daily:
 		return "rate(1day)", nil
 	case OneTime:
@@ -73,11 +86,73 @@ func (se *scheduleExpression) Expression(loc *time.Location) (string, error) {
 	}
 }
 
-func (se *scheduleExpression) String() string {
+// Next returns up to n times after from at which the expression fires in loc. from stands in for the current time, as Expression uses it for monthly schedules without start.
+func (se *ScheduleExpression) Next(from time.Time, n int, loc *time.Location) []time.Time {
+	var runs []time.Time
+	after := func(t time.Time) bool {
+		return t.After(from) && (se.Start.IsZero() || !t.Before(se.Start)) && (se.End.IsZero() || !t.After(se.End))
+	}
+
+	switch se.Type {
+	case OneTime:
+		if se.Start.After(from) && n > 0 {
+			runs = append(runs, se.Start.In(loc))
+		}
+	case Daily:
+		// rate(1day) fires every 24 hours from the start date, or from creation when there is none
+		t := from.In(loc)
+		if !se.Start.IsZero() {
+			t = se.Start.In(loc)
+		}
+		for ; len(runs) < n && (se.End.IsZero() || !t.After(se.End)); t = t.AddDate(0, 0, 1) {
+			if after(t) {
+				runs = append(runs, t)
+			}
+		}
+	case Monthly:
+		anchor := from.In(loc)
+		if !se.Start.IsZero() {
+			anchor = se.Start.In(loc)
+		}
+		// same rule as Expression, a schedule without start created on the last day of the month uses L
+		last := se.Start.IsZero() && anchor.AddDate(0, 0, 1).Month() != anchor.Month()
+
+		for i := 0; len(runs) < n; i++ {
+			month := time.Date(anchor.Year(), anchor.Month()+time.Month(i), 1, anchor.Hour(), anchor.Minute(), 0, 0, loc)
+			if !se.End.IsZero() && month.After(se.End) {
+				break
+			}
+
+			day := anchor.Day()
+			if last {
+				day = month.AddDate(0, 1, -1).Day()
+			}
+			t := month.AddDate(0, 0, day-1)
+
+			// cron skips the months without that day
+			if t.Month() == month.Month() && after(t) {
+				runs = append(runs, t)
+			}
+		}
+	case Weekly:
+		t := from.In(loc).Truncate(time.Minute)
+		if !se.Start.IsZero() {
+			t = se.Start.In(loc)
+		}
+		for ; len(runs) < n && (se.End.IsZero() || !t.After(se.End)); t = t.AddDate(0, 0, 7) {
+			if after(t) {
+				runs = append(runs, t)
+			}
+		}
+	}
+	return runs
+}
+
+func (se *ScheduleExpression) String() string {
 	return fmt.Sprintf("start: %s, end: %s, type: %s", se.Start, se.End, se.Type)
 }
 
-func (se *scheduleExpression) validate() error {
+func (se *ScheduleExpression) validate() error {
 	switch se.Type {
 	case OneTime:
 		{
@@ -102,6 +177,15 @@ func (se *scheduleExpression) validate() error {
 				return fmt.Errorf("%w. description: date and type combination for this event would never happen", ErrInvalidExpression)
 			}
 		}
+	case Weekly:
+		{
+			if !se.Start.IsZero() && !se.End.IsZero() && !se.Start.Before(se.End) {
+				return fmt.Errorf("%w. description: start time must happen before end", ErrInvalidExpression)
+			}
+			if se.Start.IsZero() && !se.End.IsZero() && se.End.Before(time.Now().AddDate(0, 0, 7)) {
+				return fmt.Errorf("%w. description: date and type combination for this event would never happen", ErrInvalidExpression)
+			}
+		}
 	case Daily:
 		{
 			if !se.Start.IsZero() && !se.End.IsZero() && !se.Start.Before(se.End) {
@@ -117,42 +201,100 @@ func (se *scheduleExpression) validate() error {
 	return nil
 }
 
-// unmarshalExpression takes a GetScheduleOutput and returns a scheduleExpression or error
-func unmarshalExpression(out awsScheduler.GetScheduleOutput) (*scheduleExpression, error) {
-	se := &scheduleExpression{}
+// unmarshalExpression reads the expression of a schedule back. cron and at expressions are in the time zone of the schedule.
+// CreateSchedule sends the start of recurring expressions as the start date, so it is kept as is.
+// without start date the start is the next time the cron fires, a monthly schedule on the last day (L) is read as on the last day of the current month.
+func unmarshalExpression(out awsScheduler.GetScheduleOutput) (*ScheduleExpression, error) {
+	loc := time.UTC
+	if tz := aws.StringValue(out.ScheduleExpressionTimezone); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("%w. tz=%s", ErrInvalidTZ, tz)
+		}
+	}
 
-	expression := *out.ScheduleExpression
-	if strings.HasPrefix(expression, "cron") {
-		cronFields := strings.Split(strings.TrimSuffix(strings.TrimPrefix(*out.ScheduleExpression, "cron("), ")"), " ")
-		hour, err := strconv.Atoi(cronFields[1])
+	se := &ScheduleExpression{}
+	if out.StartDate != nil {
+		se.Start = *out.StartDate
+	}
+	if out.EndDate != nil {
+		se.End = *out.EndDate
+	}
 
-		if err != nil {
-			return nil, ErrInvalidHour
-		}
-		day, err := strconv.Atoi(cronFields[2])
-		if err != nil {
-			return nil, ErrInvalidDay
+	expression := aws.StringValue(out.ScheduleExpression)
+	switch {
+	case strings.HasPrefix(expression, "cron("):
+		cronFields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(expression, "cron("), ")"))
+		if len(cronFields) != 6 {
+			return nil, ErrInvalidExpression
 		}
-		t := time.Date(out.StartDate.Day(), out.StartDate.Month(), day, hour, 0, 0, 0, time.UTC)
 
 		se.Type = Monthly
-		se.Start = t
-		se.End = *out.EndDate
-	} else if strings.HasPrefix(expression, "rate") {
+		if cronFields[2] == "?" {
+			se.Type = Weekly
+		}
+		if se.Start.IsZero() {
+			start, err := cronStart(cronFields, time.Now().In(loc))
+			if err != nil {
+				return nil, err
+			}
+			se.Start = start
+		}
+	case strings.HasPrefix(expression, "rate("):
 		se.Type = Daily
-		se.Start = *out.StartDate
-		se.End = time.Time{}
-	} else if strings.HasPrefix(expression, "at") {
-		se.Type = OneTime
-		t, err := time.Parse("2006-01-02T15:04:05", strings.TrimSuffix(strings.TrimPrefix(*out.ScheduleExpression, "at("), ")"))
+	case strings.HasPrefix(expression, "at("):
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", strings.TrimSuffix(strings.TrimPrefix(expression, "at("), ")"), loc)
 
 		if err != nil {
 			return nil, fmt.Errorf("error while parsing output expression error: %w", err)
 		}
+		se.Type = OneTime
 		se.Start = t
-	} else {
+		se.End = time.Time{}
+	default:
 		return nil, ErrInvalidExpression
 	}
 
 	return se, nil
 }
+
+// cronStart returns the first time at or after now a monthly (M H D * ? *) or weekly (M H ? * DOW *) cron fires
+func cronStart(cronFields []string, now time.Time) (time.Time, error) {
+	minute, err := strconv.Atoi(cronFields[0])
+	if err != nil {
+		return time.Time{}, ErrInvalidHour
+	}
+	hour, err := strconv.Atoi(cronFields[1])
+	if err != nil {
+		return time.Time{}, ErrInvalidHour
+	}
+	at := func(day int) time.Time {
+		return time.Date(now.Year(), now.Month(), day, hour, minute, 0, 0, now.Location())
+	}
+
+	// weekly
+	if cronFields[2] == "?" {
+		weekday := slices.Index(cronWeekdays, cronFields[4])
+		if weekday < 0 {
+			return time.Time{}, ErrInvalidDay
+		}
+		start := at(now.Day()).AddDate(0, 0, (weekday-int(now.Weekday())+7)%7)
+		if start.Before(now) {
+			start = start.AddDate(0, 0, 7)
+		}
+		return start, nil
+	}
+
+	if cronFields[2] == "L" {
+		return at(1).AddDate(0, 1, -1), nil
+	}
+	day, err := strconv.Atoi(cronFields[2])
+	if err != nil {
+		return time.Time{}, ErrInvalidDay
+	}
+	start := at(day)
+	if start.Before(now) {
+		start = start.AddDate(0, 1, 0)
+	}
+	return start, nil
+}
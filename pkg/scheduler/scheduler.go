@@ -1,8 +1,12 @@
 package scheduler
 
+import "context"
+
 type Scheduler interface {
-	CreateSchedule(*schedule, string) (string, error)
-	DeleteSchedule(name, token string) error
-	GetSchedule(name string) (*schedule, error)
-	UpdateSchedule(sch *schedule) (string, error)
+	CreateSchedule(ctx context.Context, sch *Schedule, token string) (string, error)
+	DeleteSchedule(ctx context.Context, group, name, token string) error
+	GetSchedule(ctx context.Context, group, name string) (*Schedule, error)
+	UpdateSchedule(ctx context.Context, sch *Schedule) (string, error)
+	CreateScheduleGroup(ctx context.Context, name, token string) error
+	DeleteScheduleGroup(ctx context.Context, name, token string) error
 }
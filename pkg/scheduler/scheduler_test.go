@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -9,19 +10,26 @@ func TestExpression(t *testing.T) {
 
 	expressions := []struct {
 		expression string
-		scheduleExpression
+		ScheduleExpression
 		valid bool
 	}{{
 		expression: "cron(0 12 1 * ? *)",
-		scheduleExpression: scheduleExpression{
+		ScheduleExpression: ScheduleExpression{
 			Start: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
 			End:   time.Time{},
 			Type:  Monthly,
 		},
 		valid: true,
+	}, {
+		expression: "cron(30 8 ? * THU *)",
+		ScheduleExpression: ScheduleExpression{
+			Start: time.Date(2030, 1, 3, 8, 30, 0, 0, time.UTC),
+			Type:  Weekly,
+		},
+		valid: true,
 	}, {
 		expression: "rate(1day)",
-		scheduleExpression: scheduleExpression{
+		ScheduleExpression: ScheduleExpression{
 			Start: time.Time{},
 			End:   time.Time{},
 			Type:  Daily,
@@ -30,7 +38,7 @@ func TestExpression(t *testing.T) {
 	},
 		{
 			expression: "rate(1day)",
-			scheduleExpression: scheduleExpression{
+			ScheduleExpression: ScheduleExpression{
 				Start: time.Time{},
 				End:   time.Now(),
 				Type:  Daily,
@@ -61,3 +69,96 @@ func TestExpression(t *testing.T) {
 
 	}
 }
+
+func TestNext(t *testing.T) {
+	from := time.Date(2030, 1, 15, 9, 0, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2030, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		ScheduleExpression
+		want []time.Time
+	}{
+		{
+			name:               "one time",
+			ScheduleExpression: ScheduleExpression{Type: OneTime, Start: at(2, 1, 10)},
+			want:               []time.Time{at(2, 1, 10)},
+		},
+		{
+			name:               "one time in the past",
+			ScheduleExpression: ScheduleExpression{Type: OneTime, Start: at(1, 1, 10)},
+		},
+		{
+			name:               "daily from start",
+			ScheduleExpression: ScheduleExpression{Type: Daily, Start: at(1, 10, 8)},
+			want:               []time.Time{at(1, 16, 8), at(1, 17, 8), at(1, 18, 8)},
+		},
+		{
+			name:               "daily until end",
+			ScheduleExpression: ScheduleExpression{Type: Daily, Start: at(1, 10, 8), End: at(1, 17, 8)},
+			want:               []time.Time{at(1, 16, 8), at(1, 17, 8)},
+		},
+		{
+			name:               "weekly on the start weekday",
+			ScheduleExpression: ScheduleExpression{Type: Weekly, Start: at(1, 3, 8), End: at(1, 31, 8)},
+			want:               []time.Time{at(1, 17, 8), at(1, 24, 8), at(1, 31, 8)},
+		},
+		{
+			name:               "monthly skips short months",
+			ScheduleExpression: ScheduleExpression{Type: Monthly, Start: at(1, 31, 12)},
+			want:               []time.Time{at(1, 31, 12), at(3, 31, 12), at(5, 31, 12)},
+		},
+		{
+			name:               "monthly without start",
+			ScheduleExpression: ScheduleExpression{Type: Monthly},
+			want:               []time.Time{at(2, 15, 9), at(3, 15, 9), at(4, 15, 9)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.Next(from, 3, time.UTC)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d runs. got=%v", len(tt.want), got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("run %d: expected %s. got=%s", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCronStart(t *testing.T) {
+	loc, err := time.LoadLocation(TimeZoneETD)
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+	// a Wednesday
+	now := time.Date(2030, 1, 16, 10, 0, 0, 0, loc)
+
+	tests := []struct {
+		cron string
+		want time.Time
+	}{
+		{cron: "30 9 20 * ? *", want: time.Date(2030, 1, 20, 9, 30, 0, 0, loc)},
+		{cron: "30 9 10 * ? *", want: time.Date(2030, 2, 10, 9, 30, 0, 0, loc)},
+		{cron: "0 8 L * ? *", want: time.Date(2030, 1, 31, 8, 0, 0, 0, loc)},
+		{cron: "0 12 ? * FRI *", want: time.Date(2030, 1, 18, 12, 0, 0, 0, loc)},
+		{cron: "0 9 ? * WED *", want: time.Date(2030, 1, 23, 9, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		got, err := cronStart(strings.Fields(tt.cron), now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.cron, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v. got=%v", tt.cron, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// schedulertest package provides an in-memory scheduler.Scheduler for the tests of its users
+package schedulertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+// operations of the scheduler, as recorded in Call.Operation and accepted by Fake.Fail
+const (
+	OpCreateSchedule      = "CreateSchedule"
+	OpDeleteSchedule      = "DeleteSchedule"
+	OpGetSchedule         = "GetSchedule"
+	OpUpdateSchedule      = "UpdateSchedule"
+	OpCreateScheduleGroup = "CreateScheduleGroup"
+	OpDeleteScheduleGroup = "DeleteScheduleGroup"
+)
+
+// Call is a call made to the fake. failed calls are recorded too.
+type Call struct {
+	Operation string
+	Group     string
+	Name      string
+	Token     string
+	Err       error
+}
+
+// Fake keeps the schedules in memory and records every call. it is safe for concurrent use.
+//
+// it answers like EventBridge: creating an existing schedule is scheduler.ErrConflict unless it is a retry with the same client token,
+// deleting or getting a missing one is scheduler.ErrNotFound, and deleting a group deletes its schedules.
+// the empty group is the default group, which always exists.
+type Fake struct {
+	mu        sync.Mutex
+	schedules map[key]*scheduler.Schedule
+	// tokens are the client tokens the schedules were created with
+	tokens   map[key]string
+	groups   map[string]bool
+	calls    []Call
+	failures map[string]error
+}
+
+type key struct{ group, name string }
+
+var _ scheduler.Scheduler = (*Fake)(nil)
+
+func New() *Fake {
+	return &Fake{
+		schedules: make(map[key]*scheduler.Schedule),
+		tokens:    make(map[key]string),
+		groups:    make(map[string]bool),
+		failures:  make(map[string]error),
+	}
+}
+
+// Fail makes every call of the operation fail with err, without side effect, until Fail is called again with a nil err
+func (f *Fake) Fail(operation string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.failures, operation)
+		return
+	}
+	f.failures[operation] = err
+}
+
+// Calls returns the calls made so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsOf returns the calls of an operation
+func (f *Fake) CallsOf(operation string) []Call {
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Operation == operation {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Schedule returns the schedule stored under the group and name, nil when there is none
+func (f *Fake) Schedule(group, name string) *scheduler.Schedule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.schedules[key{group, name}]
+}
+
+// Len is the number of schedules stored in every group
+func (f *Fake) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.schedules)
+}
+
+func (f *Fake) CreateSchedule(ctx context.Context, sch *scheduler.Schedule, token string) (string, error) {
+	err := f.record(Call{Operation: OpCreateSchedule, Group: sch.Group(), Name: sch.Name(), Token: token}, func() error {
+		if err := f.checkGroup(sch.Group()); err != nil {
+			return err
+		}
+
+		k := key{sch.Group(), sch.Name()}
+		if _, ok := f.schedules[k]; ok {
+			if token != "" && f.tokens[k] == token {
+				return nil
+			}
+			return fmt.Errorf("%w. name=%s", scheduler.ErrConflict, sch.Name())
+		}
+		f.schedules[k] = sch
+		f.tokens[k] = token
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return sch.Name(), nil
+}
+
+func (f *Fake) DeleteSchedule(ctx context.Context, group, name, token string) error {
+	return f.record(Call{Operation: OpDeleteSchedule, Group: group, Name: name, Token: token}, func() error {
+		k := key{group, name}
+		if _, ok := f.schedules[k]; !ok {
+			return scheduler.ErrNotFound
+		}
+		delete(f.schedules, k)
+		delete(f.tokens, k)
+		return nil
+	})
+}
+
+func (f *Fake) GetSchedule(ctx context.Context, group, name string) (*scheduler.Schedule, error) {
+	var sch *scheduler.Schedule
+
+	err := f.record(Call{Operation: OpGetSchedule, Group: group, Name: name}, func() error {
+		var ok bool
+		if sch, ok = f.schedules[key{group, name}]; !ok {
+			return scheduler.ErrNotFound
+		}
+		return nil
+	})
+	return sch, err
+}
+
+func (f *Fake) UpdateSchedule(ctx context.Context, sch *scheduler.Schedule) (string, error) {
+	err := f.record(Call{Operation: OpUpdateSchedule, Group: sch.Group(), Name: sch.Name()}, func() error {
+		k := key{sch.Group(), sch.Name()}
+		if _, ok := f.schedules[k]; !ok {
+			return scheduler.ErrNotFound
+		}
+		f.schedules[k] = sch
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return sch.Name(), nil
+}
+
+// CreateScheduleGroup creates a group. creating a group that already exists is not an error.
+func (f *Fake) CreateScheduleGroup(ctx context.Context, name, token string) error {
+	return f.record(Call{Operation: OpCreateScheduleGroup, Group: name, Token: token}, func() error {
+		f.groups[name] = true
+		return nil
+	})
+}
+
+func (f *Fake) DeleteScheduleGroup(ctx context.Context, name, token string) error {
+	return f.record(Call{Operation: OpDeleteScheduleGroup, Group: name, Token: token}, func() error {
+		if !f.groups[name] {
+			return scheduler.ErrNotFound
+		}
+		delete(f.groups, name)
+		for k := range f.schedules {
+			if k.group == name {
+				delete(f.schedules, k)
+				delete(f.tokens, k)
+			}
+		}
+		return nil
+	})
+}
+
+// record runs the operation unless a failure is injected for it, and records the call with its outcome
+func (f *Fake) record(call Call, op func() error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err, ok := f.failures[call.Operation]; ok {
+		call.Err = err
+	} else {
+		call.Err = op()
+	}
+	f.calls = append(f.calls, call)
+	return call.Err
+}
+
+func (f *Fake) checkGroup(group string) error {
+	if group != "" && !f.groups[group] {
+		return fmt.Errorf("%w. group=%s", scheduler.ErrNotFound, group)
+	}
+	return nil
+}
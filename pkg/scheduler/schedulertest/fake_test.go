@@ -0,0 +1,13 @@
+package schedulertest
+
+import (
+	"testing"
+
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+func TestFake(t *testing.T) {
+	Run(t, func(t *testing.T) scheduler.Scheduler {
+		return New()
+	})
+}
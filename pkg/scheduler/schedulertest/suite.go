@@ -0,0 +1,205 @@
+package schedulertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/japb1998/action-scheduler/pkg/scheduler"
+)
+
+// group every schedule of the suite is created in
+const group = "conformance"
+
+// Run checks that the schedulers returned by newScheduler behave the way the services rely on.
+// every subtest gets its own scheduler, with no schedule and no group.
+func Run(t *testing.T, newScheduler func(t *testing.T) scheduler.Scheduler) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) scheduler.Scheduler {
+		t.Helper()
+
+		s := newScheduler(t)
+		if err := s.CreateScheduleGroup(ctx, group, "group-token"); err != nil {
+			t.Fatalf("error creating group %s: %v", group, err)
+		}
+		return s
+	}
+
+	t.Run("create get delete", func(t *testing.T) {
+		s := setup(t)
+		want := newSchedule(t, "digest", scheduler.Daily, time.Now().Add(time.Hour), time.Time{})
+
+		if name, err := s.CreateSchedule(ctx, want, "token-1"); err != nil || name != want.Name() {
+			t.Fatalf("expected %s created. got=%s %v", want.Name(), name, err)
+		}
+
+		got, err := s.GetSchedule(ctx, group, want.Name())
+		if err != nil {
+			t.Fatalf("error getting schedule: %v", err)
+		}
+		if got.Name() != want.Name() || got.Group() != group || got.Target() != want.Target() || got.Role() != want.Role() || got.Payload() != want.Payload() || got.TimeZone() != want.TimeZone() {
+			t.Errorf("expected %+v. got=%+v", want, got)
+		}
+
+		if err := s.DeleteSchedule(ctx, group, want.Name(), "token-2"); err != nil {
+			t.Fatalf("error deleting schedule: %v", err)
+		}
+		if _, err := s.GetSchedule(ctx, group, want.Name()); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected %v after delete. got=%v", scheduler.ErrNotFound, err)
+		}
+	})
+
+	t.Run("missing schedule", func(t *testing.T) {
+		s := setup(t)
+
+		if _, err := s.GetSchedule(ctx, group, "missing"); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected %v getting a missing schedule. got=%v", scheduler.ErrNotFound, err)
+		}
+		if err := s.DeleteSchedule(ctx, group, "missing", "token-1"); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected %v deleting a missing schedule. got=%v", scheduler.ErrNotFound, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		s := setup(t)
+		sch := newSchedule(t, "digest", scheduler.Daily, time.Now().Add(time.Hour), time.Time{})
+
+		if _, err := s.UpdateSchedule(ctx, sch); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected %v updating a missing schedule. got=%v", scheduler.ErrNotFound, err)
+		}
+		if _, err := s.CreateSchedule(ctx, sch, "token-1"); err != nil {
+			t.Fatalf("error creating schedule: %v", err)
+		}
+
+		exp, err := scheduler.NewExpression(time.Now().Add(2*time.Hour), time.Time{}, scheduler.Weekly)
+		if err != nil {
+			t.Fatalf("invalid expression: %v", err)
+		}
+		want := scheduler.NewSchedule(sch.Name(), group, sch.Target(), sch.Role(), sch.TimeZone(), `{"to":"c@d.e"}`, *exp)
+
+		if name, err := s.UpdateSchedule(ctx, want); err != nil || name != want.Name() {
+			t.Fatalf("expected %s updated. got=%s %v", want.Name(), name, err)
+		}
+		got, err := s.GetSchedule(ctx, group, want.Name())
+		if err != nil {
+			t.Fatalf("error getting schedule: %v", err)
+		}
+		if got.Payload() != want.Payload() || got.Expression().Type != scheduler.Weekly {
+			t.Errorf("expected %+v. got=%+v", want, got)
+		}
+	})
+
+	t.Run("client token", func(t *testing.T) {
+		s := setup(t)
+		sch := newSchedule(t, "digest", scheduler.Weekly, time.Now().Add(time.Hour), time.Time{})
+
+		if _, err := s.CreateSchedule(ctx, sch, "token-1"); err != nil {
+			t.Fatalf("error creating schedule: %v", err)
+		}
+		// a retry of the same request succeeds
+		if _, err := s.CreateSchedule(ctx, sch, "token-1"); err != nil {
+			t.Errorf("expected a retry with the same token to succeed. got=%v", err)
+		}
+		if _, err := s.CreateSchedule(ctx, sch, "token-2"); !errors.Is(err, scheduler.ErrConflict) {
+			t.Errorf("expected %v with another token. got=%v", scheduler.ErrConflict, err)
+		}
+
+		if err := s.DeleteSchedule(ctx, group, sch.Name(), "token-3"); err != nil {
+			t.Fatalf("error deleting schedule: %v", err)
+		}
+		if _, err := s.GetSchedule(ctx, group, sch.Name()); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected the retried schedule stored once. got=%v", err)
+		}
+	})
+
+	t.Run("expression round trip", func(t *testing.T) {
+		loc, err := time.LoadLocation(scheduler.TimeZoneETD)
+		if err != nil {
+			t.Fatalf("error loading location: %v", err)
+		}
+		// whole minutes, the precision of the expressions
+		start := time.Now().In(loc).Truncate(time.Minute).AddDate(0, 0, 3)
+
+		tests := []struct {
+			typ        string
+			start, end time.Time
+		}{
+			{typ: scheduler.Daily, start: start, end: start.AddDate(0, 1, 0)},
+			{typ: scheduler.Daily},
+			{typ: scheduler.Weekly, start: start},
+			{typ: scheduler.Monthly, start: start, end: start.AddDate(1, 0, 0)},
+			{typ: scheduler.OneTime, start: start},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.typ, func(t *testing.T) {
+				s := setup(t)
+				want := newSchedule(t, "round-trip", tt.typ, tt.start, tt.end)
+
+				if _, err := s.CreateSchedule(ctx, want, "token-1"); err != nil {
+					t.Fatalf("error creating schedule: %v", err)
+				}
+				got, err := s.GetSchedule(ctx, group, want.Name())
+				if err != nil {
+					t.Fatalf("error getting schedule: %v", err)
+				}
+
+				wantExp, gotExp := want.Expression(), got.Expression()
+				if gotExp.Type != wantExp.Type || !gotExp.End.Equal(wantExp.End) {
+					t.Fatalf("expected %s. got=%s", wantExp, gotExp)
+				}
+				// schedules without start fire relative to their creation, only the ones with a start are the same from any time
+				if tt.start.IsZero() {
+					return
+				}
+				from := time.Now()
+				wantRuns, gotRuns := wantExp.Next(from, 4, loc), gotExp.Next(from, 4, loc)
+				if len(gotRuns) != len(wantRuns) {
+					t.Fatalf("expected runs %v. got=%v", wantRuns, gotRuns)
+				}
+				for i := range wantRuns {
+					if !gotRuns[i].Equal(wantRuns[i]) {
+						t.Errorf("expected runs %v. got=%v", wantRuns, gotRuns)
+						break
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		s := setup(t)
+
+		if err := s.CreateScheduleGroup(ctx, group, "group-token"); err != nil {
+			t.Errorf("expected creating an existing group to succeed. got=%v", err)
+		}
+
+		sch := newSchedule(t, "digest", scheduler.Daily, time.Time{}, time.Time{})
+		if _, err := s.CreateSchedule(ctx, sch, "token-1"); err != nil {
+			t.Fatalf("error creating schedule: %v", err)
+		}
+
+		if err := s.DeleteScheduleGroup(ctx, group, "group-token-2"); err != nil {
+			t.Fatalf("error deleting group: %v", err)
+		}
+		if _, err := s.GetSchedule(ctx, group, sch.Name()); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected the schedules of a deleted group deleted. got=%v", err)
+		}
+		if err := s.DeleteScheduleGroup(ctx, group, "group-token-3"); !errors.Is(err, scheduler.ErrNotFound) {
+			t.Errorf("expected %v deleting a missing group. got=%v", scheduler.ErrNotFound, err)
+		}
+	})
+}
+
+func newSchedule(t *testing.T, name, typ string, start, end time.Time) *scheduler.Schedule {
+	t.Helper()
+
+	exp, err := scheduler.NewExpression(start, end, typ)
+	if err != nil {
+		t.Fatalf("invalid expression: %v", err)
+	}
+	return scheduler.NewSchedule(name, group, "arn:aws:lambda:us-east-1:000000000000:function:action", "arn:aws:iam::000000000000:role/scheduler",
+		scheduler.TimeZoneETD, `{"to":"a@b.c"}`, *exp)
+}